@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/spf13/pflag"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/cmd/sfa-validate/validatecmd"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/fformat"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/version"
+)
+
+// Exit codes.
+const (
+	exitValid   = 0 // All checks passed.
+	exitInvalid = 1 // One or more checks failed.
+	exitError   = 2 // Validation couldn't be completed.
+)
+
+func main() {
+	p := pflag.NewFlagSet(validatecmd.Name, pflag.ContinueOnError)
+	p.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags] <SIP directory or archive>\n\n", validatecmd.Name)
+		fmt.Fprintln(os.Stderr, "Runs the preprocessing validation checks against a SIP without Temporal.")
+		fmt.Fprintln(os.Stderr)
+		p.PrintDefaults()
+	}
+	p.String("allowlist", "", "Allowed file formats CSV file (required)")
+	p.String("verapdf", "", "veraPDF command path, PDF/A validation is skipped when empty")
+	p.String("jhove", "", "JHOVE command path, image, audio and XML validation is skipped when empty")
+	p.Bool("builtin", true, "Validate the formats not validated by veraPDF or JHOVE with the built-in validators")
+	p.String("signatures", "", "Siegfried signature file, the embedded signatures are used when empty")
+	p.String("ambiguous", "best", `Ambiguous and unknown file format policy ("best", "warn" or "fail")`)
+	p.Int("identify-concurrency", fformat.DefaultConcurrency, "Number of files identified concurrently")
+	p.String("format", "text", `Report format ("text" or "json")`)
+	p.Bool("version", false, "Show version information")
+	if err := p.Parse(os.Args[1:]); err == flag.ErrHelp || err == pflag.ErrHelp {
+		os.Exit(exitValid)
+	} else if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitError)
+	}
+
+	if v, _ := p.GetBool("version"); v {
+		fmt.Println(version.Info(validatecmd.Name))
+		os.Exit(exitValid)
+	}
+
+	if p.NArg() != 1 {
+		p.Usage()
+		os.Exit(exitError)
+	}
+
+	format, _ := p.GetString("format")
+	if format != "text" && format != "json" {
+		fmt.Fprintf(os.Stderr, "Invalid report format: %q\n", format)
+		os.Exit(exitError)
+	}
+
+	var cfg validatecmd.Config
+	cfg.FileFormat.AllowlistPath, _ = p.GetString("allowlist")
+	cfg.FileValidate.VeraPDF.Path, _ = p.GetString("verapdf")
+	cfg.FileValidate.JHOVE.Path, _ = p.GetString("jhove")
+	cfg.FileValidate.Builtin.Enabled, _ = p.GetBool("builtin")
+	cfg.FileIdentify.SignatureFile, _ = p.GetString("signatures")
+	ambiguous, _ := p.GetString("ambiguous")
+	cfg.FileIdentify.Ambiguous = fformat.AmbiguityPolicy(ambiguous)
+	cfg.FileIdentify.Concurrency, _ = p.GetInt("identify-concurrency")
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration: %v\n", err)
+		os.Exit(exitError)
+	}
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+
+	m, err := validatecmd.NewMain(cfg)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration: %v\n", err)
+		os.Exit(exitError)
+	}
+
+	report, err := m.Run(ctx, p.Arg(0))
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Validation couldn't be completed: %v\n", err)
+		os.Exit(exitError)
+	}
+
+	if format == "json" {
+		err = report.WriteJSON(os.Stdout)
+	} else {
+		err = report.WriteText(os.Stdout)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(exitError)
+	}
+
+	if !report.Valid() {
+		os.Exit(exitInvalid)
+	}
+}
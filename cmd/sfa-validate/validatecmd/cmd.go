@@ -0,0 +1,297 @@
+package validatecmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/artefactual-sdps/temporal-activities/archiveextract"
+	"github.com/artefactual-sdps/temporal-activities/bagvalidate"
+	"github.com/artefactual-sdps/temporal-activities/ffvalidate"
+	"github.com/artefactual-sdps/temporal-activities/xmlvalidate"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/activities"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/fformat"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/fvalidate"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/localact"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/sip"
+)
+
+const Name = "sfa-validate"
+
+type Config struct {
+	// FileFormat configures the disallowed file format check. AllowlistPath
+	// is required.
+	FileFormat ffvalidate.Config
+
+	// FileIdentify configures the file format identification, the embedded
+	// Siegfried signatures are used when SignatureFile is empty.
+	FileIdentify fformat.Config
+
+	// FileValidate configures the file format validators. File validation
+	// with veraPDF or JHOVE is skipped when its path is empty, and with the
+	// built-in validators unless Builtin.Enabled is set.
+	FileValidate fvalidate.Config
+
+	// NamingRules are the SIP naming rules by SIP type, the default SFA
+	// naming rules are used when nil.
+	NamingRules sip.NamingRules
+}
+
+func (c Config) Validate() error {
+	if c.FileFormat.AllowlistPath == "" {
+		return errors.New("missing required value: FileFormat.AllowlistPath")
+	}
+
+	if err := c.FileIdentify.Validate(); err != nil {
+		return fmt.Errorf("FileIdentify: %v", err)
+	}
+
+	return nil
+}
+
+// Main runs the same validation activities as the preprocessing workflow,
+// calling them directly instead of going through Temporal.
+type Main struct {
+	copySIP         *activities.CopySIP
+	extract         *archiveextract.Activity
+	validateBag     *bagvalidate.Activity
+	unbag           *activities.Unbag
+	identifySIP     *activities.IdentifySIP
+	createInventory *activities.CreateInventory
+	validateStruct  *activities.ValidateStructure
+	validateName    *activities.ValidateSIPName
+	verifyManifest  *activities.VerifyManifest
+	validateFormats *ffvalidate.Activity
+	validateFiles   *activities.ValidateFiles
+	validateXML     *xmlvalidate.Activity
+	validatePREMIS  *activities.ValidatePREMIS
+	validateDigit   *activities.ValidateDigitizationPREMIS
+}
+
+// NewMain returns a Main running the validation checks configured by cfg, or an
+// error if the Siegfried signature file can't be loaded.
+func NewMain(cfg Config) (*Main, error) {
+	xmlValidator := xmlvalidate.NewXMLLintValidator()
+	namingRules := cfg.NamingRules
+	if namingRules == nil {
+		namingRules = sip.DefaultNamingRules()
+	}
+
+	identifier, err := fformat.NewSiegfriedPool(cfg.FileIdentify)
+	if err != nil {
+		return nil, err
+	}
+
+	validators := []fvalidate.Validator{
+		fvalidate.NewVeraPDFValidator(cfg.FileValidate.VeraPDF.Path, cfg.FileValidate.VeraPDF.Timeout),
+	}
+	if cfg.FileValidate.JHOVE.Path != "" {
+		validators = append(
+			validators,
+			fvalidate.NewJHOVEValidator(cfg.FileValidate.JHOVE.Path, cfg.FileValidate.JHOVE.Timeout),
+		)
+	}
+	if cfg.FileValidate.Builtin.Enabled {
+		validators = append(validators, fvalidate.BuiltinValidatorsExcept(validators...)...)
+	}
+
+	return &Main{
+		copySIP:         activities.NewCopySIP(),
+		extract:         archiveextract.New(archiveextract.Config{}),
+		validateBag:     bagvalidate.New(nil),
+		unbag:           activities.NewUnbag(),
+		identifySIP:     activities.NewIdentifySIP(),
+		createInventory: activities.NewCreateInventory(identifier, cfg.FileIdentify.Policy()),
+		validateStruct:  activities.NewValidateStructure(),
+		validateName:    activities.NewValidateSIPName(namingRules),
+		verifyManifest:  activities.NewVerifyManifest(0),
+		validateFormats: ffvalidate.New(cfg.FileFormat),
+		validateFiles:   activities.NewValidateFiles(identifier, cfg.FileValidate.Concurrency, validators...),
+		validateXML:     xmlvalidate.New(xmlValidator),
+		validatePREMIS:  activities.NewValidatePREMIS(xmlValidator),
+		validateDigit:   activities.NewValidateDigitizationPREMIS(identifier),
+	}, nil
+}
+
+// Run validates the SIP directory or archive at path and returns a report of
+// all the checks performed. An error is returned only when a check couldn't
+// be completed, failed checks are listed in the report.
+//
+// The checks run against a temporary working copy of the SIP, so path is
+// never modified.
+func (m *Main) Run(ctx context.Context, path string) (*Report, error) {
+	workDir, err := os.MkdirTemp("", Name+"-*")
+	if err != nil {
+		return nil, fmt.Errorf("create working directory: %v", err)
+	}
+	defer os.RemoveAll(workDir)
+
+	report := &Report{Path: path}
+
+	localPath, extracted, err := m.workingCopy(ctx, workDir, path)
+	if err != nil {
+		return nil, err
+	}
+
+	// Like the preprocessing workflow, check that the archive extracts to a
+	// top-level folder named after it, and carry on with the checks if not.
+	if extracted {
+		var failures []string
+		if msg := sip.TopLevelDirFailure(localPath, filepath.Base(path)); msg != "" {
+			failures = append(failures, msg)
+		}
+		report.add("Extract SIP", failures...)
+	}
+
+	isBag, err := localact.IsBag(ctx, &localact.IsBagParams{Path: localPath})
+	if err != nil {
+		return nil, fmt.Errorf("bag check: %v", err)
+	}
+	if isBag.IsBag {
+		res, err := m.validateBag.Execute(ctx, &bagvalidate.Params{Path: localPath})
+		if err != nil {
+			return nil, fmt.Errorf("validate bag: %v", err)
+		}
+		var failures []string
+		if res.Error != "" {
+			failures = []string{res.Error}
+		}
+		report.add("Validate Bag", failures...)
+
+		unbagged, err := m.unbag.Execute(ctx, &activities.UnbagParams{Path: localPath})
+		if err != nil {
+			return nil, fmt.Errorf("unbag: %v", err)
+		}
+		localPath = unbagged.Path
+	}
+
+	identified, err := m.identifySIP.Execute(ctx, &activities.IdentifySIPParams{Path: localPath})
+	if err != nil {
+		// The preprocessing workflow stops when the SIP type is unknown, as
+		// none of the remaining checks can be run.
+		report.add(
+			"Identify SIP structure",
+			"The package type could not be identified, please ensure that the SIP matches one of the supported package structures",
+		)
+		return report, nil
+	}
+	s := identified.SIP
+	report.Type = s.Type.String()
+	report.add("Identify SIP structure")
+
+	inventory, err := m.createInventory.Execute(ctx, &activities.CreateInventoryParams{SIP: s})
+	if err != nil {
+		return nil, fmt.Errorf("create inventory: %v", err)
+	}
+
+	structure, err := m.validateStruct.Execute(ctx, &activities.ValidateStructureParams{SIP: s})
+	if err != nil {
+		return nil, fmt.Errorf("validate structure: %v", err)
+	}
+	report.add("Validate SIP structure", structure.Failures...)
+
+	name, err := m.validateName.Execute(ctx, &activities.ValidateSIPNameParams{SIP: s})
+	if err != nil {
+		return nil, fmt.Errorf("validate SIP name: %v", err)
+	}
+	report.add("Validate SIP name", name.Failures...)
+
+	manifest, err := m.verifyManifest.Execute(ctx, &activities.VerifyManifestParams{SIP: s})
+	if err != nil {
+		return nil, fmt.Errorf("verify manifest: %v", err)
+	}
+	report.add("Verify SIP manifest", slices.Concat(
+		manifest.ManifestFailures,
+		manifest.MissingFiles,
+		manifest.UnexpectedFiles,
+	)...)
+	report.add("Verify SIP checksums", manifest.ChecksumFailures...)
+
+	report.add("Identify SIP file formats", inventory.Failures...)
+	report.warn(inventory.Warnings...)
+
+	if s.IsDigitized() {
+		digitization, err := m.validateDigit.Execute(ctx, &activities.ValidateDigitizationPREMISParams{SIP: s})
+		if err != nil {
+			return nil, fmt.Errorf("verify digitization PREMIS: %v", err)
+		}
+		report.add("Verify digitization PREMIS", digitization.Failures...)
+	}
+
+	if s.IsSIP() {
+		formats, err := m.validateFormats.Execute(ctx, &ffvalidate.Params{Path: s.ContentPath})
+		if err != nil {
+			return nil, fmt.Errorf("check file formats: %v", err)
+		}
+		report.add("Check for disallowed file formats", formats.Failures...)
+	}
+
+	files, err := m.validateFiles.Execute(ctx, &activities.ValidateFilesParams{SIP: s})
+	if err != nil {
+		return nil, fmt.Errorf("validate files: %v", err)
+	}
+	report.add("Validate SIP file formats", files.Failures...)
+
+	metadata, err := m.validateXML.Execute(ctx, &xmlvalidate.Params{
+		XMLPath: s.ManifestPath,
+		XSDPath: s.XSDPath,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("validate metadata: %v", err)
+	}
+	report.add("Validate SIP metadata", relFailures(s, metadata.Failures)...)
+
+	if s.IsAIP() {
+		premis, err := m.validatePREMIS.Execute(ctx, &activities.ValidatePREMISParams{
+			Path:    s.LogicalMDPath,
+			SIPPath: s.Path,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("validate logical metadata: %v", err)
+		}
+		report.add("Validate logical metadata", premis.Failures...)
+	}
+
+	return report, nil
+}
+
+// workingCopy copies the SIP at path to workDir, extracting it if it is an
+// archive, and returns the path of the copied SIP directory and true if it was
+// extracted. The copy keeps the original base name, as it is used to validate
+// the SIP name.
+func (m *Main) workingCopy(ctx context.Context, workDir, path string) (string, bool, error) {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return "", false, err
+	}
+
+	res, err := m.copySIP.Execute(ctx, &activities.CopySIPParams{Path: filepath.Clean(path), DestPath: workDir})
+	if err != nil {
+		return "", false, err
+	}
+	if fi.IsDir() {
+		return res.Path, false, nil
+	}
+
+	extract, err := m.extract.Execute(ctx, &archiveextract.Params{SourcePath: res.Path})
+	if err != nil {
+		return "", false, fmt.Errorf("extract SIP: %v", err)
+	}
+
+	return extract.ExtractPath, true, nil
+}
+
+// relFailures strips the SIP path from the XML validation failures, as done
+// by the preprocessing workflow.
+func relFailures(s sip.SIP, failures []string) []string {
+	for idx, f := range failures {
+		failures[idx] = strings.ReplaceAll(f, s.Path+"/", "")
+	}
+
+	return failures
+}
@@ -0,0 +1,99 @@
+package validatecmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Report lists the outcome of every check run against a SIP.
+type Report struct {
+	Path   string  `json:"path"`
+	Type   string  `json:"type,omitempty"`
+	Checks []Check `json:"checks"`
+}
+
+// Check is a single validation check, named after the matching preprocessing
+// workflow task. A check without failures has passed, warnings don't fail it.
+type Check struct {
+	Name     string   `json:"name"`
+	Failures []string `json:"failures,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+func (r *Report) add(name string, failures ...string) {
+	r.Checks = append(r.Checks, Check{Name: name, Failures: failures})
+}
+
+// warn adds warnings to the last check of the report.
+func (r *Report) warn(warnings ...string) {
+	if len(r.Checks) > 0 {
+		r.Checks[len(r.Checks)-1].Warnings = warnings
+	}
+}
+
+// Valid returns true if all the checks have passed.
+func (r *Report) Valid() bool {
+	return r.failed() == 0
+}
+
+func (r *Report) failed() int {
+	var n int
+	for _, c := range r.Checks {
+		if len(c.Failures) > 0 {
+			n++
+		}
+	}
+
+	return n
+}
+
+// WriteText writes a human readable version of the report to w.
+func (r *Report) WriteText(w io.Writer) error {
+	sipType := r.Type
+	if sipType == "" {
+		sipType = "unknown type"
+	}
+	if _, err := fmt.Fprintf(w, "%s (%s)\n\n", r.Path, sipType); err != nil {
+		return err
+	}
+
+	for _, c := range r.Checks {
+		outcome := "PASS"
+		if len(c.Failures) > 0 {
+			outcome = "FAIL"
+		}
+		if _, err := fmt.Fprintf(w, "%s  %s\n", outcome, c.Name); err != nil {
+			return err
+		}
+		for _, f := range c.Failures {
+			if _, err := fmt.Fprintf(w, "      - %s\n", f); err != nil {
+				return err
+			}
+		}
+		for _, warning := range c.Warnings {
+			if _, err := fmt.Fprintf(w, "      - Warning: %s\n", warning); err != nil {
+				return err
+			}
+		}
+	}
+
+	if r.Valid() {
+		_, err := fmt.Fprintf(w, "\nThe SIP passed all %d checks.\n", len(r.Checks))
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\nThe SIP failed %d of %d checks.\n", r.failed(), len(r.Checks))
+
+	return err
+}
+
+// WriteJSON writes the report to w as JSON.
+func (r *Report) WriteJSON(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(struct {
+		*Report
+		Valid bool `json:"valid"`
+	}{r, r.Valid()})
+}
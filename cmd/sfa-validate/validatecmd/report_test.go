@@ -0,0 +1,177 @@
+package validatecmd_test
+
+import (
+	"bytes"
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/cmd/sfa-validate/validatecmd"
+)
+
+func TestReport(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name      string
+		report    validatecmd.Report
+		wantValid bool
+		wantText  string
+		wantJSON  string
+	}{
+		{
+			name: "Reports a valid SIP",
+			report: validatecmd.Report{
+				Path: "/tmp/SIP_20201201_Vecteur",
+				Type: "DigitizedSIP",
+				Checks: []validatecmd.Check{
+					{Name: "Identify SIP structure"},
+					{Name: "Validate SIP name"},
+				},
+			},
+			wantValid: true,
+			wantText: `/tmp/SIP_20201201_Vecteur (DigitizedSIP)
+
+PASS  Identify SIP structure
+PASS  Validate SIP name
+
+The SIP passed all 2 checks.
+`,
+			wantJSON: `{
+  "path": "/tmp/SIP_20201201_Vecteur",
+  "type": "DigitizedSIP",
+  "checks": [
+    {
+      "name": "Identify SIP structure"
+    },
+    {
+      "name": "Validate SIP name"
+    }
+  ],
+  "valid": true
+}
+`,
+		},
+		{
+			name: "Reports an invalid SIP",
+			report: validatecmd.Report{
+				Path: "/tmp/SIP_20201201",
+				Type: "DigitizedSIP",
+				Checks: []validatecmd.Check{
+					{Name: "Identify SIP structure"},
+					{
+						Name:     "Validate SIP name",
+						Failures: []string{`SIP name "SIP_20201201" violates naming standard`},
+					},
+				},
+			},
+			wantText: `/tmp/SIP_20201201 (DigitizedSIP)
+
+PASS  Identify SIP structure
+FAIL  Validate SIP name
+      - SIP name "SIP_20201201" violates naming standard
+
+The SIP failed 1 of 2 checks.
+`,
+			wantJSON: `{
+  "path": "/tmp/SIP_20201201",
+  "type": "DigitizedSIP",
+  "checks": [
+    {
+      "name": "Identify SIP structure"
+    },
+    {
+      "name": "Validate SIP name",
+      "failures": [
+        "SIP name \"SIP_20201201\" violates naming standard"
+      ]
+    }
+  ],
+  "valid": false
+}
+`,
+		},
+		{
+			name: "Reports warnings without failing the check",
+			report: validatecmd.Report{
+				Path: "/tmp/SIP_20201201_Vecteur",
+				Type: "DigitizedSIP",
+				Checks: []validatecmd.Check{
+					{
+						Name:     "Identify SIP file formats",
+						Warnings: []string{`"content/d_0000001/data.bin" file format could not be identified: no match`},
+					},
+				},
+			},
+			wantValid: true,
+			wantText: `/tmp/SIP_20201201_Vecteur (DigitizedSIP)
+
+PASS  Identify SIP file formats
+      - Warning: "content/d_0000001/data.bin" file format could not be identified: no match
+
+The SIP passed all 1 checks.
+`,
+			wantJSON: `{
+  "path": "/tmp/SIP_20201201_Vecteur",
+  "type": "DigitizedSIP",
+  "checks": [
+    {
+      "name": "Identify SIP file formats",
+      "warnings": [
+        "\"content/d_0000001/data.bin\" file format could not be identified: no match"
+      ]
+    }
+  ],
+  "valid": true
+}
+`,
+		},
+		{
+			name: "Reports an unidentified SIP",
+			report: validatecmd.Report{
+				Path: "/tmp/unknown",
+				Checks: []validatecmd.Check{
+					{
+						Name:     "Identify SIP structure",
+						Failures: []string{"The package type could not be identified"},
+					},
+				},
+			},
+			wantText: `/tmp/unknown (unknown type)
+
+FAIL  Identify SIP structure
+      - The package type could not be identified
+
+The SIP failed 1 of 1 checks.
+`,
+			wantJSON: `{
+  "path": "/tmp/unknown",
+  "checks": [
+    {
+      "name": "Identify SIP structure",
+      "failures": [
+        "The package type could not be identified"
+      ]
+    }
+  ],
+  "valid": false
+}
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.report.Valid(), tt.wantValid)
+
+			var text bytes.Buffer
+			assert.NilError(t, tt.report.WriteText(&text))
+			assert.Equal(t, text.String(), tt.wantText)
+
+			var js bytes.Buffer
+			assert.NilError(t, tt.report.WriteJSON(&js))
+			assert.Equal(t, js.String(), tt.wantJSON)
+		})
+	}
+}
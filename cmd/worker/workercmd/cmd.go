@@ -35,6 +35,8 @@ import (
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/persistence"
 	entclient "github.com/artefactual-sdps/preprocessing-sfa/internal/persistence/ent/client"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/persistence/ent/db"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/progress"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/toolinfo"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/workflows"
 )
 
@@ -46,12 +48,17 @@ type Main struct {
 	temporalWorker temporalsdk_worker.Worker
 	temporalClient temporalsdk_client.Client
 	dbClient       *db.Client
+
+	// progress keeps the progress reported by the activities, it's read by
+	// the workflows progress query.
+	progress *progress.Store
 }
 
 func NewMain(logger logr.Logger, cfg config.Config) *Main {
 	return &Main{
-		logger: logger,
-		cfg:    cfg,
+		logger:   logger,
+		cfg:      cfg,
+		progress: progress.NewStore(),
 	}
 }
 
@@ -70,6 +77,8 @@ func (m *Main) Run(ctx context.Context) error {
 	w := temporalsdk_worker.New(m.temporalClient, m.cfg.Worker.TaskQueue, temporalsdk_worker.Options{
 		EnableSessionWorker:               true,
 		MaxConcurrentSessionExecutionSize: m.cfg.Worker.MaxConcurrentSessions,
+		// Allow activities to record their progress for the progress query.
+		BackgroundActivityContext: progress.WithStore(context.Background(), m.progress),
 		Interceptors: []temporalsdk_interceptor.WorkerInterceptor{
 			temporal.NewLoggerInterceptor(m.logger),
 		},
@@ -107,7 +116,49 @@ func (m *Main) Run(ctx context.Context) error {
 		psvc = entclient.New(m.dbClient)
 	}
 
-	veraPDFValidator := fvalidate.NewVeraPDFValidator(m.cfg.Preprocessing.FileValidate.VeraPDF.Path)
+	// The pool of Siegfried instances is created once and shared by the
+	// activities identifying file formats.
+	identifier, err := fformat.NewSiegfriedPool(m.cfg.Preprocessing.FileIdentify)
+	if err != nil {
+		m.logger.Error(err, "Unable to create file format identifier.")
+		return err
+	}
+
+	fvCfg := m.cfg.Preprocessing.FileValidate
+	validators := []fvalidate.Validator{
+		fvalidate.NewVeraPDFValidator(fvCfg.VeraPDF.Path, fvCfg.VeraPDF.Timeout),
+	}
+	// JHOVE is optional, only add it when configured so the premis.xml doesn't
+	// record JHOVE validation events that didn't happen.
+	if fvCfg.JHOVE.Path != "" {
+		validators = append(validators, fvalidate.NewJHOVEValidator(fvCfg.JHOVE.Path, fvCfg.JHOVE.Timeout))
+	}
+	for _, cfg := range fvCfg.Tools {
+		v, err := fvalidate.NewToolValidator(cfg)
+		if err != nil {
+			m.logger.Error(err, "Unable to create file validator.", "name", cfg.Name)
+			return err
+		}
+		validators = append(validators, v)
+	}
+	// The built-in validators only validate the formats that no external
+	// validator validates, to avoid reporting the same failures twice.
+	if fvCfg.Builtin.Enabled {
+		validators = append(validators, fvalidate.BuiltinValidatorsExcept(validators...)...)
+	}
+
+	// Discover the tool versions once, so validation tasks don't run a version
+	// command for each SIP.
+	for i, v := range validators {
+		validators[i] = fvalidate.NewCachedValidator(ctx, v)
+	}
+	tools := toolinfo.Discover(ctx)
+	m.logger.Info(
+		"Validation tools found.",
+		"bagit", tools.BagIt.String(),
+		"xmllint", tools.XMLLint.String(),
+		"siegfried", tools.Siegfried.String(),
+	)
 
 	// Set up APIS client.
 	var apisClient apis.Client
@@ -133,7 +184,7 @@ func (m *Main) Run(ctx context.Context) error {
 		return fmt.Errorf("unable to create Storage Service client: %w", err)
 	}
 
-	m.registerPreprocessingWorkflow(psvc, apisClient, veraPDFValidator)
+	m.registerPreprocessingWorkflow(psvc, apisClient, identifier, validators, tools)
 	m.registerPoststorageWorkflow(ssClient.Packages(), apisClient)
 
 	if err := w.Start(); err != nil {
@@ -167,12 +218,20 @@ func (m *Main) Close() error {
 func (m *Main) registerPreprocessingWorkflow(
 	psvc persistence.Service,
 	apisClient apis.Client,
-	veraPDFValidator fvalidate.Validator,
+	identifier fformat.Identifier,
+	validators []fvalidate.Validator,
+	tools toolinfo.Tools,
 ) {
 	m.temporalWorker.RegisterWorkflowWithOptions(
-		workflows.NewPreprocessing(psvc, m.cfg.Preprocessing, m.cfg.APIS.Enabled).Execute,
+		workflows.NewPreprocessing(psvc, m.cfg.Preprocessing, m.cfg.APIS.Enabled, tools, m.progress).Execute,
 		temporalsdk_workflow.RegisterOptions{Name: m.cfg.Preprocessing.WorkflowName},
 	)
+	if m.cfg.Preprocessing.ValidationWorkflowName != "" {
+		m.temporalWorker.RegisterWorkflowWithOptions(
+			workflows.NewValidation(m.cfg.Preprocessing, tools, m.progress).Execute,
+			temporalsdk_workflow.RegisterOptions{Name: m.cfg.Preprocessing.ValidationWorkflowName},
+		)
+	}
 
 	m.temporalWorker.RegisterActivityWithOptions(
 		activities.NewChecksumSIP().Execute,
@@ -194,16 +253,20 @@ func (m *Main) registerPreprocessingWorkflow(
 		activities.NewIdentifySIP().Execute,
 		temporalsdk_activity.RegisterOptions{Name: activities.IdentifySIPName},
 	)
+	m.temporalWorker.RegisterActivityWithOptions(
+		activities.NewCreateInventory(identifier, m.cfg.Preprocessing.FileIdentify.Policy()).Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.CreateInventoryName},
+	)
 	m.temporalWorker.RegisterActivityWithOptions(
 		activities.NewValidateStructure().Execute,
 		temporalsdk_activity.RegisterOptions{Name: activities.ValidateStructureName},
 	)
 	m.temporalWorker.RegisterActivityWithOptions(
-		activities.NewValidateSIPName().Execute,
+		activities.NewValidateSIPName(m.cfg.Preprocessing.Naming.Rules()).Execute,
 		temporalsdk_activity.RegisterOptions{Name: activities.ValidateSIPNameName},
 	)
 	m.temporalWorker.RegisterActivityWithOptions(
-		activities.NewVerifyManifest().Execute,
+		activities.NewVerifyManifest(m.cfg.Preprocessing.ChecksumConcurrency).Execute,
 		temporalsdk_activity.RegisterOptions{Name: activities.VerifyManifestName},
 	)
 	m.temporalWorker.RegisterActivityWithOptions(
@@ -212,13 +275,14 @@ func (m *Main) registerPreprocessingWorkflow(
 	)
 	m.temporalWorker.RegisterActivityWithOptions(
 		activities.NewValidateFiles(
-			fformat.NewSiegfriedEmbed(),
-			veraPDFValidator,
+			identifier,
+			m.cfg.Preprocessing.FileValidate.Concurrency,
+			validators...,
 		).Execute,
 		temporalsdk_activity.RegisterOptions{Name: activities.ValidateFilesName},
 	)
 	m.temporalWorker.RegisterActivityWithOptions(
-		activities.NewAddPREMISObjects(rand.Reader).Execute,
+		activities.NewAddPREMISObjects(clockwork.NewRealClock(), rand.Reader, identifier).Execute,
 		temporalsdk_activity.RegisterOptions{Name: activities.AddPREMISObjectsName},
 	)
 	m.temporalWorker.RegisterActivityWithOptions(
@@ -233,7 +297,7 @@ func (m *Main) registerPreprocessingWorkflow(
 		activities.NewAddPREMISValidationEvent(
 			clockwork.NewRealClock(),
 			rand.Reader,
-			veraPDFValidator,
+			validators...,
 		).Execute,
 		temporalsdk_activity.RegisterOptions{Name: activities.AddPREMISValidationEventName},
 	)
@@ -245,6 +309,10 @@ func (m *Main) registerPreprocessingWorkflow(
 		activities.NewValidatePREMIS(xmlvalidate.NewXMLLintValidator()).Execute,
 		temporalsdk_activity.RegisterOptions{Name: activities.ValidatePREMISName},
 	)
+	m.temporalWorker.RegisterActivityWithOptions(
+		activities.NewValidateDigitizationPREMIS(identifier).Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.ValidateDigitizationPREMISName},
+	)
 	m.temporalWorker.RegisterActivityWithOptions(
 		activities.NewTransformSIP().Execute,
 		temporalsdk_activity.RegisterOptions{Name: activities.TransformSIPName},
@@ -253,6 +321,22 @@ func (m *Main) registerPreprocessingWorkflow(
 		activities.NewWriteIdentifierFile().Execute,
 		temporalsdk_activity.RegisterOptions{Name: activities.WriteIdentifierFileName},
 	)
+	m.temporalWorker.RegisterActivityWithOptions(
+		activities.NewWriteValidationReport().Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.WriteValidationReportName},
+	)
+	m.temporalWorker.RegisterActivityWithOptions(
+		activities.NewWriteFailedPREMIS(rand.Reader).Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.WriteFailedPREMISName},
+	)
+	m.temporalWorker.RegisterActivityWithOptions(
+		activities.NewMoveFailedSIP().Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.MoveFailedSIPName},
+	)
+	m.temporalWorker.RegisterActivityWithOptions(
+		activities.NewCopySIP().Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.CopySIPName},
+	)
 	m.temporalWorker.RegisterActivityWithOptions(
 		apis.NewCreateImportTaskActivity(apisClient).Execute,
 		temporalsdk_activity.RegisterOptions{Name: apis.CreateImportTaskActivityName},
@@ -261,6 +345,10 @@ func (m *Main) registerPreprocessingWorkflow(
 		apis.NewPollImportTaskStatusActivity(apisClient, m.cfg.APIS.PollInterval).Execute,
 		temporalsdk_activity.RegisterOptions{Name: apis.PollImportTaskStatusActivityName},
 	)
+	m.temporalWorker.RegisterActivityWithOptions(
+		apis.NewCancelImportTaskActivity(apisClient).Execute,
+		temporalsdk_activity.RegisterOptions{Name: apis.CancelImportTaskActivityName},
+	)
 	m.temporalWorker.RegisterActivityWithOptions(
 		bagcreate.New(m.cfg.Preprocessing.BagCreate).Execute,
 		temporalsdk_activity.RegisterOptions{Name: bagcreate.Name},
@@ -269,7 +357,7 @@ func (m *Main) registerPreprocessingWorkflow(
 
 func (m *Main) registerPoststorageWorkflow(packages *ssclient.PackagesService, apisClient apis.Client) {
 	m.temporalWorker.RegisterWorkflowWithOptions(
-		workflows.NewPoststorage(m.cfg.Poststorage, m.cfg.APIS.Enabled).Execute,
+		workflows.NewPoststorage(m.cfg.Poststorage, m.cfg.APIS.Enabled, m.progress).Execute,
 		temporalsdk_workflow.RegisterOptions{Name: m.cfg.Poststorage.WorkflowName},
 	)
 
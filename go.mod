@@ -31,6 +31,7 @@ require (
 	go.temporal.io/sdk v1.40.0
 	go.uber.org/mock v0.6.0
 	gocloud.dev v0.45.0
+	golang.org/x/net v0.52.0
 	gotest.tools/v3 v3.5.2
 )
 
@@ -133,7 +134,6 @@ require (
 	golang.org/x/exp v0.0.0-20240325151524-a685a6edb6d8 // indirect
 	golang.org/x/image v0.39.0 // indirect
 	golang.org/x/mod v0.35.0 // indirect
-	golang.org/x/net v0.52.0 // indirect
 	golang.org/x/oauth2 v0.35.0 // indirect
 	golang.org/x/sync v0.20.0 // indirect
 	golang.org/x/sys v0.43.0 // indirect
@@ -156,6 +156,38 @@ func TestImportFailureIsSurfacedThroughTaskStatus(t *testing.T) {
 	})
 }
 
+func TestImportedTaskCannotBeCancelled(t *testing.T) {
+	ctx := t.Context()
+	h := newHandler()
+
+	taskID := createTask(t, ctx, h, "metadata.xml", "dev@example.com")
+	_ = getTaskStatus(t, ctx, h, taskID)
+	_ = getTaskStatus(t, ctx, h, taskID)
+	_ = getTaskStatus(t, ctx, h, taskID)
+	_ = createRun(t, ctx, h, taskID, "METS.xml", "")
+	_ = getTaskStatus(t, ctx, h, taskID)
+	status := getTaskStatus(t, ctx, h, taskID)
+	assert.Equal(t, status.Status, gen.ImportTaskStatusImportiert)
+
+	res, err := h.APIImporttasksIDCancelPost(
+		ctx,
+		&gen.CancelImportTaskRequest{
+			Reason:      gen.NewOptNilString("Ingest was stopped by a system error."),
+			CancelledBy: gen.NewOptNilString("sfa-enduro"),
+		},
+		gen.APIImporttasksIDCancelPostParams{ID: taskID},
+	)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, res, &gen.APIImporttasksIDCancelPostConflict{
+		Title:  gen.NewOptNilString("Conflict"),
+		Status: gen.NewOptNilInt32(409),
+		Detail: gen.NewOptNilString("cannot cancel an already imported task"),
+	})
+
+	status = getTaskStatus(t, ctx, h, taskID)
+	assert.Equal(t, status.Status, gen.ImportTaskStatusImportiert)
+}
+
 func TestDefaultResultsCanBeConfigured(t *testing.T) {
 	ctx := t.Context()
 	h := mock.NewHandler(gen.AnalysisResultAlleGleich, gen.ImportResultFehler)
@@ -6,9 +6,16 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
+	"time"
 
+	"github.com/beevik/etree"
 	"github.com/google/uuid"
+	"github.com/jonboulle/clockwork"
 
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/fformat"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/inventory"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/manifest"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/premis"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/sip"
 )
@@ -23,11 +30,28 @@ type AddPREMISObjectsParams struct {
 type AddPREMISObjectsResult struct{}
 
 type AddPREMISObjectsActivity struct {
+	// Clock for time-related operations, can be used to mock time in tests.
+	clock clockwork.Clock
+
+	// Random number generator for generating UUIDs. Can be set to a
+	// deterministic generator for testing purposes.
 	rng io.Reader
+
+	// identifier identifies the file formats missing from the SIP inventory,
+	// and is the agent of the format identification events.
+	identifier fformat.Identifier
 }
 
-func NewAddPREMISObjects(rand io.Reader) *AddPREMISObjectsActivity {
-	return &AddPREMISObjectsActivity{rng: rand}
+func NewAddPREMISObjects(
+	clock clockwork.Clock,
+	rng io.Reader,
+	identifier fformat.Identifier,
+) *AddPREMISObjectsActivity {
+	return &AddPREMISObjectsActivity{
+		clock:      clock,
+		rng:        rng,
+		identifier: identifier,
+	}
 }
 
 func (a *AddPREMISObjectsActivity) Execute(
@@ -35,10 +59,19 @@ func (a *AddPREMISObjectsActivity) Execute(
 	params *AddPREMISObjectsParams,
 ) (*AddPREMISObjectsResult, error) {
 	// Get subpaths of files in transfer.
-	subpaths, err := premis.FilesWithinDirectory(params.SIP.ContentPath)
+	inv, err := inventory.Load(params.SIP.Path)
 	if err != nil {
 		return nil, err
 	}
+	content := relPath(params.SIP.Path, params.SIP.ContentPath)
+	subpaths := inv.Files(content)
+
+	// Get the formats of the files, they are only identified again if the SIP
+	// inventory hasn't been created.
+	fileformats, err := inv.Formats(ctx, a.identifier, params.SIP)
+	if err != nil {
+		return nil, fmt.Errorf("identifyFormats: %v", err)
+	}
 
 	// Create parent directory, if necessary.
 	mdPath := filepath.Dir(params.PREMISFilePath)
@@ -57,6 +90,11 @@ func (a *AddPREMISObjectsActivity) Execute(
 	}
 	defer f.Close()
 
+	m, err := parseManifest(params.SIP, f)
+	if err != nil {
+		return nil, fmt.Errorf("parse manifest file: %v", err)
+	}
+
 	for _, subpath := range subpaths {
 		id, err := uuid.NewRandomFromReader(a.rng)
 		if err != nil {
@@ -67,6 +105,11 @@ func (a *AddPREMISObjectsActivity) Execute(
 			IdType:       "UUID",
 			IdValue:      id.String(),
 			OriginalName: premis.OriginalNameForSubpath(params.SIP, subpath),
+			Fixity:       objectFixity(m, inv, filepath.Join(content, subpath)),
+			Format:       objectFormat(fileformats[filepath.Join(params.SIP.ContentPath, subpath)]),
+		}
+		if e, ok := inv.Entry(filepath.Join(content, subpath)); ok {
+			object.Size = &e.Size
 		}
 
 		err = premis.AppendObjectXML(doc, object)
@@ -75,6 +118,15 @@ func (a *AddPREMISObjectsActivity) Execute(
 		}
 	}
 
+	// Link a format identification event to each object. The events are
+	// added once all the objects exist, as PREMIS requires the objects to be
+	// listed before the events.
+	if len(subpaths) > 0 {
+		if err := a.addFormatIdentificationEvents(doc, params.SIP, subpaths, fileformats); err != nil {
+			return nil, err
+		}
+	}
+
 	doc.Indent(2)
 	err = doc.WriteToFile(params.PREMISFilePath)
 	if err != nil {
@@ -83,3 +135,119 @@ func (a *AddPREMISObjectsActivity) Execute(
 
 	return &AddPREMISObjectsResult{}, nil
 }
+
+func (a *AddPREMISObjectsActivity) addFormatIdentificationEvents(
+	doc *etree.Document,
+	s sip.SIP,
+	subpaths []string,
+	fileformats fformat.FileFormats,
+) error {
+	PREMISEl := doc.FindElement("/premis:premis")
+	if PREMISEl == nil {
+		return fmt.Errorf("no root premis element found in document")
+	}
+
+	agent := a.identifier.PREMISAgent()
+
+	for _, subpath := range subpaths {
+		// Find PREMIS object element using original name.
+		originalName := premis.OriginalNameForSubpath(s, subpath)
+		objectOriginalNameEl := doc.FindElement(
+			fmt.Sprintf("/premis:premis/premis:object/premis:originalName[text()='%s']", originalName),
+		)
+		if objectOriginalNameEl == nil {
+			return fmt.Errorf("element not found")
+		}
+
+		id, err := uuid.NewRandomFromReader(a.rng)
+		if err != nil {
+			return fmt.Errorf("generate UUID: %v", err)
+		}
+
+		event := premis.Event{
+			Summary:      formatIdentificationSummary(fileformats[filepath.Join(s.ContentPath, subpath)]),
+			IdType:       "UUID",
+			IdValue:      id.String(),
+			DateTime:     a.clock.Now().Format(time.RFC3339),
+			AgentIdType:  agent.IdType,
+			AgentIdValue: agent.IdValue,
+		}
+
+		premis.AddEventElement(PREMISEl, event)
+		premis.LinkEventToObject(objectOriginalNameEl.Parent(), event)
+	}
+
+	if err := premis.AppendAgentXML(doc, agent); err != nil {
+		return fmt.Errorf("addAgent: %v", err)
+	}
+
+	return nil
+}
+
+// objectFixity returns the PREMIS object fixity of the file at path, relative
+// to the SIP root directory: the manifest checksum, and the FixityAlgorithm
+// checksum calculated when the SIP inventory was created.
+func objectFixity(m *manifest.Manifest, inv *inventory.Inventory, path string) []premis.Fixity {
+	var fixity []premis.Fixity
+
+	alg := ""
+	if f, ok := m.Files[path]; ok && f.Checksum.Hash != "" {
+		alg = f.Checksum.Algorithm
+		fixity = append(fixity, premis.Fixity{
+			Algorithm:  f.Checksum.Algorithm,
+			Digest:     f.Checksum.Hash,
+			Originator: "SIP manifest",
+		})
+	}
+
+	if sum, ok := inv.Checksum(path, FixityAlgorithm); ok && alg != FixityAlgorithm {
+		fixity = append(fixity, premis.Fixity{
+			Algorithm:  FixityAlgorithm,
+			Digest:     sum,
+			Originator: premis.AgentDefault().Name,
+		})
+	}
+
+	return fixity
+}
+
+// objectFormat returns the PREMIS object format for ff, or nil if the file
+// format wasn't identified.
+func objectFormat(ff *fformat.FileFormat) *premis.Format {
+	if !ff.Known() {
+		return nil
+	}
+
+	registry := ff.Namespace
+	if strings.EqualFold(registry, "pronom") {
+		registry = "PRONOM"
+	}
+
+	return &premis.Format{
+		Name:         ff.CommonName,
+		Version:      ff.Version,
+		RegistryName: registry,
+		RegistryKey:  ff.ID,
+		Basis:        ff.Basis,
+	}
+}
+
+func formatIdentificationSummary(ff *fformat.FileFormat) premis.EventSummary {
+	summary := premis.EventSummary{
+		Type:    "format identification",
+		Detail:  "name=\"Identify file format\"",
+		Outcome: "positive",
+	}
+
+	if ff.Known() {
+		summary.OutcomeDetail = ff.ID
+	} else {
+		summary.Outcome = "negative"
+		summary.OutcomeDetail = "No format identified"
+		if ff != nil && ff.Warning != "" {
+			summary.OutcomeDetail = ff.Warning
+		}
+	}
+
+	return summary
+}
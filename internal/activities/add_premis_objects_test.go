@@ -1,22 +1,49 @@
 package activities_test
 
 import (
+	"errors"
 	"fmt"
 	pseudorand "math/rand"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/jonboulle/clockwork"
 	temporalsdk_activity "go.temporal.io/sdk/activity"
 	temporalsdk_testsuite "go.temporal.io/sdk/testsuite"
+	"go.uber.org/mock/gomock"
 	"gotest.tools/v3/assert"
 	"gotest.tools/v3/fs"
 
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/activities"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/fformat"
+	fake_fformat "github.com/artefactual-sdps/preprocessing-sfa/internal/fformat/fake"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/premis"
 )
 
 func TestAddPREMISObjects(t *testing.T) {
 	t.Parallel()
 
+	jp2 := &fformat.FileFormat{
+		Namespace:  "pronom",
+		ID:         "x-fmt/392",
+		CommonName: "JP2 (JPEG 2000 part 1)",
+		MIMEType:   "image/jp2",
+		Basis:      "extension match jp2; byte match at [[0 12] [20 4]]",
+	}
+	unknown := &fformat.FileFormat{
+		Namespace: "pronom",
+		ID:        "UNKNOWN",
+		Warning:   "no match; possibilities based on extension are fmt/101",
+	}
+	siegfriedAgent := premis.Agent{
+		Type:    "software",
+		Name:    "Siegfried 1.11.2 (signature file: default.sig)",
+		IdType:  "url",
+		IdValue: "https://github.com/richardlehane/siegfried",
+	}
+
 	// Normally populated files (for execution expected to work).
 	contentFilesNormal := fs.NewDir(t, "",
 		fs.WithDir("digitized_Vecteur_SIP",
@@ -57,6 +84,7 @@ func TestAddPREMISObjects(t *testing.T) {
 		name       string
 		params     activities.AddPREMISObjectsParams
 		result     activities.AddPREMISObjectsResult
+		mock       func(*fake_fformat.MockIdentifierMockRecorder)
 		wantPREMIS string
 		wantErr    string
 	}{
@@ -66,53 +94,21 @@ func TestAddPREMISObjects(t *testing.T) {
 				SIP:            testSIP(t, contentFilesNormal.Join("digitized_Vecteur_SIP")),
 				PREMISFilePath: premisFilePathNormal,
 			},
-			result: activities.AddPREMISObjectsResult{},
-		},
-		{
-			name: "Error when manifest is missing",
-			params: activities.AddPREMISObjectsParams{
-				SIP:            testSIP(t, contentNoFiles.Join("digitized_Vecteur_SIP")),
-				PREMISFilePath: premisFilePathNoFiles,
+			mock: func(m *fake_fformat.MockIdentifierMockRecorder) {
+				m.Identify(gomock.Any()).DoAndReturn(func(path string) (*fformat.FileFormat, error) {
+					switch filepath.Base(path) {
+					case "00000001.jp2", "00000002.jp2":
+						return jp2, nil
+					case "Prozess_Digitalisierung_PREMIS.xml":
+						return nil, errors.New("read error")
+					default:
+						return unknown, nil
+					}
+				}).Times(5)
+				m.PREMISAgent().Return(siegfriedAgent)
 			},
 			result: activities.AddPREMISObjectsResult{},
-			wantErr: fmt.Sprintf(
-				"open manifest file: open %s: no such file or directory",
-				contentNoFiles.Join("digitized_Vecteur_SIP", "header", "metadata.xml"),
-			),
-		},
-	}
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			t.Parallel()
-
-			ts := &temporalsdk_testsuite.WorkflowTestSuite{}
-			env := ts.NewTestActivityEnvironment()
-			rng := pseudorand.New(pseudorand.NewSource(1)) // #nosec G404
-			env.RegisterActivityWithOptions(
-				activities.NewAddPREMISObjects(rng).Execute,
-				temporalsdk_activity.RegisterOptions{Name: activities.AddPREMISObjectsName},
-			)
-
-			var res activities.AddPREMISObjectsResult
-			future, err := env.ExecuteActivity(activities.AddPREMISObjectsName, tt.params)
-
-			if tt.wantErr != "" {
-				if err == nil {
-					t.Errorf("error is nil, expecting: %q", tt.wantErr)
-				} else {
-					assert.ErrorContains(t, err, tt.wantErr)
-				}
-
-				return
-			}
-			assert.NilError(t, err)
-
-			future.Get(&res)
-			assert.DeepEqual(t, res, tt.result)
-
-			b, err := os.ReadFile(tt.params.PREMISFilePath)
-			assert.NilError(t, err)
-			assert.Equal(t, string(b), `<?xml version="1.0" encoding="UTF-8"?>
+			wantPREMIS: `<?xml version="1.0" encoding="UTF-8"?>
 <premis:premis xmlns:premis="http://www.loc.gov/premis/v3" xmlns:xlink="http://www.w3.org/1999/xlink" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:schemaLocation="http://www.loc.gov/premis/v3 https://www.loc.gov/standards/premis/premis.xsd" version="3.0">
   <premis:object xsi:type="premis:file">
     <premis:objectIdentifier>
@@ -120,13 +116,29 @@ func TestAddPREMISObjects(t *testing.T) {
       <premis:objectIdentifierValue>52fdfc07-2182-454f-963f-5f0f9a621d72</premis:objectIdentifierValue>
     </premis:objectIdentifier>
     <premis:objectCharacteristics>
+      <premis:fixity>
+        <premis:messageDigestAlgorithm>MD5</premis:messageDigestAlgorithm>
+        <premis:messageDigest>827ccb0eea8a706c4c34a16891f84e7b</premis:messageDigest>
+        <premis:messageDigestOriginator>SIP manifest</premis:messageDigestOriginator>
+      </premis:fixity>
+      <premis:size>0</premis:size>
       <premis:format>
         <premis:formatDesignation>
-          <premis:formatName/>
+          <premis:formatName>JP2 (JPEG 2000 part 1)</premis:formatName>
         </premis:formatDesignation>
+        <premis:formatRegistry>
+          <premis:formatRegistryName>PRONOM</premis:formatRegistryName>
+          <premis:formatRegistryKey>x-fmt/392</premis:formatRegistryKey>
+          <premis:formatRegistryRole>specification</premis:formatRegistryRole>
+        </premis:formatRegistry>
+        <premis:formatNote>Identification basis: extension match jp2; byte match at [[0 12] [20 4]]</premis:formatNote>
       </premis:format>
     </premis:objectCharacteristics>
     <premis:originalName>data/objects/digitized_Vecteur_SIP/content/d_0000001/00000001.jp2</premis:originalName>
+    <premis:linkingEventIdentifier>
+      <premis:linkingEventIdentifierType>UUID</premis:linkingEventIdentifierType>
+      <premis:linkingEventIdentifierValue>95af5a25-3679-41ba-a2ff-6cd471c483f1</premis:linkingEventIdentifierValue>
+    </premis:linkingEventIdentifier>
   </premis:object>
   <premis:object xsi:type="premis:file">
     <premis:objectIdentifier>
@@ -134,6 +146,12 @@ func TestAddPREMISObjects(t *testing.T) {
       <premis:objectIdentifierValue>9566c74d-1003-4c4d-bbbb-0407d1e2c649</premis:objectIdentifierValue>
     </premis:objectIdentifier>
     <premis:objectCharacteristics>
+      <premis:fixity>
+        <premis:messageDigestAlgorithm>MD5</premis:messageDigestAlgorithm>
+        <premis:messageDigest>e80b5017098950fc58aad83c8c14978e</premis:messageDigest>
+        <premis:messageDigestOriginator>SIP manifest</premis:messageDigestOriginator>
+      </premis:fixity>
+      <premis:size>0</premis:size>
       <premis:format>
         <premis:formatDesignation>
           <premis:formatName/>
@@ -141,6 +159,10 @@ func TestAddPREMISObjects(t *testing.T) {
       </premis:format>
     </premis:objectCharacteristics>
     <premis:originalName>data/objects/digitized_Vecteur_SIP/content/d_0000001/00000001_PREMIS.xml</premis:originalName>
+    <premis:linkingEventIdentifier>
+      <premis:linkingEventIdentifierType>UUID</premis:linkingEventIdentifierType>
+      <premis:linkingEventIdentifierValue>5fb90bad-b37c-4821-b6d9-5526a41a9504</premis:linkingEventIdentifierValue>
+    </premis:linkingEventIdentifier>
   </premis:object>
   <premis:object xsi:type="premis:file">
     <premis:objectIdentifier>
@@ -148,13 +170,29 @@ func TestAddPREMISObjects(t *testing.T) {
       <premis:objectIdentifierValue>81855ad8-681d-4d86-91e9-1e00167939cb</premis:objectIdentifierValue>
     </premis:objectIdentifier>
     <premis:objectCharacteristics>
+      <premis:fixity>
+        <premis:messageDigestAlgorithm>MD5</premis:messageDigestAlgorithm>
+        <premis:messageDigest>1e01ba3e07ac48cbdab2d3284d1dd0fa</premis:messageDigest>
+        <premis:messageDigestOriginator>SIP manifest</premis:messageDigestOriginator>
+      </premis:fixity>
+      <premis:size>0</premis:size>
       <premis:format>
         <premis:formatDesignation>
-          <premis:formatName/>
+          <premis:formatName>JP2 (JPEG 2000 part 1)</premis:formatName>
         </premis:formatDesignation>
+        <premis:formatRegistry>
+          <premis:formatRegistryName>PRONOM</premis:formatRegistryName>
+          <premis:formatRegistryKey>x-fmt/392</premis:formatRegistryKey>
+          <premis:formatRegistryRole>specification</premis:formatRegistryRole>
+        </premis:formatRegistry>
+        <premis:formatNote>Identification basis: extension match jp2; byte match at [[0 12] [20 4]]</premis:formatNote>
       </premis:format>
     </premis:objectCharacteristics>
     <premis:originalName>data/objects/digitized_Vecteur_SIP/content/d_0000001/00000002.jp2</premis:originalName>
+    <premis:linkingEventIdentifier>
+      <premis:linkingEventIdentifierType>UUID</premis:linkingEventIdentifierType>
+      <premis:linkingEventIdentifierValue>680b4e7c-8b76-4a1b-9d49-d4955c848621</premis:linkingEventIdentifierValue>
+    </premis:linkingEventIdentifier>
   </premis:object>
   <premis:object xsi:type="premis:file">
     <premis:objectIdentifier>
@@ -162,6 +200,12 @@ func TestAddPREMISObjects(t *testing.T) {
       <premis:objectIdentifierValue>6694d2c4-22ac-4208-a007-2939487f6999</premis:objectIdentifierValue>
     </premis:objectIdentifier>
     <premis:objectCharacteristics>
+      <premis:fixity>
+        <premis:messageDigestAlgorithm>MD5</premis:messageDigestAlgorithm>
+        <premis:messageDigest>33f12195e0fc136bc17de332c6b92b0d</premis:messageDigest>
+        <premis:messageDigestOriginator>SIP manifest</premis:messageDigestOriginator>
+      </premis:fixity>
+      <premis:size>0</premis:size>
       <premis:format>
         <premis:formatDesignation>
           <premis:formatName/>
@@ -169,6 +213,10 @@ func TestAddPREMISObjects(t *testing.T) {
       </premis:format>
     </premis:objectCharacteristics>
     <premis:originalName>data/objects/digitized_Vecteur_SIP/content/d_0000001/00000002_PREMIS.xml</premis:originalName>
+    <premis:linkingEventIdentifier>
+      <premis:linkingEventIdentifierType>UUID</premis:linkingEventIdentifierType>
+      <premis:linkingEventIdentifierValue>6325253f-ec73-4dd7-a9e2-8bf921119c16</premis:linkingEventIdentifierValue>
+    </premis:linkingEventIdentifier>
   </premis:object>
   <premis:object xsi:type="premis:file">
     <premis:objectIdentifier>
@@ -176,6 +224,12 @@ func TestAddPREMISObjects(t *testing.T) {
       <premis:objectIdentifierValue>eb9d18a4-4784-445d-87f3-c67cf22746e9</premis:objectIdentifierValue>
     </premis:objectIdentifier>
     <premis:objectCharacteristics>
+      <premis:fixity>
+        <premis:messageDigestAlgorithm>MD5</premis:messageDigestAlgorithm>
+        <premis:messageDigest>816cabd1c0334ed363555889d9f4dbe4</premis:messageDigest>
+        <premis:messageDigestOriginator>SIP manifest</premis:messageDigestOriginator>
+      </premis:fixity>
+      <premis:size>0</premis:size>
       <premis:format>
         <premis:formatDesignation>
           <premis:formatName/>
@@ -183,9 +237,180 @@ func TestAddPREMISObjects(t *testing.T) {
       </premis:format>
     </premis:objectCharacteristics>
     <premis:originalName>data/metadata/Prozess_Digitalisierung_PREMIS.xml</premis:originalName>
+    <premis:linkingEventIdentifier>
+      <premis:linkingEventIdentifierType>UUID</premis:linkingEventIdentifierType>
+      <premis:linkingEventIdentifierValue>0f070244-8615-4bda-8831-3f6a8eb668d2</premis:linkingEventIdentifierValue>
+    </premis:linkingEventIdentifier>
   </premis:object>
+  <premis:event>
+    <premis:eventIdentifier>
+      <premis:eventIdentifierType>UUID</premis:eventIdentifierType>
+      <premis:eventIdentifierValue>95af5a25-3679-41ba-a2ff-6cd471c483f1</premis:eventIdentifierValue>
+    </premis:eventIdentifier>
+    <premis:eventType>format identification</premis:eventType>
+    <premis:eventDateTime>2025-06-06T09:57:16Z</premis:eventDateTime>
+    <premis:eventDetailInformation>
+      <premis:eventDetail>name=&quot;Identify file format&quot;</premis:eventDetail>
+    </premis:eventDetailInformation>
+    <premis:eventOutcomeInformation>
+      <premis:eventOutcome>positive</premis:eventOutcome>
+      <premis:eventOutcomeDetail>
+        <premis:eventOutcomeDetailNote>x-fmt/392</premis:eventOutcomeDetailNote>
+      </premis:eventOutcomeDetail>
+    </premis:eventOutcomeInformation>
+    <premis:linkingAgentIdentifier>
+      <premis:linkingAgentIdentifierType valueURI="http://id.loc.gov/vocabulary/identifiers/local">url</premis:linkingAgentIdentifierType>
+      <premis:linkingAgentIdentifierValue>https://github.com/richardlehane/siegfried</premis:linkingAgentIdentifierValue>
+    </premis:linkingAgentIdentifier>
+  </premis:event>
+  <premis:event>
+    <premis:eventIdentifier>
+      <premis:eventIdentifierType>UUID</premis:eventIdentifierType>
+      <premis:eventIdentifierValue>5fb90bad-b37c-4821-b6d9-5526a41a9504</premis:eventIdentifierValue>
+    </premis:eventIdentifier>
+    <premis:eventType>format identification</premis:eventType>
+    <premis:eventDateTime>2025-06-06T09:57:16Z</premis:eventDateTime>
+    <premis:eventDetailInformation>
+      <premis:eventDetail>name=&quot;Identify file format&quot;</premis:eventDetail>
+    </premis:eventDetailInformation>
+    <premis:eventOutcomeInformation>
+      <premis:eventOutcome>negative</premis:eventOutcome>
+      <premis:eventOutcomeDetail>
+        <premis:eventOutcomeDetailNote>no match; possibilities based on extension are fmt/101</premis:eventOutcomeDetailNote>
+      </premis:eventOutcomeDetail>
+    </premis:eventOutcomeInformation>
+    <premis:linkingAgentIdentifier>
+      <premis:linkingAgentIdentifierType valueURI="http://id.loc.gov/vocabulary/identifiers/local">url</premis:linkingAgentIdentifierType>
+      <premis:linkingAgentIdentifierValue>https://github.com/richardlehane/siegfried</premis:linkingAgentIdentifierValue>
+    </premis:linkingAgentIdentifier>
+  </premis:event>
+  <premis:event>
+    <premis:eventIdentifier>
+      <premis:eventIdentifierType>UUID</premis:eventIdentifierType>
+      <premis:eventIdentifierValue>680b4e7c-8b76-4a1b-9d49-d4955c848621</premis:eventIdentifierValue>
+    </premis:eventIdentifier>
+    <premis:eventType>format identification</premis:eventType>
+    <premis:eventDateTime>2025-06-06T09:57:16Z</premis:eventDateTime>
+    <premis:eventDetailInformation>
+      <premis:eventDetail>name=&quot;Identify file format&quot;</premis:eventDetail>
+    </premis:eventDetailInformation>
+    <premis:eventOutcomeInformation>
+      <premis:eventOutcome>positive</premis:eventOutcome>
+      <premis:eventOutcomeDetail>
+        <premis:eventOutcomeDetailNote>x-fmt/392</premis:eventOutcomeDetailNote>
+      </premis:eventOutcomeDetail>
+    </premis:eventOutcomeInformation>
+    <premis:linkingAgentIdentifier>
+      <premis:linkingAgentIdentifierType valueURI="http://id.loc.gov/vocabulary/identifiers/local">url</premis:linkingAgentIdentifierType>
+      <premis:linkingAgentIdentifierValue>https://github.com/richardlehane/siegfried</premis:linkingAgentIdentifierValue>
+    </premis:linkingAgentIdentifier>
+  </premis:event>
+  <premis:event>
+    <premis:eventIdentifier>
+      <premis:eventIdentifierType>UUID</premis:eventIdentifierType>
+      <premis:eventIdentifierValue>6325253f-ec73-4dd7-a9e2-8bf921119c16</premis:eventIdentifierValue>
+    </premis:eventIdentifier>
+    <premis:eventType>format identification</premis:eventType>
+    <premis:eventDateTime>2025-06-06T09:57:16Z</premis:eventDateTime>
+    <premis:eventDetailInformation>
+      <premis:eventDetail>name=&quot;Identify file format&quot;</premis:eventDetail>
+    </premis:eventDetailInformation>
+    <premis:eventOutcomeInformation>
+      <premis:eventOutcome>negative</premis:eventOutcome>
+      <premis:eventOutcomeDetail>
+        <premis:eventOutcomeDetailNote>no match; possibilities based on extension are fmt/101</premis:eventOutcomeDetailNote>
+      </premis:eventOutcomeDetail>
+    </premis:eventOutcomeInformation>
+    <premis:linkingAgentIdentifier>
+      <premis:linkingAgentIdentifierType valueURI="http://id.loc.gov/vocabulary/identifiers/local">url</premis:linkingAgentIdentifierType>
+      <premis:linkingAgentIdentifierValue>https://github.com/richardlehane/siegfried</premis:linkingAgentIdentifierValue>
+    </premis:linkingAgentIdentifier>
+  </premis:event>
+  <premis:event>
+    <premis:eventIdentifier>
+      <premis:eventIdentifierType>UUID</premis:eventIdentifierType>
+      <premis:eventIdentifierValue>0f070244-8615-4bda-8831-3f6a8eb668d2</premis:eventIdentifierValue>
+    </premis:eventIdentifier>
+    <premis:eventType>format identification</premis:eventType>
+    <premis:eventDateTime>2025-06-06T09:57:16Z</premis:eventDateTime>
+    <premis:eventDetailInformation>
+      <premis:eventDetail>name=&quot;Identify file format&quot;</premis:eventDetail>
+    </premis:eventDetailInformation>
+    <premis:eventOutcomeInformation>
+      <premis:eventOutcome>negative</premis:eventOutcome>
+      <premis:eventOutcomeDetail>
+        <premis:eventOutcomeDetailNote>identification failed: read error</premis:eventOutcomeDetailNote>
+      </premis:eventOutcomeDetail>
+    </premis:eventOutcomeInformation>
+    <premis:linkingAgentIdentifier>
+      <premis:linkingAgentIdentifierType valueURI="http://id.loc.gov/vocabulary/identifiers/local">url</premis:linkingAgentIdentifierType>
+      <premis:linkingAgentIdentifierValue>https://github.com/richardlehane/siegfried</premis:linkingAgentIdentifierValue>
+    </premis:linkingAgentIdentifier>
+  </premis:event>
+  <premis:agent>
+    <premis:agentIdentifier>
+      <premis:agentIdentifierType valueURI="http://id.loc.gov/vocabulary/identifiers/local">url</premis:agentIdentifierType>
+      <premis:agentIdentifierValue>https://github.com/richardlehane/siegfried</premis:agentIdentifierValue>
+    </premis:agentIdentifier>
+    <premis:agentName>Siegfried 1.11.2 (signature file: default.sig)</premis:agentName>
+    <premis:agentType>software</premis:agentType>
+  </premis:agent>
 </premis:premis>
-`)
+`,
+		},
+		{
+			name: "Error when manifest is missing",
+			params: activities.AddPREMISObjectsParams{
+				SIP:            testSIP(t, contentNoFiles.Join("digitized_Vecteur_SIP")),
+				PREMISFilePath: premisFilePathNoFiles,
+			},
+			result: activities.AddPREMISObjectsResult{},
+			wantErr: fmt.Sprintf(
+				"open manifest file: open %s: no such file or directory",
+				contentNoFiles.Join("digitized_Vecteur_SIP", "header", "metadata.xml"),
+			),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ts := &temporalsdk_testsuite.WorkflowTestSuite{}
+			env := ts.NewTestActivityEnvironment()
+			rng := pseudorand.New(pseudorand.NewSource(1)) // #nosec G404
+			idr := fake_fformat.NewMockIdentifier(gomock.NewController(t))
+			if tt.mock != nil {
+				tt.mock(idr.EXPECT())
+			}
+			env.RegisterActivityWithOptions(
+				activities.NewAddPREMISObjects(
+					clockwork.NewFakeClockAt(time.Date(2025, 6, 6, 9, 57, 16, 0, time.UTC)),
+					rng,
+					idr,
+				).Execute,
+				temporalsdk_activity.RegisterOptions{Name: activities.AddPREMISObjectsName},
+			)
+
+			var res activities.AddPREMISObjectsResult
+			future, err := env.ExecuteActivity(activities.AddPREMISObjectsName, tt.params)
+
+			if tt.wantErr != "" {
+				if err == nil {
+					t.Errorf("error is nil, expecting: %q", tt.wantErr)
+				} else {
+					assert.ErrorContains(t, err, tt.wantErr)
+				}
+
+				return
+			}
+			assert.NilError(t, err)
+
+			future.Get(&res)
+			assert.DeepEqual(t, res, tt.result)
+
+			b, err := os.ReadFile(tt.params.PREMISFilePath)
+			assert.NilError(t, err)
+			assert.Equal(t, string(b), tt.wantPREMIS)
 		})
 	}
 }
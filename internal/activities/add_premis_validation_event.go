@@ -2,18 +2,23 @@ package activities
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
+	"io/fs"
+	"maps"
 	"path/filepath"
 	"slices"
 	"time"
 
+	"github.com/beevik/etree"
 	"github.com/google/uuid"
 	"github.com/jonboulle/clockwork"
 
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/fformat"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/fsutil"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/fvalidate"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/inventory"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/premis"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/sip"
 )
@@ -23,7 +28,14 @@ const AddPREMISValidationEventName = "add-premis-validation-event"
 type AddPREMISValidationEventParams struct {
 	SIP            sip.SIP
 	PREMISFilePath string
-	Summary        premis.EventSummary
+
+	// Summary of the events of the valid files.
+	Summary premis.EventSummary
+
+	// Failures are the validation failures (e.g. the failed veraPDF rule
+	// clauses) by validator name and file path, relative to the SIP root
+	// directory. A file without failures is valid.
+	Failures map[string]map[string][]string
 }
 
 type AddPREMISValidationEventResult struct{}
@@ -36,18 +48,18 @@ type AddPREMISValidationEventActivity struct {
 	// deterministic generator for testing purposes.
 	rng io.Reader
 
-	validator fvalidate.Validator
+	validators []fvalidate.Validator
 }
 
 func NewAddPREMISValidationEvent(
 	clock clockwork.Clock,
 	rng io.Reader,
-	validator fvalidate.Validator,
+	validators ...fvalidate.Validator,
 ) *AddPREMISValidationEventActivity {
 	return &AddPREMISValidationEventActivity{
-		clock:     clock,
-		rng:       rng,
-		validator: validator,
+		clock:      clock,
+		rng:        rng,
+		validators: validators,
 	}
 }
 
@@ -55,8 +67,6 @@ func (a *AddPREMISValidationEventActivity) Execute(
 	ctx context.Context,
 	params *AddPREMISValidationEventParams,
 ) (*AddPREMISValidationEventResult, error) {
-	var addAgent bool
-
 	// Ensure the PREMIS file path exists.
 	if !fsutil.FileExists(params.PREMISFilePath) {
 		return nil, fmt.Errorf("PREMIS file path does not exist: %s", params.PREMISFilePath)
@@ -73,62 +83,23 @@ func (a *AddPREMISValidationEventActivity) Execute(
 		return nil, fmt.Errorf("no root premis element found in document")
 	}
 
-	// Determine formats of SIP files.
-	fileformats, err := fformat.IdentifyFormats(ctx, fformat.NewSiegfriedEmbed(), params.SIP)
+	// Determine formats of SIP files, they are only identified again if the
+	// SIP inventory hasn't been created.
+	var fileformats fformat.FileFormats
+	inv, err := inventory.Read(params.SIP.Path)
+	switch {
+	case err == nil && inv.Identified:
+		fileformats, err = inv.Formats(ctx, nil, params.SIP)
+	case err == nil || errors.Is(err, fs.ErrNotExist):
+		fileformats, err = fformat.IdentifyFormats(ctx, fformat.NewSiegfriedEmbed(), params.SIP)
+	}
 	if err != nil {
 		return nil, fmt.Errorf("identifyFormats: %v", err)
 	}
 
-	agent := a.validator.PREMISAgent()
-
-	// Determine which files should have been checked by the validator.
-	allowedIds := a.validator.FormatIDs()
-
-	for path, f := range fileformats {
-		if slices.Contains(allowedIds, f.ID) {
-			// Determine subpath.
-			subpath, err := filepath.Rel(params.SIP.ContentPath, path)
-			if err != nil {
-				return nil, err
-			}
-
-			// Find PREMIS object element using original name.
-			originalName := premis.OriginalNameForSubpath(params.SIP, subpath)
-			objectOriginalNameEl := doc.FindElement(
-				fmt.Sprintf("/premis:premis/premis:object/premis:originalName[text()='%s']", originalName),
-			)
-			if objectOriginalNameEl == nil {
-				return nil, fmt.Errorf("element not found")
-			}
-
-			id, err := uuid.NewRandomFromReader(a.rng)
-			if err != nil {
-				return nil, fmt.Errorf("generate UUID: %v", err)
-			}
-
-			// Append PREMIS event linked to PREMIS object element.
-			objectEl := objectOriginalNameEl.Parent()
-			event := premis.Event{
-				Summary:      params.Summary,
-				IdType:       "UUID",
-				IdValue:      id.String(),
-				DateTime:     a.clock.Now().Format(time.RFC3339),
-				AgentIdType:  agent.IdType,
-				AgentIdValue: agent.IdValue,
-			}
-
-			premis.AddEventElement(PREMISEl, event)
-			premis.LinkEventToObject(objectEl, event)
-
-			// Add agent to PREMIS.
-			addAgent = true
-		}
-	}
-
-	// Add validator agents to PREMIS document.
-	if addAgent {
-		if e := premis.AppendAgentXML(doc, agent); e != nil {
-			return nil, fmt.Errorf("addAgent: %v", e)
+	for _, v := range a.validators {
+		if err := a.addValidatorEvents(ctx, doc, params, v, fileformats); err != nil {
+			return nil, err
 		}
 	}
 
@@ -141,3 +112,80 @@ func (a *AddPREMISValidationEventActivity) Execute(
 
 	return &AddPREMISValidationEventResult{}, nil
 }
+
+// addValidatorEvents adds a validation event for each SIP file that should
+// have been checked by the v validator, with the file outcome and failures,
+// and the validator agent if any event was added.
+func (a *AddPREMISValidationEventActivity) addValidatorEvents(
+	ctx context.Context,
+	doc *etree.Document,
+	params *AddPREMISValidationEventParams,
+	v fvalidate.Validator,
+	fileformats fformat.FileFormats,
+) error {
+	var addAgent bool
+	PREMISEl := doc.FindElement("/premis:premis")
+	agent := v.PREMISAgent(ctx)
+
+	// Determine which files should have been checked by the validator.
+	allowedIds := v.FormatIDs()
+
+	for _, path := range slices.Sorted(maps.Keys(fileformats)) {
+		if !slices.Contains(allowedIds, fileformats[path].ID) {
+			continue
+		}
+
+		// Determine subpath.
+		subpath, err := filepath.Rel(params.SIP.ContentPath, path)
+		if err != nil {
+			return err
+		}
+
+		// Find PREMIS object element using original name.
+		originalName := premis.OriginalNameForSubpath(params.SIP, subpath)
+		objectOriginalNameEl := doc.FindElement(
+			fmt.Sprintf("/premis:premis/premis:object/premis:originalName[text()='%s']", originalName),
+		)
+		if objectOriginalNameEl == nil {
+			return fmt.Errorf("element not found")
+		}
+
+		id, err := uuid.NewRandomFromReader(a.rng)
+		if err != nil {
+			return fmt.Errorf("generate UUID: %v", err)
+		}
+
+		summary := params.Summary
+		if failures := params.Failures[v.Name()][relPath(params.SIP.Path, path)]; len(failures) > 0 {
+			summary.Outcome = "invalid"
+			summary.OutcomeDetail = "File format does not comply with specification"
+			summary.Failures = failures
+		}
+
+		// Append PREMIS event linked to PREMIS object element.
+		objectEl := objectOriginalNameEl.Parent()
+		event := premis.Event{
+			Summary:      summary,
+			IdType:       "UUID",
+			IdValue:      id.String(),
+			DateTime:     a.clock.Now().Format(time.RFC3339),
+			AgentIdType:  agent.IdType,
+			AgentIdValue: agent.IdValue,
+		}
+
+		premis.AddEventElement(PREMISEl, event)
+		premis.LinkEventToObject(objectEl, event)
+
+		// Add agent to PREMIS.
+		addAgent = true
+	}
+
+	// Add validator agent to PREMIS document.
+	if addAgent {
+		if e := premis.AppendAgentXML(doc, agent); e != nil {
+			return fmt.Errorf("addAgent: %v", e)
+		}
+	}
+
+	return nil
+}
@@ -4,6 +4,7 @@ import (
 	pseudorand "math/rand"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
@@ -118,7 +119,8 @@ func TestAddPREMISValidationEvent(t *testing.T) {
 			result: activities.AddPREMISValidationEventResult{},
 			expectVld: func(m *fake_fvalidate.MockValidatorMockRecorder) {
 				m.FormatIDs().Return([]string{"fmt/354", "fmt/817"})
-				m.PREMISAgent().Return(premis.Agent{
+				m.Name().Return("veraPDF")
+				m.PREMISAgent(gomock.Any()).Return(premis.Agent{
 					Type:    "software",
 					Name:    "veraPDF v1.2.3",
 					IdType:  "url",
@@ -197,7 +199,7 @@ func TestAddPREMISValidationEvent(t *testing.T) {
 			result: activities.AddPREMISValidationEventResult{},
 			expectVld: func(m *fake_fvalidate.MockValidatorMockRecorder) {
 				m.FormatIDs().Return([]string{"fmt/354", "fmt/817"})
-				m.PREMISAgent().Return(premis.Agent{
+				m.PREMISAgent(gomock.Any()).Return(premis.Agent{
 					Type:    "software",
 					Name:    "veraPDF v1.2.3",
 					IdType:  "url",
@@ -267,3 +269,102 @@ func TestAddPREMISValidationEvent(t *testing.T) {
 		})
 	}
 }
+
+func TestAddPREMISValidationEventValidators(t *testing.T) {
+	t.Parallel()
+
+	dir := fs.NewDir(t, "", fs.WithDir("test_transfer", fs.WithFile("file.json", "{}")))
+	premisPath := dir.Join("test_transfer", "premis.xml")
+
+	// The PREMIS object original name includes the SIP name.
+	fs.Apply(t, dir, fs.WithDir("test_transfer", fs.WithFile("premis.xml", strings.Replace(
+		premisObjectContent,
+		"data/objects/content/",
+		"data/objects/"+filepath.Base(dir.Path())+"/content/",
+		1,
+	))))
+
+	ctrl := gomock.NewController(t)
+	veraPDF := fake_fvalidate.NewMockValidator(ctrl)
+	veraPDF.EXPECT().FormatIDs().Return([]string{"fmt/354"})
+	veraPDF.EXPECT().PREMISAgent(gomock.Any()).Return(premis.Agent{
+		Type:    "software",
+		Name:    "veraPDF v1.2.3",
+		IdType:  "url",
+		IdValue: "https://verapdf.org",
+	})
+	jhove := fake_fvalidate.NewMockValidator(ctrl)
+	jhove.EXPECT().FormatIDs().Return([]string{"fmt/817"})
+	jhove.EXPECT().Name().Return("JHOVE")
+	jhove.EXPECT().PREMISAgent(gomock.Any()).Return(premis.Agent{
+		Type:    "software",
+		Name:    "Jhove (Rel. 1.28.0, 2023-05-18)",
+		IdType:  "url",
+		IdValue: "https://jhove.openpreservation.org",
+	})
+
+	ts := &temporalsdk_testsuite.WorkflowTestSuite{}
+	env := ts.NewTestActivityEnvironment()
+	env.RegisterActivityWithOptions(
+		activities.NewAddPREMISValidationEvent(
+			clockwork.NewFakeClockAt(time.Date(2025, 6, 6, 9, 57, 16, 0, time.UTC)),
+			pseudorand.New(pseudorand.NewSource(1)), // #nosec G404
+			veraPDF,
+			jhove,
+		).Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.AddPREMISValidationEventName},
+	)
+
+	_, err := env.ExecuteActivity(
+		activities.AddPREMISValidationEventName,
+		&activities.AddPREMISValidationEventParams{
+			SIP: sip.SIP{
+				Type:        enums.SIPTypeBornDigitalAIP,
+				Path:        dir.Path(),
+				ContentPath: filepath.Join(dir.Path(), "test_transfer"),
+			},
+			PREMISFilePath: premisPath,
+			Summary: premis.EventSummary{
+				Type:          "validation",
+				Detail:        "name=\"Validate SIP file formats\"",
+				Outcome:       "valid",
+				OutcomeDetail: "File format complies with specification",
+			},
+			Failures: map[string]map[string][]string{
+				"JHOVE": {
+					"test_transfer/file.json": {"Well-Formed, but not valid", "Unexpected end of file"},
+				},
+				"veraPDF": {
+					"test_transfer/file.json": {"ISO 19005-1:2005 6.1.3-1"},
+				},
+			},
+		},
+	)
+	assert.NilError(t, err)
+
+	doc, err := premis.ParseFile(premisPath)
+	assert.NilError(t, err)
+
+	// Only the validator supporting the file format is recorded, with the
+	// failures it found in the file.
+	events := doc.FindElements("/premis:premis/premis:event")
+	assert.Equal(t, len(events), 1)
+	assert.Equal(
+		t,
+		events[0].FindElement("./premis:linkingAgentIdentifier/premis:linkingAgentIdentifierValue").Text(),
+		"https://jhove.openpreservation.org",
+	)
+	assert.Equal(t, events[0].FindElement("./premis:eventOutcomeInformation/premis:eventOutcome").Text(), "invalid")
+	var notes []string
+	for _, el := range events[0].FindElements(".//premis:eventOutcomeDetailNote") {
+		notes = append(notes, el.Text())
+	}
+	assert.DeepEqual(t, notes, []string{
+		"File format does not comply with specification",
+		"Well-Formed, but not valid",
+		"Unexpected end of file",
+	})
+	agents := doc.FindElements("/premis:premis/premis:agent")
+	assert.Equal(t, len(agents), 1)
+	assert.Equal(t, agents[0].FindElement("./premis:agentName").Text(), "Jhove (Rel. 1.28.0, 2023-05-18)")
+}
@@ -0,0 +1,79 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const CopySIPName = "copy-sip"
+
+type (
+	CopySIP       struct{}
+	CopySIPParams struct {
+		// Path of the SIP to copy, it can be an archive or a SIP directory.
+		Path string
+
+		// DestPath is the working directory where the SIP is copied to. It's
+		// created if it doesn't exist.
+		DestPath string
+	}
+	CopySIPResult struct {
+		// Path of the SIP copy.
+		Path string
+	}
+)
+
+func NewCopySIP() *CopySIP {
+	return &CopySIP{}
+}
+
+// Execute copies the SIP archive or directory at params.Path to the
+// params.DestPath working directory, so it can be processed without modifying
+// the original SIP. An existing copy from a previous attempt is replaced.
+func (a *CopySIP) Execute(ctx context.Context, params *CopySIPParams) (*CopySIPResult, error) {
+	fi, err := os.Stat(params.Path)
+	if err != nil {
+		return nil, fmt.Errorf("CopySIP: %v", err)
+	}
+
+	dest := filepath.Join(params.DestPath, filepath.Base(params.Path))
+	if err := os.RemoveAll(dest); err != nil {
+		return nil, fmt.Errorf("CopySIP: remove previous copy: %v", err)
+	}
+	if err := os.MkdirAll(params.DestPath, 0o750); err != nil {
+		return nil, fmt.Errorf("CopySIP: %v", err)
+	}
+
+	if fi.IsDir() {
+		err = os.CopyFS(dest, os.DirFS(params.Path))
+	} else {
+		err = copyFile(params.Path, dest)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("CopySIP: copy SIP: %v", err)
+	}
+
+	return &CopySIPResult{Path: dest}, nil
+}
+
+func copyFile(src, dest string) error {
+	r, err := os.Open(src) // #nosec G304 -- trusted file path.
+	if err != nil {
+		return err
+	}
+	defer r.Close()
+
+	w, err := os.OpenFile(dest, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o640) // #nosec G304 -- trusted file path.
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, r); err != nil {
+		_ = w.Close()
+		return err
+	}
+
+	return w.Close()
+}
@@ -0,0 +1,100 @@
+package activities_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	temporalsdk_activity "go.temporal.io/sdk/activity"
+	temporalsdk_testsuite "go.temporal.io/sdk/testsuite"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/activities"
+)
+
+func TestCopySIP(t *testing.T) {
+	t.Parallel()
+
+	type test struct {
+		name    string
+		src     fs.PathOp
+		base    string
+		dest    string
+		want    fs.Manifest
+		wantErr string
+	}
+	for _, tt := range []test{
+		{
+			name: "Copies a SIP archive",
+			src:  fs.WithFile("SIP_20201201_Vecteur.zip", "archive"),
+			base: "SIP_20201201_Vecteur.zip",
+			want: fs.Expected(t, fs.MatchAnyFileMode, fs.WithFile("SIP_20201201_Vecteur.zip", "archive", fs.WithMode(0o640))),
+		},
+		{
+			name: "Copies a SIP directory",
+			src: fs.WithDir("SIP_20201201_Vecteur",
+				fs.WithDir("content", fs.WithFile("file.txt", "content")),
+				fs.WithFile("metadata.xml", "<xml/>"),
+			),
+			base: "SIP_20201201_Vecteur",
+			want: fs.Expected(t, fs.MatchAnyFileMode, fs.WithDir("SIP_20201201_Vecteur",
+				fs.MatchAnyFileMode,
+				fs.WithDir("content", fs.MatchAnyFileMode, fs.WithFile("file.txt", "content", fs.MatchAnyFileMode)),
+				fs.WithFile("metadata.xml", "<xml/>", fs.MatchAnyFileMode),
+			)),
+		},
+		{
+			name: "Replaces a previous copy",
+			src:  fs.WithFile("SIP_20201201_Vecteur.zip", "archive"),
+			base: "SIP_20201201_Vecteur.zip",
+			dest: "partial copy",
+			want: fs.Expected(t, fs.MatchAnyFileMode, fs.WithFile("SIP_20201201_Vecteur.zip", "archive", fs.WithMode(0o640))),
+		},
+		{
+			name:    "Errors when the SIP doesn't exist",
+			src:     fs.WithFile("other.zip", ""),
+			base:    "SIP_20201201_Vecteur.zip",
+			wantErr: "CopySIP: stat ",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			src := fs.NewDir(t, "", tt.src)
+			destDir := fs.NewDir(t, "")
+			workDir := filepath.Join(destDir.Path(), "work")
+			if tt.dest != "" {
+				fs.Apply(t, destDir, fs.WithDir("work", fs.WithFile(tt.base, tt.dest)))
+			}
+
+			env := (&temporalsdk_testsuite.WorkflowTestSuite{}).NewTestActivityEnvironment()
+			env.RegisterActivityWithOptions(
+				activities.NewCopySIP().Execute,
+				temporalsdk_activity.RegisterOptions{Name: activities.CopySIPName},
+			)
+
+			enc, err := env.ExecuteActivity(
+				activities.CopySIPName,
+				&activities.CopySIPParams{
+					Path:     filepath.Join(src.Path(), tt.base),
+					DestPath: workDir,
+				},
+			)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NilError(t, err)
+
+			var result activities.CopySIPResult
+			_ = enc.Get(&result)
+			assert.Equal(t, result.Path, filepath.Join(workDir, tt.base))
+			assert.Assert(t, fs.Equal(workDir, tt.want))
+
+			// The original SIP is left in place.
+			_, err = os.Stat(filepath.Join(src.Path(), tt.base))
+			assert.NilError(t, err)
+		})
+	}
+}
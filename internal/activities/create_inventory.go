@@ -0,0 +1,250 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"go.artefactual.dev/tools/temporal"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/fformat"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/inventory"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/progress"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/report"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/sip"
+)
+
+const CreateInventoryName = "create-inventory"
+
+// FixityAlgorithm is the checksum algorithm used to hash the SIP files, along
+// with the manifest checksum algorithm, for the PREMIS object fixity.
+const FixityAlgorithm = "SHA-512"
+
+// inventoryCheckpoint is the inventory state recorded in the activity
+// heartbeat details, so a retried attempt can reuse the file formats
+// identified by a previous attempt.
+type inventoryCheckpoint struct {
+	// Saved is the number of identified files in the partial inventory
+	// saved by the attempt.
+	Saved int64 `json:"saved"`
+}
+
+type (
+	CreateInventory struct {
+		identifier fformat.Identifier
+		policy     fformat.AmbiguityPolicy
+	}
+	CreateInventoryParams struct {
+		SIP sip.SIP
+	}
+	CreateInventoryResult struct {
+		// Path of the inventory file.
+		Path string
+
+		// Files is the number of files in the inventory.
+		Files int
+
+		// Failures are the content files that couldn't be conclusively
+		// identified, when the ambiguity policy fails the SIP.
+		Failures []string
+
+		// Warnings are the content files that couldn't be conclusively
+		// identified, when the ambiguity policy allows them.
+		Warnings []string
+
+		// Entries are the report entries of the Failures and Warnings.
+		Entries []report.Entry
+	}
+)
+
+// NewCreateInventory returns a CreateInventory activity identifying the SIP
+// content file formats with identifier, as many files at a time as the
+// identifier pool size. Ambiguous and unknown identifications are reported
+// following policy, or fformat.AmbiguityBest if policy is empty.
+func NewCreateInventory(identifier fformat.Identifier, policy fformat.AmbiguityPolicy) *CreateInventory {
+	if policy == "" {
+		policy = fformat.AmbiguityBest
+	}
+
+	return &CreateInventory{
+		identifier: identifier,
+		policy:     policy,
+	}
+}
+
+// Execute walks the SIP directory tree once and writes an inventory of its
+// files next to the SIP directory. The format of each file in the content
+// directory is identified, so later activities don't need to read the files
+// again. The content files that couldn't be conclusively identified are
+// reported as failures or warnings, depending on the ambiguity policy.
+//
+// The files are not hashed here: the checksums are calculated when the
+// manifest is verified, and shared with the later activities through the SIP
+// checksums file.
+func (a *CreateInventory) Execute(
+	ctx context.Context,
+	params *CreateInventoryParams,
+) (*CreateInventoryResult, error) {
+	h := temporal.StartAutoHeartbeat(ctx)
+	defer h.Stop()
+
+	inv, err := inventory.Scan(params.SIP.Path)
+	if err != nil {
+		return nil, fmt.Errorf("CreateInventory: %v", err)
+	}
+
+	// Reuse the formats saved by a previous attempt, or remove the files
+	// left by a previous run.
+	var cp inventoryCheckpoint
+	if _, ok := progress.LastDetails(ctx, progress.FilesInventoried, &cp); ok && cp.Saved > 0 {
+		if prev, err := inventory.Read(params.SIP.Path); err == nil {
+			inv.Resume(prev)
+		}
+	} else if err := inventory.Remove(params.SIP.Path); err != nil {
+		return nil, fmt.Errorf("CreateInventory: %v", err)
+	}
+
+	content, err := filepath.Rel(params.SIP.Path, params.SIP.ContentPath)
+	if err != nil {
+		return nil, fmt.Errorf("CreateInventory: %v", err)
+	}
+
+	if err := a.process(ctx, params.SIP.Path, content, inv); err != nil {
+		return nil, fmt.Errorf("CreateInventory: %v", err)
+	}
+	inv.Identified = true
+
+	if err := inventory.Write(params.SIP.Path, inv); err != nil {
+		return nil, fmt.Errorf("CreateInventory: %v", err)
+	}
+
+	res := &CreateInventoryResult{
+		Path:  inventory.Path(params.SIP.Path),
+		Files: len(inv.Files(".")),
+	}
+	a.checkIdentification(inv, content, res)
+
+	return res, nil
+}
+
+// checkIdentification adds the content files of inv with an unknown or
+// ambiguous file format to res, as failures or warnings depending on the
+// ambiguity policy. Ambiguous formats are only reported by the warn and fail
+// policies, the best basis policy relies on the best candidate.
+func (a *CreateInventory) checkIdentification(
+	inv *inventory.Inventory,
+	content string,
+	res *CreateInventoryResult,
+) {
+	severity := report.SeverityWarning
+	if a.policy == fformat.AmbiguityFail {
+		severity = report.SeverityError
+	}
+
+	var version string
+	for _, e := range inv.Entries {
+		if _, ok := e.Within(content); e.Dir || !ok {
+			continue
+		}
+
+		var (
+			code report.Code
+			msg  string
+		)
+		switch ff := e.Format; {
+		case !ff.Known():
+			code = report.CodeFileFormatUnidentified
+			msg = fmt.Sprintf("%q file format could not be identified", e.Path)
+			if ff != nil && ff.Warning != "" {
+				msg = fmt.Sprintf("%s: %s", msg, ff.Warning)
+			}
+		case ff.Ambiguous() && a.policy != fformat.AmbiguityBest:
+			code = report.CodeFileFormatAmbiguous
+			msg = fmt.Sprintf(
+				"%q matches several file formats (%s), identified as %s on the best basis",
+				e.Path, strings.Join(append([]string{ff.ID}, ff.Candidates...), ", "), ff.ID,
+			)
+		default:
+			continue
+		}
+
+		if version == "" {
+			version = a.identifier.Version()
+		}
+		res.Entries = append(res.Entries, report.Entry{
+			Check:       CreateInventoryName,
+			Severity:    severity,
+			Path:        e.Path,
+			Code:        code,
+			Message:     msg,
+			Tool:        "Siegfried",
+			ToolVersion: version,
+		})
+		if severity == report.SeverityError {
+			res.Failures = append(res.Failures, msg)
+		} else {
+			res.Warnings = append(res.Warnings, msg)
+		}
+	}
+}
+
+// process identifies the inventory content files not identified yet, as many
+// at a time as the identifier allows (see fformat.IdentifyEach). The partial
+// inventory is saved periodically, and recorded as a checkpoint in the
+// activity heartbeat details.
+func (a *CreateInventory) process(
+	ctx context.Context,
+	root, content string,
+	inv *inventory.Inventory,
+) error {
+	var (
+		mu       sync.Mutex
+		counter  = progress.Counter{Total: int64(len(inv.Files(".")))}
+		cp       inventoryCheckpoint
+		reporter = progress.NewReporter(ctx, progress.FilesInventoried)
+	)
+
+	var pending []int
+	for i, e := range inv.Entries {
+		if e.Dir {
+			continue
+		}
+		if _, ok := e.Within(content); ok && e.Format == nil {
+			pending = append(pending, i)
+		} else {
+			counter.Done++
+		}
+	}
+	reporter.ReportDetails(counter, cp)
+
+	// Save the partial inventory periodically, as identifying the files of a
+	// large SIP may take a long time.
+	stop := every(ctx, checksumHeartbeatInterval, func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if err := inventory.Write(root, inv); err != nil {
+			temporal.GetLogger(ctx).Info("Unable to save the inventory checkpoint.", "error", err)
+			return
+		}
+		cp.Saved = counter.Done
+		reporter.ReportDetails(counter, cp)
+	})
+	defer stop()
+
+	paths := make([]string, len(pending))
+	for j, i := range pending {
+		paths[j] = filepath.Join(root, inv.Entries[i].Path)
+	}
+
+	return fformat.IdentifyEach(ctx, a.identifier, paths, func(j int, ff *fformat.FileFormat) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		inv.Entries[pending[j]].Format = ff
+		counter.Done++
+		reporter.ReportDetails(counter, cp)
+	})
+}
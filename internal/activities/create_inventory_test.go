@@ -0,0 +1,445 @@
+package activities_test
+
+import (
+	"context"
+	"crypto/rand"
+	"io"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jonboulle/clockwork"
+	temporalsdk_activity "go.temporal.io/sdk/activity"
+	temporalsdk_testsuite "go.temporal.io/sdk/testsuite"
+	temporalsdk_worker "go.temporal.io/sdk/worker"
+	"go.uber.org/mock/gomock"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/activities"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/fformat"
+	fake_fformat "github.com/artefactual-sdps/preprocessing-sfa/internal/fformat/fake"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/inventory"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/premis"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/progress"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/report"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/sip"
+)
+
+// inventoryTestSIP returns a SIP in a working directory, where its inventory
+// files are written.
+func inventoryTestSIP(t *testing.T) sip.SIP {
+	t.Helper()
+
+	return testSIP(t, fs.NewDir(t, "", fs.WithDir("Test_Inventory",
+		fs.WithDir("additional",
+			fs.WithFile("UpdatedAreldaMetadata.xml", digitizedAIPUpdatedArelda),
+		),
+		fs.WithDir("content",
+			fs.WithDir("content",
+				fs.WithDir("d_0000001",
+					fs.WithFile("00000001.jp2", "12345"),
+					fs.WithFile("00000001_PREMIS.xml", "abcdef"),
+					fs.WithFile("00000002.jp2", "67890"),
+					fs.WithFile("00000002_PREMIS.xml", "ghijk"),
+					fs.WithFile("Prozess_Digitalisierung_PREMIS.xml", "lmnop"),
+				),
+			),
+			fs.WithDir("header",
+				fs.WithDir("old",
+					fs.WithDir("SIP",
+						fs.WithFile("metadata.xml", digitizedAIPMetadata),
+					),
+				),
+				fs.WithDir("xsd",
+					fs.WithFile("arelda.xsd", "vwxyz"),
+				),
+			),
+		),
+	)).Join("Test_Inventory"))
+}
+
+func TestCreateInventory(t *testing.T) {
+	t.Parallel()
+
+	jp2 := &fformat.FileFormat{Namespace: "PRONOM", ID: "x-fmt/392"}
+
+	t.Run("Creates the SIP inventory", func(t *testing.T) {
+		t.Parallel()
+
+		s := inventoryTestSIP(t)
+		idr := fake_fformat.NewMockIdentifier(gomock.NewController(t))
+		idr.EXPECT().Identify(gomock.Any()).Return(jp2, nil).Times(5)
+
+		ts := &temporalsdk_testsuite.WorkflowTestSuite{}
+		env := ts.NewTestActivityEnvironment()
+		env.RegisterActivityWithOptions(
+			activities.NewCreateInventory(idr, "").Execute,
+			temporalsdk_activity.RegisterOptions{Name: activities.CreateInventoryName},
+		)
+
+		future, err := env.ExecuteActivity(activities.CreateInventoryName, &activities.CreateInventoryParams{SIP: s})
+		assert.NilError(t, err)
+
+		var res activities.CreateInventoryResult
+		assert.NilError(t, future.Get(&res))
+		assert.DeepEqual(t, res, activities.CreateInventoryResult{
+			Path:  inventory.Path(s.Path),
+			Files: 8,
+		})
+
+		// The inventory is written outside of the SIP directory.
+		assert.Equal(t, filepath.Dir(res.Path), filepath.Dir(s.Path))
+		inv, err := inventory.Read(s.Path)
+		assert.NilError(t, err)
+		assert.Assert(t, inv.Identified)
+
+		// The files are hashed when the manifest is verified.
+		_, ok := inv.Checksum("content/content/d_0000001/00000001.jp2", "MD5")
+		assert.Assert(t, !ok)
+
+		// Only the content files are identified.
+		formats, err := inv.Formats(context.Background(), nil, s)
+		assert.NilError(t, err)
+		assert.Equal(t, len(formats), 5)
+		assert.DeepEqual(t, formats[filepath.Join(s.ContentPath, "d_0000001", "00000001.jp2")], jp2)
+	})
+
+	t.Run("Identifies as many files at a time as the identifier pool size", func(t *testing.T) {
+		t.Parallel()
+
+		s := inventoryTestSIP(t)
+		ctrl := gomock.NewController(t)
+
+		var active, maxActive atomic.Int32
+		pool, err := fformat.NewPool(2, func() (fformat.Identifier, error) {
+			idr := fake_fformat.NewMockIdentifier(ctrl)
+			idr.EXPECT().Identify(gomock.Any()).DoAndReturn(func(string) (*fformat.FileFormat, error) {
+				n := active.Add(1)
+				defer active.Add(-1)
+				for {
+					m := maxActive.Load()
+					if n <= m || maxActive.CompareAndSwap(m, n) {
+						break
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+
+				return jp2, nil
+			}).AnyTimes()
+
+			return idr, nil
+		})
+		assert.NilError(t, err)
+
+		ts := &temporalsdk_testsuite.WorkflowTestSuite{}
+		env := ts.NewTestActivityEnvironment()
+		env.RegisterActivityWithOptions(
+			activities.NewCreateInventory(pool, "").Execute,
+			temporalsdk_activity.RegisterOptions{Name: activities.CreateInventoryName},
+		)
+
+		_, err = env.ExecuteActivity(activities.CreateInventoryName, &activities.CreateInventoryParams{SIP: s})
+		assert.NilError(t, err)
+		assert.Equal(t, maxActive.Load(), int32(2))
+	})
+
+	t.Run("Reuses the formats saved by a previous attempt", func(t *testing.T) {
+		t.Parallel()
+
+		s := inventoryTestSIP(t)
+		prev, err := inventory.Scan(s.Path)
+		assert.NilError(t, err)
+		for i, e := range prev.Entries {
+			if e.Path == "content/content/d_0000001/00000001.jp2" {
+				prev.Entries[i].Format = jp2
+			}
+		}
+		assert.NilError(t, inventory.Write(s.Path, prev))
+
+		// Only the files not identified by the previous attempt are identified.
+		idr := fake_fformat.NewMockIdentifier(gomock.NewController(t))
+		idr.EXPECT().Identify(gomock.Not(filepath.Join(s.ContentPath, "d_0000001", "00000001.jp2"))).
+			Return(&fformat.FileFormat{Namespace: "PRONOM", ID: "fmt/101"}, nil).
+			Times(4)
+
+		ts := &temporalsdk_testsuite.WorkflowTestSuite{}
+		env := ts.NewTestActivityEnvironment()
+		env.SetHeartbeatDetails(progress.Heartbeat{
+			Update: progress.Update{
+				Name:    progress.FilesInventoried,
+				Counter: progress.Counter{Done: 4, Total: 8},
+			},
+			Details: map[string]int{"saved": 4},
+		})
+		env.RegisterActivityWithOptions(
+			activities.NewCreateInventory(idr, "").Execute,
+			temporalsdk_activity.RegisterOptions{Name: activities.CreateInventoryName},
+		)
+
+		_, err = env.ExecuteActivity(activities.CreateInventoryName, &activities.CreateInventoryParams{SIP: s})
+		assert.NilError(t, err)
+
+		inv, err := inventory.Read(s.Path)
+		assert.NilError(t, err)
+		formats, err := inv.Formats(t.Context(), nil, s)
+		assert.NilError(t, err)
+		assert.Equal(t, len(formats), 5)
+		assert.DeepEqual(t, formats[filepath.Join(s.ContentPath, "d_0000001", "00000001.jp2")], jp2)
+	})
+
+	t.Run("Ignores the files of a previous run without checkpoint", func(t *testing.T) {
+		t.Parallel()
+
+		s := inventoryTestSIP(t)
+		prev, err := inventory.Scan(s.Path)
+		assert.NilError(t, err)
+		for i := range prev.Entries {
+			prev.Entries[i].Format = jp2
+		}
+		assert.NilError(t, inventory.Write(s.Path, prev))
+		assert.NilError(t, inventory.WriteChecksums(s.Path, inventory.Checksums{
+			"content/content/d_0000001/00000001.jp2": {"MD5": "0123456789abcdef0123456789abcdef"},
+		}))
+
+		idr := fake_fformat.NewMockIdentifier(gomock.NewController(t))
+		idr.EXPECT().Identify(gomock.Any()).Return(jp2, nil).Times(5)
+
+		ts := &temporalsdk_testsuite.WorkflowTestSuite{}
+		env := ts.NewTestActivityEnvironment()
+		env.RegisterActivityWithOptions(
+			activities.NewCreateInventory(idr, "").Execute,
+			temporalsdk_activity.RegisterOptions{Name: activities.CreateInventoryName},
+		)
+
+		_, err = env.ExecuteActivity(activities.CreateInventoryName, &activities.CreateInventoryParams{SIP: s})
+		assert.NilError(t, err)
+
+		sums, err := inventory.ReadChecksums(s.Path)
+		assert.NilError(t, err)
+		assert.Equal(t, len(sums), 0)
+	})
+
+	t.Run("Errors when canceled", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		ts := &temporalsdk_testsuite.WorkflowTestSuite{}
+		env := ts.NewTestActivityEnvironment()
+		env.SetWorkerOptions(temporalsdk_worker.Options{BackgroundActivityContext: ctx})
+		env.RegisterActivityWithOptions(
+			activities.NewCreateInventory(fake_fformat.NewMockIdentifier(gomock.NewController(t)), "").Execute,
+			temporalsdk_activity.RegisterOptions{Name: activities.CreateInventoryName},
+		)
+
+		_, err := env.ExecuteActivity(
+			activities.CreateInventoryName,
+			&activities.CreateInventoryParams{SIP: inventoryTestSIP(t)},
+		)
+		assert.ErrorContains(t, err, "CreateInventory: context canceled")
+	})
+}
+
+func TestCreateInventoryIdentification(t *testing.T) {
+	t.Parallel()
+
+	const (
+		ambiguousPath = "content/content/d_0000001/00000001.jp2"
+		unknownPath   = "content/content/d_0000001/00000002.jp2"
+		failedPath    = "content/content/d_0000001/Prozess_Digitalisierung_PREMIS.xml"
+	)
+
+	entry := func(severity report.Severity, code report.Code, path, msg string) report.Entry {
+		return report.Entry{
+			Check:       activities.CreateInventoryName,
+			Severity:    severity,
+			Path:        path,
+			Code:        code,
+			Message:     msg,
+			Tool:        "Siegfried",
+			ToolVersion: "1.11.4",
+		}
+	}
+	ambiguousMsg := `"content/content/d_0000001/00000001.jp2" matches several file formats (x-fmt/392, fmt/463), ` +
+		"identified as x-fmt/392 on the best basis"
+	unknownMsg := `"content/content/d_0000001/00000002.jp2" file format could not be identified: no match`
+	failedMsg := `"content/content/d_0000001/Prozess_Digitalisierung_PREMIS.xml" file format could not be ` +
+		"identified: identification failed: unexpected EOF"
+
+	type test struct {
+		name   string
+		policy fformat.AmbiguityPolicy
+		want   activities.CreateInventoryResult
+	}
+	for _, tt := range []test{
+		{
+			name:   "Reports unknown formats as warnings with the best basis policy",
+			policy: fformat.AmbiguityBest,
+			want: activities.CreateInventoryResult{
+				Warnings: []string{unknownMsg, failedMsg},
+				Entries: []report.Entry{
+					entry(report.SeverityWarning, report.CodeFileFormatUnidentified, unknownPath, unknownMsg),
+					entry(report.SeverityWarning, report.CodeFileFormatUnidentified, failedPath, failedMsg),
+				},
+			},
+		},
+		{
+			name:   "Reports ambiguous and unknown formats as warnings",
+			policy: fformat.AmbiguityWarn,
+			want: activities.CreateInventoryResult{
+				Warnings: []string{ambiguousMsg, unknownMsg, failedMsg},
+				Entries: []report.Entry{
+					entry(report.SeverityWarning, report.CodeFileFormatAmbiguous, ambiguousPath, ambiguousMsg),
+					entry(report.SeverityWarning, report.CodeFileFormatUnidentified, unknownPath, unknownMsg),
+					entry(report.SeverityWarning, report.CodeFileFormatUnidentified, failedPath, failedMsg),
+				},
+			},
+		},
+		{
+			name:   "Reports ambiguous and unknown formats as failures",
+			policy: fformat.AmbiguityFail,
+			want: activities.CreateInventoryResult{
+				Failures: []string{ambiguousMsg, unknownMsg, failedMsg},
+				Entries: []report.Entry{
+					entry(report.SeverityError, report.CodeFileFormatAmbiguous, ambiguousPath, ambiguousMsg),
+					entry(report.SeverityError, report.CodeFileFormatUnidentified, unknownPath, unknownMsg),
+					entry(report.SeverityError, report.CodeFileFormatUnidentified, failedPath, failedMsg),
+				},
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s := inventoryTestSIP(t)
+			idr := fake_fformat.NewMockIdentifier(gomock.NewController(t))
+			idr.EXPECT().Identify(gomock.Any()).DoAndReturn(func(path string) (*fformat.FileFormat, error) {
+				switch filepath.Base(path) {
+				case "00000001.jp2":
+					return &fformat.FileFormat{ID: "x-fmt/392", Candidates: []string{"fmt/463"}}, nil
+				case "00000002.jp2":
+					return &fformat.FileFormat{ID: fformat.UnknownID, Warning: "no match"}, nil
+				case "Prozess_Digitalisierung_PREMIS.xml":
+					return nil, io.ErrUnexpectedEOF
+				default:
+					return &fformat.FileFormat{ID: "fmt/101"}, nil
+				}
+			}).Times(5)
+			idr.EXPECT().Version().Return("1.11.4")
+
+			ts := &temporalsdk_testsuite.WorkflowTestSuite{}
+			env := ts.NewTestActivityEnvironment()
+			env.RegisterActivityWithOptions(
+				activities.NewCreateInventory(idr, tt.policy).Execute,
+				temporalsdk_activity.RegisterOptions{Name: activities.CreateInventoryName},
+			)
+
+			future, err := env.ExecuteActivity(activities.CreateInventoryName, &activities.CreateInventoryParams{SIP: s})
+			assert.NilError(t, err)
+
+			var res activities.CreateInventoryResult
+			assert.NilError(t, future.Get(&res))
+			tt.want.Path = inventory.Path(s.Path)
+			tt.want.Files = 8
+			assert.DeepEqual(t, res, tt.want)
+
+			// Unidentified files are kept in the inventory with an unknown format.
+			inv, err := inventory.Read(s.Path)
+			assert.NilError(t, err)
+			formats, err := inv.Formats(t.Context(), nil, s)
+			assert.NilError(t, err)
+			assert.Equal(t, formats[filepath.Join(s.Path, failedPath)].ID, fformat.UnknownID)
+		})
+	}
+}
+
+func TestVerifyManifestUsesInventory(t *testing.T) {
+	t.Parallel()
+
+	// The shared checksums are used instead of hashing the file again.
+	s := inventoryTestSIP(t)
+	inv, err := inventory.Scan(s.Path)
+	assert.NilError(t, err)
+	assert.NilError(t, inventory.Write(s.Path, inv))
+	assert.NilError(t, inventory.WriteChecksums(s.Path, inventory.Checksums{
+		"content/content/d_0000001/00000002.jp2": {
+			"MD5":                      "0123456789abcdef0123456789abcdef",
+			activities.FixityAlgorithm: "fedcba9876543210",
+		},
+	}))
+
+	ts := &temporalsdk_testsuite.WorkflowTestSuite{}
+	env := ts.NewTestActivityEnvironment()
+	env.RegisterActivityWithOptions(
+		activities.NewVerifyManifest(0).Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.VerifyManifestName},
+	)
+
+	future, err := env.ExecuteActivity(activities.VerifyManifestName, &activities.VerifyManifestParams{SIP: s})
+	assert.NilError(t, err)
+
+	var res activities.VerifyManifestResult
+	assert.NilError(t, future.Get(&res))
+	assert.DeepEqual(t, res.Entries, []report.Entry{
+		manifestEntry(
+			report.CodeManifestChecksumMismatch,
+			"content/content/d_0000001/00000002.jp2",
+			`Checksum mismatch for "content/content/d_0000001/00000002.jp2" (expected: "1e01ba3e07ac48cbdab2d3284d1dd0fa", got: "0123456789abcdef0123456789abcdef")`,
+		),
+	})
+
+	// The other manifest files are hashed with the manifest and the fixity
+	// algorithms, and their checksums are shared.
+	sums, err := inventory.ReadChecksums(s.Path)
+	assert.NilError(t, err)
+	assert.Equal(t, len(sums), 7)
+	assert.DeepEqual(t, sums["content/content/d_0000001/00000001.jp2"], map[string]string{
+		"MD5":                      "827ccb0eea8a706c4c34a16891f84e7b",
+		activities.FixityAlgorithm: "3627909a29c31381a071ec27f7c9ca97726182aed29a7ddd2e54353322cfb30abb9e3a6df2ac2c20fe23436311d678564d0c8d305930575f60e2d3d048184d79",
+	})
+	assert.Equal(t, sums["content/content/d_0000001/00000002.jp2"][activities.FixityAlgorithm], "fedcba9876543210")
+}
+
+func TestAddPREMISObjectsUsesInventory(t *testing.T) {
+	t.Parallel()
+
+	// The files are not identified again when the inventory has their formats,
+	// and the inventory fixity checksums are added to the PREMIS objects.
+	s := inventoryTestSIP(t)
+	inv, err := inventory.Scan(s.Path)
+	assert.NilError(t, err)
+	inv.Identified = true
+	for i, e := range inv.Entries {
+		if e.Path == "content/content/d_0000001/00000001.jp2" {
+			inv.Entries[i].Format = &fformat.FileFormat{Namespace: "pronom", ID: "x-fmt/392"}
+			inv.Entries[i].Checksums = map[string]string{activities.FixityAlgorithm: "0123456789abcdef"}
+		}
+	}
+	assert.NilError(t, inventory.Write(s.Path, inv))
+
+	idr := fake_fformat.NewMockIdentifier(gomock.NewController(t))
+	idr.EXPECT().PREMISAgent().Return(premis.Agent{Type: "software", IdType: "url", IdValue: "siegfried"})
+
+	ts := &temporalsdk_testsuite.WorkflowTestSuite{}
+	env := ts.NewTestActivityEnvironment()
+	env.RegisterActivityWithOptions(
+		activities.NewAddPREMISObjects(clockwork.NewFakeClock(), rand.Reader, idr).Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.AddPREMISObjectsName},
+	)
+
+	path := filepath.Join(s.Path, "metadata", "premis.xml")
+	_, err = env.ExecuteActivity(
+		activities.AddPREMISObjectsName,
+		&activities.AddPREMISObjectsParams{SIP: s, PREMISFilePath: path},
+	)
+	assert.NilError(t, err)
+
+	doc, err := premis.ParseFile(path)
+	assert.NilError(t, err)
+	assert.Equal(t, len(doc.FindElements("//premis:formatRegistryKey")), 1)
+	assert.Equal(t, len(doc.FindElements("//premis:messageDigestAlgorithm[text()='SHA-512']")), 1)
+	assert.Equal(t, len(doc.FindElements("//premis:event")), 5)
+}
@@ -0,0 +1,80 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"go.artefactual.dev/tools/fsutil"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/inventory"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/report"
+)
+
+const MoveFailedSIPName = "move-failed-sip"
+
+type (
+	MoveFailedSIP       struct{}
+	MoveFailedSIPParams struct {
+		// Path of the SIP to move, it can be the original archive or the
+		// extracted SIP directory.
+		Path string
+
+		// DestPath is the directory where the SIP and its validation report
+		// are moved to.
+		DestPath string
+
+		// Report is written as JSON to DestPath.
+		Report report.Report
+	}
+	MoveFailedSIPResult struct {
+		// Path of the moved SIP.
+		Path string
+
+		// ReportPath is the path of the validation report file.
+		ReportPath string
+	}
+)
+
+func NewMoveFailedSIP() *MoveFailedSIP {
+	return &MoveFailedSIP{}
+}
+
+// Execute moves a SIP that failed preprocessing to the failed SIPs directory
+// and writes a validation report next to it. It's idempotent: a SIP that is
+// already in the failed SIPs directory and no longer at its original path is
+// considered moved.
+func (a *MoveFailedSIP) Execute(ctx context.Context, params *MoveFailedSIPParams) (*MoveFailedSIPResult, error) {
+	if err := os.MkdirAll(params.DestPath, 0o750); err != nil {
+		return nil, fmt.Errorf("MoveFailedSIP: %v", err)
+	}
+
+	reportPath, err := report.Write(params.DestPath, params.Report)
+	if err != nil {
+		return nil, fmt.Errorf("MoveFailedSIP: %v", err)
+	}
+
+	// A previous attempt may have moved the SIP before failing, consider it
+	// moved if it's only found at its destination.
+	dest := filepath.Join(params.DestPath, filepath.Base(params.Path))
+	if _, err := os.Stat(params.Path); errors.Is(err, fs.ErrNotExist) {
+		if _, err := os.Stat(dest); err == nil {
+			return &MoveFailedSIPResult{Path: dest, ReportPath: reportPath}, nil
+		}
+	}
+
+	// The SIP inventory files are only used while processing, don't leave
+	// them in the shared directory.
+	if err := inventory.Remove(params.Path); err != nil {
+		return nil, fmt.Errorf("MoveFailedSIP: %v", err)
+	}
+
+	if err := fsutil.Move(params.Path, dest); err != nil {
+		return nil, fmt.Errorf("MoveFailedSIP: move SIP: %v", err)
+	}
+
+	return &MoveFailedSIPResult{Path: dest, ReportPath: reportPath}, nil
+}
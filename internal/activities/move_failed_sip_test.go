@@ -0,0 +1,173 @@
+package activities_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	temporalsdk_activity "go.temporal.io/sdk/activity"
+	temporalsdk_testsuite "go.temporal.io/sdk/testsuite"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/activities"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/report"
+)
+
+const expectedReport = `{
+    "sipId": "52fdfc07-2182-454f-963f-5f0f9a621d72",
+    "sipName": "SIP_20201201_Vecteur.zip",
+    "outcome": "content error",
+    "tasks": [
+        {
+            "name": "Validate SIP name",
+            "outcome": "validation failure",
+            "message": "Content error: SIP name validation has failed.",
+            "startedAt": "2024-06-01T10:00:00Z",
+            "completedAt": "2024-06-01T10:00:01Z"
+        }
+    ],
+    "entries": [
+        {
+            "check": "validate-sip-name",
+            "severity": "error",
+            "code": "sip.invalid-name",
+            "message": "SIP name \"SIP_20201201_Vecteur.zip\" violates naming standard"
+        }
+    ]
+}`
+
+func TestMoveFailedSIP(t *testing.T) {
+	t.Parallel()
+
+	rep := report.Report{
+		SIPID:   "52fdfc07-2182-454f-963f-5f0f9a621d72",
+		SIPName: "SIP_20201201_Vecteur.zip",
+		Outcome: "content error",
+		Tasks: []report.Task{
+			{
+				Name:        "Validate SIP name",
+				Outcome:     "validation failure",
+				Message:     "Content error: SIP name validation has failed.",
+				StartedAt:   time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC),
+				CompletedAt: time.Date(2024, 6, 1, 10, 0, 1, 0, time.UTC),
+			},
+		},
+		Entries: []report.Entry{
+			{
+				Check:    activities.ValidateSIPNameName,
+				Severity: report.SeverityError,
+				Code:     report.CodeSIPInvalidName,
+				Message:  `SIP name "SIP_20201201_Vecteur.zip" violates naming standard`,
+			},
+		},
+	}
+
+	tests := []struct {
+		name    string
+		src     *fs.Dir
+		sipName string
+		moved   bool
+		wantFS  fs.Manifest
+		wantErr string
+	}{
+		{
+			name: "Moves a SIP directory and writes a report",
+			src: fs.NewDir(t, "",
+				fs.WithDir("SIP_20201201_Vecteur",
+					fs.WithDir("content",
+						fs.WithFile("file.txt", "content"),
+					),
+				),
+				fs.WithFile(".SIP_20201201_Vecteur.inventory.json", "{}"),
+				fs.WithFile(".SIP_20201201_Vecteur.checksums.json", "{}"),
+			),
+			sipName: "SIP_20201201_Vecteur",
+			wantFS: fs.Expected(t,
+				fs.WithMode(0o750),
+				fs.WithDir("SIP_20201201_Vecteur",
+					fs.WithMode(0o755),
+					fs.WithDir("content",
+						fs.WithMode(0o755),
+						fs.WithFile("file.txt", "content", fs.WithMode(0o644)),
+					),
+				),
+				fs.WithFile(report.Filename, expectedReport, fs.WithMode(0o644)),
+			),
+		},
+		{
+			name: "Moves a SIP archive and writes a report",
+			src: fs.NewDir(t, "",
+				fs.WithFile("SIP_20201201_Vecteur.zip", "archive"),
+			),
+			sipName: "SIP_20201201_Vecteur.zip",
+			wantFS: fs.Expected(t,
+				fs.WithMode(0o750),
+				fs.WithFile("SIP_20201201_Vecteur.zip", "archive", fs.WithMode(0o644)),
+				fs.WithFile(report.Filename, expectedReport, fs.WithMode(0o644)),
+			),
+		},
+		{
+			name:    "Succeeds when a previous attempt has moved the SIP",
+			src:     fs.NewDir(t, ""),
+			sipName: "SIP_20201201_Vecteur.zip",
+			moved:   true,
+			wantFS: fs.Expected(t,
+				fs.WithMode(0o750),
+				fs.WithFile("SIP_20201201_Vecteur.zip", "archive", fs.WithMode(0o644)),
+				fs.WithFile(report.Filename, expectedReport, fs.WithMode(0o644)),
+			),
+		},
+		{
+			name:    "Errors when the SIP doesn't exist",
+			src:     fs.NewDir(t, ""),
+			sipName: "missing",
+			wantErr: "MoveFailedSIP: move SIP:",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ts := &temporalsdk_testsuite.WorkflowTestSuite{}
+			env := ts.NewTestActivityEnvironment()
+			env.RegisterActivityWithOptions(
+				activities.NewMoveFailedSIP().Execute,
+				temporalsdk_activity.RegisterOptions{Name: activities.MoveFailedSIPName},
+			)
+
+			destPath := filepath.Join(t.TempDir(), "failed", "SIP_20201201_Vecteur")
+			if tt.moved {
+				assert.NilError(t, os.MkdirAll(destPath, 0o750))
+				assert.NilError(t, os.WriteFile(filepath.Join(destPath, tt.sipName), []byte("archive"), 0o644))
+			}
+			enc, err := env.ExecuteActivity(
+				activities.MoveFailedSIPName,
+				&activities.MoveFailedSIPParams{
+					Path:     tt.src.Join(tt.sipName),
+					DestPath: destPath,
+					Report:   rep,
+				},
+			)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NilError(t, err)
+
+			var result activities.MoveFailedSIPResult
+			_ = enc.Get(&result)
+			assert.DeepEqual(t, result, activities.MoveFailedSIPResult{
+				Path:       filepath.Join(destPath, tt.sipName),
+				ReportPath: filepath.Join(destPath, report.Filename),
+			})
+			assert.Assert(t, fs.Equal(destPath, tt.wantFS))
+
+			// The SIP inventory files are removed.
+			entries, err := os.ReadDir(tt.src.Path())
+			assert.NilError(t, err)
+			assert.Equal(t, len(entries), 0)
+		})
+	}
+}
@@ -0,0 +1,288 @@
+package activities
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"go.artefactual.dev/tools/fsutil"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/enums"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/sip"
+)
+
+// transformJournalName is the name of the file used to journal the SIP
+// restructuring, it's stored in the SIP directory and removed when the
+// restructuring completes or is rolled back.
+const transformJournalName = ".transform-sip.json"
+
+type transformState string
+
+const (
+	// transformApplying means the journal steps are being applied, and can
+	// be resumed or rolled back.
+	transformApplying transformState = "applying"
+
+	// transformRollingBack means a step has failed and the applied steps are
+	// being reverted.
+	transformRollingBack transformState = "rolling-back"
+
+	// transformCommitted means all the steps have been applied and the old
+	// SIP directories are being removed, the restructuring can only be
+	// resumed from this point.
+	transformCommitted transformState = "committed"
+)
+
+type transformOp string
+
+const (
+	transformMkdir transformOp = "mkdir"
+	transformMove  transformOp = "move"
+)
+
+// transformStep is a single restructuring operation. Paths are relative to
+// the SIP directory.
+type transformStep struct {
+	Op   transformOp `json:"op"`
+	Src  string      `json:"src,omitempty"`
+	Dest string      `json:"dest"`
+}
+
+// transformJournal records the planned SIP restructuring steps, so an
+// interrupted restructuring can be resumed or rolled back. Applying and
+// reverting steps is idempotent, which allows retrying steps that may or may
+// not have completed before an interruption.
+type transformJournal struct {
+	State transformState  `json:"state"`
+	Steps []transformStep `json:"steps"`
+
+	root string
+}
+
+// planTransform returns a journal with the steps needed to restructure s.
+func planTransform(s sip.SIP) (*transformJournal, error) {
+	j := &transformJournal{State: transformApplying, root: s.Path}
+	rel := func(path string) (string, error) {
+		return filepath.Rel(s.Path, path)
+	}
+
+	j.mkdir("metadata")
+
+	// Prozess_Digitalisierung_PREMIS.xml is only present in digitized
+	// SIPs/AIPs, and there can only be one dossier in a digitized SIP/AIP.
+	if s.Type == enums.SIPTypeDigitizedSIP || s.Type == enums.SIPTypeDigitizedAIP {
+		entries, err := os.ReadDir(s.ContentPath)
+		if err != nil {
+			return nil, err
+		}
+		if len(entries) == 0 {
+			return nil, fmt.Errorf("no dossier found in %s", s.ContentPath)
+		}
+
+		src, err := rel(filepath.Join(
+			s.ContentPath,
+			entries[0].Name(), // dossier name.
+			"Prozess_Digitalisierung_PREMIS.xml",
+		))
+		if err != nil {
+			return nil, err
+		}
+		j.move(src, filepath.Join("metadata", "Prozess_Digitalisierung_PREMIS.xml"))
+	}
+
+	// UpdatedAreldaMetatdata.xml and the logical metadata file (AIP only).
+	if s.IsAIP() {
+		for _, path := range []string{s.UpdatedAreldaMDPath, s.LogicalMDPath} {
+			src, err := rel(path)
+			if err != nil {
+				return nil, err
+			}
+			j.move(src, filepath.Join("metadata", filepath.Base(path)))
+		}
+	}
+
+	objectsPath := filepath.Join("objects", s.Name())
+	j.mkdir("objects")
+	j.mkdir(objectsPath)
+
+	src, err := rel(s.ContentPath)
+	if err != nil {
+		return nil, err
+	}
+	j.move(src, filepath.Join(objectsPath, "content"))
+
+	headerPath := filepath.Join(objectsPath, "header")
+	j.mkdir(headerPath)
+
+	src, err = rel(s.MetadataPath)
+	if err != nil {
+		return nil, err
+	}
+	j.move(src, filepath.Join(headerPath, filepath.Base(s.MetadataPath)))
+
+	return j, nil
+}
+
+// loadTransformJournal reads the journal from the SIP directory at root. It
+// returns a nil journal if there is no journal file.
+func loadTransformJournal(root string) (*transformJournal, error) {
+	b, err := os.ReadFile(filepath.Join(root, transformJournalName))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, fmt.Errorf("read journal: %v", err)
+	}
+
+	j := &transformJournal{root: root}
+	if err := json.Unmarshal(b, j); err != nil {
+		return nil, fmt.Errorf("decode journal: %v", err)
+	}
+
+	return j, nil
+}
+
+func (j *transformJournal) mkdir(dest string) {
+	// Existing directories are not part of the restructuring, and are kept
+	// on rollback.
+	if _, err := os.Stat(filepath.Join(j.root, dest)); err == nil {
+		return
+	}
+	j.Steps = append(j.Steps, transformStep{Op: transformMkdir, Dest: dest})
+}
+
+func (j *transformJournal) move(src, dest string) {
+	j.Steps = append(j.Steps, transformStep{Op: transformMove, Src: src, Dest: dest})
+}
+
+// save writes the journal atomically, so an interruption never leaves a
+// partially written journal behind.
+func (j *transformJournal) save() error {
+	b, err := json.Marshal(j)
+	if err != nil {
+		return fmt.Errorf("encode journal: %v", err)
+	}
+
+	tmp := filepath.Join(j.root, transformJournalName+".tmp")
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return fmt.Errorf("write journal: %v", err)
+	}
+	if err := os.Rename(tmp, filepath.Join(j.root, transformJournalName)); err != nil {
+		return fmt.Errorf("write journal: %v", err)
+	}
+
+	return nil
+}
+
+func (j *transformJournal) setState(state transformState) error {
+	j.State = state
+	return j.save()
+}
+
+func (j *transformJournal) remove() error {
+	if err := os.Remove(filepath.Join(j.root, transformJournalName)); err != nil {
+		return fmt.Errorf("remove journal: %v", err)
+	}
+
+	return nil
+}
+
+// apply applies all the journal steps in order, skipping the steps that have
+// already been applied.
+func (j *transformJournal) apply() error {
+	for _, step := range j.Steps {
+		dest := filepath.Join(j.root, step.Dest)
+
+		switch step.Op {
+		case transformMkdir:
+			if err := os.MkdirAll(dest, 0o700); err != nil {
+				return err
+			}
+		case transformMove:
+			src := filepath.Join(j.root, step.Src)
+			srcExists, destExists, err := pathsExist(src, dest)
+			if err != nil {
+				return err
+			}
+			if !srcExists && destExists {
+				continue // Already moved.
+			}
+			if srcExists && destExists {
+				// An interrupted copy between devices leaves a partial
+				// destination behind, the source is only removed when the
+				// copy completes.
+				if err := os.RemoveAll(dest); err != nil {
+					return err
+				}
+			}
+			if err := fsutil.Move(src, dest); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unknown journal operation: %q", step.Op)
+		}
+	}
+
+	return nil
+}
+
+// rollback reverts all the journal steps in reverse order, skipping the steps
+// that haven't been applied.
+func (j *transformJournal) rollback() error {
+	for i := len(j.Steps) - 1; i >= 0; i-- {
+		step := j.Steps[i]
+		dest := filepath.Join(j.root, step.Dest)
+
+		switch step.Op {
+		case transformMkdir:
+			if err := os.Remove(dest); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+		case transformMove:
+			src := filepath.Join(j.root, step.Src)
+			srcExists, destExists, err := pathsExist(src, dest)
+			if err != nil {
+				return err
+			}
+			if !destExists {
+				continue // Not moved.
+			}
+			if srcExists {
+				// Partial copy of an interrupted move.
+				if err := os.RemoveAll(dest); err != nil {
+					return err
+				}
+				continue
+			}
+			if err := fsutil.Move(dest, src); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unknown journal operation: %q", step.Op)
+		}
+	}
+
+	return nil
+}
+
+// pathsExist reports whether src and dest exist.
+func pathsExist(src, dest string) (srcExists, destExists bool, err error) {
+	exists := func(path string) (bool, error) {
+		_, err := os.Lstat(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return false, nil
+		}
+		return err == nil, err
+	}
+
+	if srcExists, err = exists(src); err != nil {
+		return false, false, err
+	}
+	if destExists, err = exists(dest); err != nil {
+		return false, false, err
+	}
+
+	return srcExists, destExists, nil
+}
@@ -3,12 +3,12 @@ package activities
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
-	"path/filepath"
 
 	"go.artefactual.dev/tools/fsutil"
 
-	"github.com/artefactual-sdps/preprocessing-sfa/internal/enums"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/inventory"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/pips"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/sip"
 )
@@ -29,93 +29,90 @@ func NewTransformSIP() *TransformSIP {
 	return &TransformSIP{}
 }
 
+// Execute restructures the SIP into a PIP. The planned steps are journaled
+// in the SIP directory before any change is made, so a retried execution
+// resumes an interrupted restructuring instead of starting from an unknown
+// state. If a step fails, the applied steps are rolled back leaving the SIP
+// in its original layout.
 func (a *TransformSIP) Execute(ctx context.Context, params *TransformSIPParams) (*TransformSIPResult, error) {
-	// Create a metadata directory.
-	mdPath := filepath.Join(params.SIP.Path, "metadata")
-	if err := os.MkdirAll(mdPath, 0o700); err != nil {
-		return nil, err
+	j, err := loadTransformJournal(params.SIP.Path)
+	if err != nil {
+		return nil, fmt.Errorf("TransformSIP: %v", err)
 	}
 
-	// Move the Prozess_Digitalisierung_PREMIS.xml file to the metadata
-	// directory. Prozess_Digitalisierung_PREMIS.xml is only present in
-	// digitized SIPs/AIPs, and there can only be one dossier in a digitized
-	// SIP/AIP.
-	if params.SIP.Type == enums.SIPTypeDigitizedSIP || params.SIP.Type == enums.SIPTypeDigitizedAIP {
-		entries, err := os.ReadDir(params.SIP.ContentPath)
-		if err != nil {
+	// Finish an interrupted rollback and start over.
+	if j != nil && j.State == transformRollingBack {
+		if err := a.rollback(j); err != nil {
 			return nil, err
 		}
+		j = nil
+	}
 
-		p := filepath.Join(
-			params.SIP.ContentPath,
-			entries[0].Name(), // dossier name.
-			"Prozess_Digitalisierung_PREMIS.xml",
-		)
-
-		err = fsutil.Move(p, filepath.Join(mdPath, "Prozess_Digitalisierung_PREMIS.xml"))
+	if j == nil {
+		j, err = planTransform(params.SIP)
 		if err != nil {
 			return nil, err
 		}
+		if err := j.save(); err != nil {
+			return nil, fmt.Errorf("TransformSIP: %v", err)
+		}
 	}
 
-	// Move the UpdatedAreldaMetatdata.xml and logical metadata files to the
-	// metadata directory (AIP only).
-	if params.SIP.IsAIP() {
-		err := fsutil.Move(
-			params.SIP.UpdatedAreldaMDPath,
-			filepath.Join(mdPath, filepath.Base(params.SIP.UpdatedAreldaMDPath)),
-		)
-		if err != nil {
+	if j.State == transformApplying {
+		if err := j.apply(); err != nil {
+			if rbErr := a.rollback(j); rbErr != nil {
+				return nil, errors.Join(err, rbErr)
+			}
 			return nil, err
 		}
-
-		err = fsutil.Move(
-			params.SIP.LogicalMDPath,
-			filepath.Join(mdPath, filepath.Base(params.SIP.LogicalMDPath)),
-		)
-		if err != nil {
-			return nil, err
+		if err := j.setState(transformCommitted); err != nil {
+			return nil, fmt.Errorf("TransformSIP: %v", err)
 		}
 	}
 
-	// Create objects and [sip-name] sub-directories.
-	objectsPath := filepath.Join(params.SIP.Path, "objects", params.SIP.Name())
-	if err := os.MkdirAll(objectsPath, 0o700); err != nil {
-		return nil, err
+	// Remove the old top-level directories, and the SIP inventory as its
+	// paths don't match the PIP layout.
+	for _, path := range params.SIP.TopLevelPaths {
+		if removeErr := os.RemoveAll(path); removeErr != nil {
+			err = errors.Join(err, removeErr)
+		}
+	}
+	if removeErr := inventory.Remove(params.SIP.Path); removeErr != nil {
+		err = errors.Join(err, removeErr)
 	}
-
-	// Move the content directory into the objects directory.
-	err := fsutil.Move(params.SIP.ContentPath, filepath.Join(objectsPath, "content"))
 	if err != nil {
 		return nil, err
 	}
 
-	// Create a header directory in the objects folder.
-	headerPath := filepath.Join(objectsPath, "header")
-	if err = os.MkdirAll(headerPath, 0o700); err != nil {
+	// Set all the file modes.
+	if err = fsutil.SetFileModes(params.SIP.Path, 0o700, 0o600); err != nil {
 		return nil, err
 	}
 
-	// Move the metadata.xml file into the header directory.
-	err = fsutil.Move(params.SIP.MetadataPath, filepath.Join(headerPath, filepath.Base(params.SIP.MetadataPath)))
-	if err != nil {
-		return nil, err
+	pip := pips.NewFromSIP(params.SIP)
+	if err := pip.Verify(); err != nil {
+		return nil, fmt.Errorf("TransformSIP: invalid PIP layout: %v", err)
 	}
 
-	// Remove the old top-level directories.
-	for _, path := range params.SIP.TopLevelPaths {
-		if removeErr := os.RemoveAll(path); err != nil {
-			err = errors.Join(err, removeErr)
-		}
-	}
-	if err != nil {
-		return nil, err
+	if err := j.remove(); err != nil {
+		return nil, fmt.Errorf("TransformSIP: %v", err)
 	}
 
-	// Set all the file modes.
-	if err = fsutil.SetFileModes(params.SIP.Path, 0o700, 0o600); err != nil {
-		return nil, err
+	return &TransformSIPResult{PIP: pip}, nil
+}
+
+// rollback reverts the applied journal steps and removes the journal. The
+// journal is kept if the rollback fails, so it can be resumed on retry.
+func (a *TransformSIP) rollback(j *transformJournal) error {
+	if err := j.setState(transformRollingBack); err != nil {
+		return fmt.Errorf("TransformSIP: rollback: %v", err)
+	}
+	if err := j.rollback(); err != nil {
+		return fmt.Errorf("TransformSIP: rollback: %v", err)
+	}
+	if err := j.remove(); err != nil {
+		return fmt.Errorf("TransformSIP: rollback: %v", err)
 	}
 
-	return &TransformSIPResult{PIP: pips.NewFromSIP(params.SIP)}, nil
+	return nil
 }
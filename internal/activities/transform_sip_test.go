@@ -12,6 +12,8 @@ import (
 	"gotest.tools/v3/fs"
 
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/activities"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/enums"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/pips"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/sip"
 )
 
@@ -178,6 +180,7 @@ func TestTransformSIP(t *testing.T) {
 				temporalsdk_activity.RegisterOptions{Name: activities.TransformSIPName},
 			)
 
+			original := fs.ManifestFromDir(t, tt.params.SIP.Path)
 			_, err := env.ExecuteActivity(activities.TransformSIPName, tt.params)
 
 			if tt.wantErr != "" {
@@ -187,6 +190,8 @@ func TestTransformSIP(t *testing.T) {
 					assert.ErrorContains(t, err, tt.wantErr)
 				}
 
+				// Failed steps are rolled back.
+				assert.Assert(t, fs.Equal(tt.params.SIP.Path, original))
 				return
 			}
 
@@ -195,3 +200,149 @@ func TestTransformSIP(t *testing.T) {
 		})
 	}
 }
+
+func TestTransformSIPJournal(t *testing.T) {
+	t.Parallel()
+
+	var (
+		dmode = os.FileMode(0o700)
+		fmode = os.FileMode(0o600)
+	)
+
+	journal := func(state string) string {
+		return fmt.Sprintf(`{
+	"state": %q,
+	"steps": [
+		{"op": "mkdir", "dest": "metadata"},
+		{"op": "move", "src": "content/d_0000001/Prozess_Digitalisierung_PREMIS.xml", "dest": "metadata/Prozess_Digitalisierung_PREMIS.xml"},
+		{"op": "mkdir", "dest": "objects"},
+		{"op": "mkdir", "dest": "objects/SIP_20201201_Vecteur"},
+		{"op": "move", "src": "content", "dest": "objects/SIP_20201201_Vecteur/content"},
+		{"op": "mkdir", "dest": "objects/SIP_20201201_Vecteur/header"},
+		{"op": "move", "src": "header/metadata.xml", "dest": "objects/SIP_20201201_Vecteur/header/metadata.xml"}
+	]
+}`, state)
+	}
+
+	expectedPIP := fs.Expected(t,
+		fs.WithDir("objects", fs.WithMode(dmode),
+			fs.WithDir("SIP_20201201_Vecteur", fs.WithMode(dmode),
+				fs.WithDir("content", fs.WithMode(dmode),
+					fs.WithDir("d_0000001", fs.WithMode(dmode),
+						fs.WithFile("00000001.jp2", "", fs.WithMode(fmode)),
+					),
+				),
+				fs.WithDir("header", fs.WithMode(dmode),
+					fs.WithFile("metadata.xml", "", fs.WithMode(fmode)),
+				),
+			),
+		),
+		fs.WithDir("metadata", fs.WithMode(dmode),
+			fs.WithFile("Prozess_Digitalisierung_PREMIS.xml", "", fs.WithMode(fmode)),
+		),
+	)
+
+	tests := []struct {
+		name    string
+		layout  []fs.PathOp
+		wantSIP fs.Manifest
+	}{
+		{
+			name: "Resumes an interrupted restructuring",
+			layout: []fs.PathOp{
+				fs.WithFile(".transform-sip.json", journal("applying")),
+				fs.WithDir("header",
+					fs.WithFile("metadata.xml", ""),
+					fs.WithDir("xsd", fs.WithFile("arelda.xsd", "")),
+				),
+				fs.WithDir("metadata",
+					fs.WithFile("Prozess_Digitalisierung_PREMIS.xml", ""),
+				),
+				fs.WithDir("objects",
+					fs.WithDir("SIP_20201201_Vecteur",
+						fs.WithDir("content",
+							fs.WithDir("d_0000001", fs.WithFile("00000001.jp2", "")),
+						),
+					),
+				),
+			},
+			wantSIP: expectedPIP,
+		},
+		{
+			name: "Resumes an interrupted top-level directories removal",
+			layout: []fs.PathOp{
+				fs.WithFile(".transform-sip.json", journal("committed")),
+				fs.WithDir("header",
+					fs.WithDir("xsd"),
+				),
+				fs.WithDir("metadata",
+					fs.WithFile("Prozess_Digitalisierung_PREMIS.xml", ""),
+				),
+				fs.WithDir("objects",
+					fs.WithDir("SIP_20201201_Vecteur",
+						fs.WithDir("content",
+							fs.WithDir("d_0000001", fs.WithFile("00000001.jp2", "")),
+						),
+						fs.WithDir("header", fs.WithFile("metadata.xml", "")),
+					),
+				),
+			},
+			wantSIP: expectedPIP,
+		},
+		{
+			name: "Completes an interrupted rollback before restructuring",
+			layout: []fs.PathOp{
+				fs.WithFile(".transform-sip.json", journal("rolling-back")),
+				fs.WithDir("header",
+					fs.WithFile("metadata.xml", ""),
+					fs.WithDir("xsd", fs.WithFile("arelda.xsd", "")),
+				),
+				fs.WithDir("metadata",
+					fs.WithFile("Prozess_Digitalisierung_PREMIS.xml", ""),
+				),
+				fs.WithDir("objects",
+					fs.WithDir("SIP_20201201_Vecteur",
+						fs.WithDir("content",
+							fs.WithDir("d_0000001", fs.WithFile("00000001.jp2", "")),
+						),
+					),
+				),
+			},
+			wantSIP: expectedPIP,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := fs.NewDir(t, "", fs.WithDir("SIP_20201201_Vecteur", tt.layout...)).Join("SIP_20201201_Vecteur")
+			s := sip.SIP{
+				Type:         enums.SIPTypeDigitizedSIP,
+				Path:         path,
+				ContentPath:  filepath.Join(path, "content"),
+				ManifestPath: filepath.Join(path, "header", "metadata.xml"),
+				MetadataPath: filepath.Join(path, "header", "metadata.xml"),
+				XSDPath:      filepath.Join(path, "header", "xsd", "arelda.xsd"),
+				TopLevelPaths: []string{
+					filepath.Join(path, "content"),
+					filepath.Join(path, "header"),
+				},
+			}
+
+			ts := &temporalsdk_testsuite.WorkflowTestSuite{}
+			env := ts.NewTestActivityEnvironment()
+			env.RegisterActivityWithOptions(
+				activities.NewTransformSIP().Execute,
+				temporalsdk_activity.RegisterOptions{Name: activities.TransformSIPName},
+			)
+
+			enc, err := env.ExecuteActivity(activities.TransformSIPName, activities.TransformSIPParams{SIP: s})
+			assert.NilError(t, err)
+
+			var result activities.TransformSIPResult
+			_ = enc.Get(&result)
+			assert.DeepEqual(t, result.PIP, pips.NewFromSIP(s))
+			assert.Assert(t, fs.Equal(path, tt.wantSIP))
+		})
+	}
+}
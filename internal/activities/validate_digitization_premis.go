@@ -0,0 +1,269 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/beevik/etree"
+	"go.artefactual.dev/tools/temporal"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/fformat"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/inventory"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/report"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/sip"
+)
+
+const ValidateDigitizationPREMISName = "validate-digitization-premis"
+
+// digitizationPREMISSuffix is the file name suffix of the PREMIS files
+// describing the digitized files, e.g. "00000001_PREMIS.xml".
+const digitizationPREMISSuffix = "_PREMIS.xml"
+
+type (
+	ValidateDigitizationPREMIS struct {
+		identifier fformat.Identifier
+	}
+	ValidateDigitizationPREMISParams struct {
+		SIP sip.SIP
+	}
+	ValidateDigitizationPREMISResult struct {
+		Failures []string
+		Entries  []report.Entry
+
+		// Files is the number of files checked against their PREMIS
+		// declaration.
+		Files int
+	}
+)
+
+// declaredFile is a file described by a digitization PREMIS object.
+type declaredFile struct {
+	// Name of the file, relative to the PREMIS file directory.
+	Name string
+
+	// PUID is the declared PRONOM format ID, empty if not declared.
+	PUID string
+
+	// Size is the declared size in bytes, -1 if not declared.
+	Size int64
+
+	// MD5 is the declared MD5 digest, empty if not declared.
+	MD5 string
+}
+
+// NewValidateDigitizationPREMIS returns a ValidateDigitizationPREMIS activity
+// identifying the file formats missing from the SIP inventory with identifier.
+func NewValidateDigitizationPREMIS(identifier fformat.Identifier) *ValidateDigitizationPREMIS {
+	return &ValidateDigitizationPREMIS{identifier: identifier}
+}
+
+// Execute checks the files described by the per-file PREMIS documents of a
+// digitized SIP (e.g. "00000001_PREMIS.xml", but not
+// "Prozess_Digitalisierung_PREMIS.xml"). The PRONOM format, size and MD5
+// digest declared by the digitization vendor for each file are compared with
+// the format identified by Siegfried and the file on disk, using the SIP
+// inventory when possible. Each mismatch is reported as a failure.
+//
+// PREMIS objects of files that are not in the SIP are ignored, unless they
+// declare a size or a digest, as digitization PREMIS files also describe the
+// source files that are not delivered (e.g. the scanned TIFF images).
+func (a *ValidateDigitizationPREMIS) Execute(
+	ctx context.Context,
+	params *ValidateDigitizationPREMISParams,
+) (*ValidateDigitizationPREMISResult, error) {
+	h := temporal.StartAutoHeartbeat(ctx)
+	defer h.Stop()
+
+	inv, err := inventory.Load(params.SIP.Path)
+	if err != nil {
+		return nil, fmt.Errorf("ValidateDigitizationPREMIS: %v", err)
+	}
+
+	formats, err := inv.Formats(ctx, a.identifier, params.SIP)
+	if err != nil {
+		return nil, fmt.Errorf("ValidateDigitizationPREMIS: identify formats: %v", err)
+	}
+
+	content, err := filepath.Rel(params.SIP.Path, params.SIP.ContentPath)
+	if err != nil {
+		return nil, fmt.Errorf("ValidateDigitizationPREMIS: %v", err)
+	}
+
+	res := &ValidateDigitizationPREMISResult{}
+	for _, p := range inv.Files(content) {
+		name := filepath.Base(p)
+		if !strings.HasSuffix(name, digitizationPREMISSuffix) || name == "Prozess_Digitalisierung_PREMIS.xml" {
+			continue
+		}
+
+		premisPath := filepath.Join(content, p)
+		files, err := parseDigitizationPREMIS(filepath.Join(params.SIP.Path, premisPath))
+		if err != nil {
+			res.addFailure(premisEntry(
+				report.CodeDigitizationPREMISInvalid,
+				premisPath,
+				fmt.Sprintf("Unable to read %q: %v", premisPath, err),
+			))
+			continue
+		}
+
+		for _, f := range files {
+			path := filepath.Join(filepath.Dir(premisPath), f.Name)
+			if err := a.checkFile(ctx, params.SIP.Path, inv, formats, premisPath, path, f, res); err != nil {
+				return nil, fmt.Errorf("ValidateDigitizationPREMIS: %v", err)
+			}
+		}
+	}
+
+	return res, nil
+}
+
+// checkFile compares the f file declared in the premisPath PREMIS file with
+// the SIP file at path, and adds a failure to res for each mismatch. The
+// paths are relative to the SIP root directory.
+func (a *ValidateDigitizationPREMIS) checkFile(
+	ctx context.Context,
+	root string,
+	inv *inventory.Inventory,
+	formats fformat.FileFormats,
+	premisPath, path string,
+	f declaredFile,
+	res *ValidateDigitizationPREMISResult,
+) error {
+	e, ok := inv.Entry(path)
+	if !ok || e.Dir {
+		if f.Size >= 0 || f.MD5 != "" {
+			res.addFailure(premisEntry(
+				report.CodeDigitizationPREMISMissingFile,
+				path,
+				fmt.Sprintf("Missing file: %q is described in %q but not found", path, premisPath),
+			))
+		}
+		return nil
+	}
+	res.Files++
+
+	// Unknown and ambiguous formats are reported by the file format
+	// identification, a declared format matching a candidate is accepted.
+	ff := formats[filepath.Join(root, path)]
+	if f.PUID != "" && ff.Known() && ff.ID != f.PUID && !slices.Contains(ff.Candidates, f.PUID) {
+		entry := premisEntry(
+			report.CodeDigitizationPREMISFormatMismatch,
+			path,
+			fmt.Sprintf(
+				"Format mismatch for %q (declared in %q: %q, identified: %q)",
+				path, premisPath, f.PUID, ff.ID,
+			),
+		)
+		entry.Tool = "Siegfried"
+		entry.ToolVersion = a.identifier.Version()
+		res.addFailure(entry)
+	}
+
+	if f.Size >= 0 && f.Size != e.Size {
+		res.addFailure(premisEntry(
+			report.CodeDigitizationPREMISSizeMismatch,
+			path,
+			fmt.Sprintf("Size mismatch for %q (declared in %q: %d, got: %d)", path, premisPath, f.Size, e.Size),
+		))
+	}
+
+	if f.MD5 == "" {
+		return nil
+	}
+	sum, ok := inv.Checksum(path, "MD5")
+	if !ok {
+		var err error
+		sum, err = generateHash(ctx, filepath.Join(root, path), "MD5")
+		if err != nil {
+			return err
+		}
+	}
+	if !strings.EqualFold(sum, f.MD5) {
+		res.addFailure(premisEntry(
+			report.CodeDigitizationPREMISChecksumMismatch,
+			path,
+			fmt.Sprintf("Checksum mismatch for %q (declared in %q: %q, got: %q)", path, premisPath, f.MD5, sum),
+		))
+	}
+
+	return nil
+}
+
+func (r *ValidateDigitizationPREMISResult) addFailure(e report.Entry) {
+	r.Failures = append(r.Failures, e.Message)
+	r.Entries = append(r.Entries, e)
+}
+
+func premisEntry(code report.Code, path, msg string) report.Entry {
+	return report.Entry{
+		Check:    ValidateDigitizationPREMISName,
+		Severity: report.SeverityError,
+		Path:     path,
+		Code:     code,
+		Message:  msg,
+	}
+}
+
+// parseDigitizationPREMIS returns the files described by the file objects of
+// the PREMIS document at path.
+func parseDigitizationPREMIS(path string) ([]declaredFile, error) {
+	doc := etree.NewDocument()
+	if err := doc.ReadFromFile(path); err != nil {
+		return nil, err
+	}
+
+	root := doc.SelectElement("premis")
+	if root == nil {
+		return nil, fmt.Errorf("missing premis root element")
+	}
+
+	var files []declaredFile
+	for _, el := range root.SelectElements("object") {
+		f := declaredFile{
+			Name: elementText(el, "./objectIdentifier/objectIdentifierValue"),
+			Size: -1,
+		}
+		if f.Name == "" {
+			continue
+		}
+
+		if v := elementText(el, "./objectCharacteristics/size"); v != "" {
+			size, err := strconv.ParseInt(v, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("object %q: invalid size: %q", f.Name, v)
+			}
+			f.Size = size
+		}
+
+		for _, fixity := range el.FindElements("./objectCharacteristics/fixity") {
+			if strings.EqualFold(elementText(fixity, "./messageDigestAlgorithm"), "md5") {
+				f.MD5 = elementText(fixity, "./messageDigest")
+			}
+		}
+
+		for _, reg := range el.FindElements("./objectCharacteristics/format/formatRegistry") {
+			if strings.EqualFold(elementText(reg, "./formatRegistryName"), "PRONOM") {
+				f.PUID = elementText(reg, "./formatRegistryKey")
+			}
+		}
+
+		files = append(files, f)
+	}
+
+	return files, nil
+}
+
+// elementText returns the trimmed text of the first element matching path
+// from el, or an empty string if there is none.
+func elementText(el *etree.Element, path string) string {
+	if c := el.FindElement(path); c != nil {
+		return strings.TrimSpace(c.Text())
+	}
+
+	return ""
+}
@@ -0,0 +1,225 @@
+package activities_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	temporalsdk_activity "go.temporal.io/sdk/activity"
+	temporalsdk_testsuite "go.temporal.io/sdk/testsuite"
+	"go.uber.org/mock/gomock"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/activities"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/fformat"
+	fake_fformat "github.com/artefactual-sdps/preprocessing-sfa/internal/fformat/fake"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/report"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/sip"
+)
+
+// digitizationPREMIS returns a digitization PREMIS document describing the
+// name file, and its TIFF source file that isn't part of the SIP.
+func digitizationPREMIS(name, puid string, size int, md5 string) string {
+	return fmt.Sprintf(`<?xml version="1.0" encoding="UTF-8"?>
+<premis version="3.0" xmlns="http://www.loc.gov/premis/v3" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">
+  <object xsi:type="premis:file">
+    <objectIdentifier>
+      <objectIdentifierType>local</objectIdentifierType>
+      <objectIdentifierValue>%[1]s</objectIdentifierValue>
+    </objectIdentifier>
+    <objectCharacteristics>
+      <fixity>
+        <messageDigestAlgorithm>md5</messageDigestAlgorithm>
+        <messageDigest>%[4]s</messageDigest>
+      </fixity>
+      <size>%[3]d</size>
+      <format>
+        <formatDesignation><formatName>JP2</formatName></formatDesignation>
+        <formatRegistry>
+          <formatRegistryName>PRONOM</formatRegistryName>
+          <formatRegistryKey>%[2]s</formatRegistryKey>
+        </formatRegistry>
+      </format>
+    </objectCharacteristics>
+  </object>
+  <object xsi:type="premis:file">
+    <objectIdentifier>
+      <objectIdentifierType>local</objectIdentifierType>
+      <objectIdentifierValue>source.tif</objectIdentifierValue>
+    </objectIdentifier>
+    <objectCharacteristics>
+      <format>
+        <formatRegistry>
+          <formatRegistryName>PRONOM</formatRegistryName>
+          <formatRegistryKey>fmt/353</formatRegistryKey>
+        </formatRegistry>
+      </format>
+    </objectCharacteristics>
+  </object>
+</premis>`, name, puid, size, md5)
+}
+
+func TestValidateDigitizationPREMIS(t *testing.T) {
+	t.Parallel()
+
+	const (
+		jp2Path    = "content/content/d_0000001/00000001.jp2"
+		premisPath = "content/content/d_0000001/00000001_PREMIS.xml"
+		jp2MD5     = "827ccb0eea8a706c4c34a16891f84e7b"
+	)
+
+	jp2Format := &fformat.FileFormat{Namespace: "pronom", ID: "x-fmt/392"}
+
+	type test struct {
+		name   string
+		premis string
+		format *fformat.FileFormat
+		want   activities.ValidateDigitizationPREMISResult
+	}
+	for _, tt := range []test{
+		{
+			name:   "Accepts files matching their digitization PREMIS",
+			premis: digitizationPREMIS("00000001.jp2", "x-fmt/392", 5, jp2MD5),
+			format: jp2Format,
+			want:   activities.ValidateDigitizationPREMISResult{Files: 1},
+		},
+		{
+			name:   "Accepts a declared format that is an identification candidate",
+			premis: digitizationPREMIS("00000001.jp2", "fmt/463", 5, jp2MD5),
+			format: &fformat.FileFormat{Namespace: "pronom", ID: "x-fmt/392", Candidates: []string{"fmt/463"}},
+			want:   activities.ValidateDigitizationPREMISResult{Files: 1},
+		},
+		{
+			name:   "Ignores the declared format of an unknown file format",
+			premis: digitizationPREMIS("00000001.jp2", "x-fmt/392", 5, jp2MD5),
+			format: &fformat.FileFormat{ID: fformat.UnknownID},
+			want:   activities.ValidateDigitizationPREMISResult{Files: 1},
+		},
+		{
+			name:   "Reports format, size and checksum mismatches",
+			premis: digitizationPREMIS("00000001.jp2", "fmt/353", 6, "1e01ba3e07ac48cbdab2d3284d1dd0fa"),
+			format: jp2Format,
+			want: activities.ValidateDigitizationPREMISResult{
+				Files: 1,
+				Failures: []string{
+					`Format mismatch for "` + jp2Path + `" (declared in "` + premisPath + `": "fmt/353", identified: "x-fmt/392")`,
+					`Size mismatch for "` + jp2Path + `" (declared in "` + premisPath + `": 6, got: 5)`,
+					`Checksum mismatch for "` + jp2Path + `" (declared in "` + premisPath +
+						`": "1e01ba3e07ac48cbdab2d3284d1dd0fa", got: "` + jp2MD5 + `")`,
+				},
+				Entries: []report.Entry{
+					{
+						Check:    activities.ValidateDigitizationPREMISName,
+						Severity: report.SeverityError,
+						Path:     jp2Path,
+						Code:     report.CodeDigitizationPREMISFormatMismatch,
+						Message: `Format mismatch for "` + jp2Path + `" (declared in "` + premisPath +
+							`": "fmt/353", identified: "x-fmt/392")`,
+						Tool:        "Siegfried",
+						ToolVersion: "1.11.2",
+					},
+					{
+						Check:    activities.ValidateDigitizationPREMISName,
+						Severity: report.SeverityError,
+						Path:     jp2Path,
+						Code:     report.CodeDigitizationPREMISSizeMismatch,
+						Message:  `Size mismatch for "` + jp2Path + `" (declared in "` + premisPath + `": 6, got: 5)`,
+					},
+					{
+						Check:    activities.ValidateDigitizationPREMISName,
+						Severity: report.SeverityError,
+						Path:     jp2Path,
+						Code:     report.CodeDigitizationPREMISChecksumMismatch,
+						Message: `Checksum mismatch for "` + jp2Path + `" (declared in "` + premisPath +
+							`": "1e01ba3e07ac48cbdab2d3284d1dd0fa", got: "` + jp2MD5 + `")`,
+					},
+				},
+			},
+		},
+		{
+			name:   "Reports a declared file missing from the SIP",
+			premis: digitizationPREMIS("00000003.jp2", "x-fmt/392", 5, jp2MD5),
+			format: jp2Format,
+			want: activities.ValidateDigitizationPREMISResult{
+				Failures: []string{
+					`Missing file: "content/content/d_0000001/00000003.jp2" is described in "` + premisPath +
+						`" but not found`,
+				},
+				Entries: []report.Entry{{
+					Check:    activities.ValidateDigitizationPREMISName,
+					Severity: report.SeverityError,
+					Path:     "content/content/d_0000001/00000003.jp2",
+					Code:     report.CodeDigitizationPREMISMissingFile,
+					Message: `Missing file: "content/content/d_0000001/00000003.jp2" is described in "` + premisPath +
+						`" but not found`,
+				}},
+			},
+		},
+		{
+			name:   "Reports an invalid digitization PREMIS file",
+			premis: "<premis><object>",
+			format: jp2Format,
+			want: activities.ValidateDigitizationPREMISResult{
+				Failures: []string{
+					`Unable to read "` + premisPath + `": etree: invalid XML format`,
+				},
+				Entries: []report.Entry{{
+					Check:    activities.ValidateDigitizationPREMISName,
+					Severity: report.SeverityError,
+					Path:     premisPath,
+					Code:     report.CodeDigitizationPREMISInvalid,
+					Message:  `Unable to read "` + premisPath + `": etree: invalid XML format`,
+				}},
+			},
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := sip.New(fs.NewDir(t, "",
+				fs.WithDir("additional",
+					fs.WithFile("UpdatedAreldaMetadata.xml", ""),
+				),
+				fs.WithDir("content",
+					fs.WithDir("content",
+						fs.WithDir("d_0000001",
+							fs.WithFile("00000001.jp2", "12345"),
+							fs.WithFile("00000001_PREMIS.xml", tt.premis),
+							fs.WithFile("Prozess_Digitalisierung_PREMIS.xml", "<premis/>"),
+						),
+					),
+				),
+			).Path())
+			assert.NilError(t, err)
+
+			// The files are identified by the activity as there is no SIP
+			// inventory.
+			mockIdr := fake_fformat.NewMockIdentifier(gomock.NewController(t))
+			mockIdr.EXPECT().Identify(gomock.Any()).DoAndReturn(func(path string) (*fformat.FileFormat, error) {
+				if filepath.Ext(path) == ".jp2" {
+					return tt.format, nil
+				}
+				return &fformat.FileFormat{Namespace: "pronom", ID: "fmt/101"}, nil
+			}).Times(3)
+			mockIdr.EXPECT().Version().Return("1.11.2").AnyTimes()
+
+			ts := &temporalsdk_testsuite.WorkflowTestSuite{}
+			env := ts.NewTestActivityEnvironment()
+			env.RegisterActivityWithOptions(
+				activities.NewValidateDigitizationPREMIS(mockIdr).Execute,
+				temporalsdk_activity.RegisterOptions{Name: activities.ValidateDigitizationPREMISName},
+			)
+
+			enc, err := env.ExecuteActivity(
+				activities.ValidateDigitizationPREMISName,
+				&activities.ValidateDigitizationPREMISParams{SIP: s},
+			)
+			assert.NilError(t, err)
+
+			var result activities.ValidateDigitizationPREMISResult
+			_ = enc.Get(&result)
+			assert.DeepEqual(t, result, tt.want)
+		})
+	}
+}
@@ -5,52 +5,86 @@ import (
 	"errors"
 	"fmt"
 	"slices"
+	"strings"
+	"sync"
 
-	temporalsdk_activity "go.temporal.io/sdk/activity"
+	"go.artefactual.dev/tools/temporal"
 
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/fformat"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/fvalidate"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/inventory"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/progress"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/report"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/sip"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/toolinfo"
 )
 
 const ValidateFilesName = "validate-files"
 
+// DefaultFileValidateConcurrency is the default number of files validated
+// concurrently by file-scoped validators.
+const DefaultFileValidateConcurrency = 4
+
 type (
 	ValidateFiles struct {
-		identifier fformat.Identifier
-		validators []fvalidate.Validator
+		identifier  fformat.Identifier
+		concurrency int
+		validators  []fvalidate.Validator
 	}
 	ValidateFilesParams struct {
 		SIP sip.SIP
 	}
 	ValidateFilesResult struct {
 		Failures []string
+		Entries  []report.Entry
+
+		// Tools are the validators that validated at least one file, with
+		// their versions.
+		Tools []toolinfo.Tool
 	}
 )
 
-func NewValidateFiles(idr fformat.Identifier, vdrs ...fvalidate.Validator) *ValidateFiles {
+// NewValidateFiles returns a ValidateFiles activity running the vdrs
+// validators. File-scoped validators validate concurrency files at a time, or
+// DefaultFileValidateConcurrency files if concurrency is not positive.
+func NewValidateFiles(idr fformat.Identifier, concurrency int, vdrs ...fvalidate.Validator) *ValidateFiles {
+	if concurrency <= 0 {
+		concurrency = DefaultFileValidateConcurrency
+	}
+
 	return &ValidateFiles{
-		identifier: idr,
-		validators: vdrs,
+		identifier:  idr,
+		concurrency: concurrency,
+		validators:  vdrs,
 	}
 }
 
-// Execute validates SIP files against a file format specification. The
-// only format validator currently implemented verapdf for PDF/A.
+// Execute validates SIP files against a file format specification. Directory
+// validators (e.g. veraPDF for PDF/A) validate the SIP content directory at
+// once, and file validators validate each file with a format they support,
+// one file at a time. Each invalid file is added to the failures, and each of
+// its failures is added as a report entry, naming the validator and its
+// version. The activity heartbeats while the validators run, and reports the
+// FilesValidated progress counter after each file validated.
 func (a *ValidateFiles) Execute(ctx context.Context, params *ValidateFilesParams) (*ValidateFilesResult, error) {
-	logger := temporalsdk_activity.GetLogger(ctx)
+	logger := temporal.GetLogger(ctx)
+
+	inv, err := inventory.Load(params.SIP.Path)
+	if err != nil {
+		return nil, fmt.Errorf("identifyFormats: %v", err)
+	}
 
-	formats, err := fformat.IdentifyFormats(ctx, a.identifier, params.SIP)
+	formats, err := inv.Formats(ctx, a.identifier, params.SIP)
 	if err != nil {
 		return nil, fmt.Errorf("identifyFormats: %v", err)
 	}
 
-	failures, err := a.validateFiles(params.SIP, formats)
+	res, err := a.validateFiles(ctx, params.SIP, formats)
 	if err != nil {
 		var se *fvalidate.SystemError
 		if errors.As(err, &se) {
 			// Log the underlying system error and the validator name.
-			logger.Error(se.Error(), "validator", se.Validator())
+			logger.Error(se, "File validation system error.", "validator", se.Validator())
 
 			// Return a user-friendly error message.
 			return nil, errors.New(se.Message())
@@ -59,56 +93,168 @@ func (a *ValidateFiles) Execute(ctx context.Context, params *ValidateFilesParams
 		return nil, fmt.Errorf("validateFiles: %v", err)
 	}
 
-	return &ValidateFilesResult{Failures: failures}, nil
+	return res, nil
 }
 
 func (a *ValidateFiles) validateFiles(
+	ctx context.Context,
 	sip sip.SIP,
 	files fformat.FileFormats,
-) ([]string, error) {
-	var failures []string
-	for _, v := range a.validators {
+) (*ValidateFilesResult, error) {
+	var (
+		paths    = make([][]string, len(a.validators))
+		counter  progress.Counter
+		reporter = progress.NewReporter(ctx, progress.FilesValidated)
+	)
+	for i, v := range a.validators {
+		paths[i] = supportedFiles(v, files)
+		counter.Total += int64(len(paths[i]))
+	}
+	reporter.Report(counter)
+
+	res := &ValidateFilesResult{}
+	for i, v := range a.validators {
+		if len(paths[i]) == 0 {
+			continue
+		}
+
 		var (
-			out string
+			out []fvalidate.FileResult
 			err error
 		)
 
-		if v.Scope() == fvalidate.TargetTypeDir {
-			out, err = validateDir(v, sip.ContentPath, files)
-		} else {
-			return nil, fmt.Errorf("unsupported validator scope")
+		// Keep heartbeating while the validator runs, validating a directory
+		// or a single large file may take longer than the heartbeat timeout.
+		h := temporal.StartAutoHeartbeat(ctx)
+		switch v.Scope() {
+		case fvalidate.TargetTypeDir:
+			out, err = v.Validate(ctx, sip.ContentPath)
+			counter.Done += int64(len(paths[i]))
+			reporter.Report(counter)
+		case fvalidate.TargetTypeFile:
+			out, err = validateFiles(ctx, v, paths[i], a.concurrency, func() {
+				counter.Done++
+				reporter.Report(counter)
+			})
+		default:
+			err = fmt.Errorf("unsupported validator scope")
 		}
+		h.Stop()
 
 		if err != nil {
 			return nil, err
 		}
-		if out != "" {
-			failures = append(failures, out)
+
+		// The version is informational, don't fail the validation if it's
+		// not available.
+		version, _ := v.Version(ctx)
+		tool := toolinfo.Tool{Name: v.Name(), Version: version}
+		res.Tools = append(res.Tools, tool)
+
+		for _, r := range out {
+			path := relPath(sip.Path, r.Path)
+			res.Failures = append(res.Failures, fmt.Sprintf(
+				"%q failed %s validation: %s", path, tool, strings.Join(r.Failures, "; "),
+			))
+			for _, f := range r.Failures {
+				res.Entries = append(res.Entries, validatorEntry(tool.Name, tool.Version, path, f))
+			}
 		}
 	}
 
-	return failures, nil
+	return res, nil
 }
 
-func validateDir(v fvalidate.Validator, path string, ff fformat.FileFormats) (string, error) {
-	var canValidate bool
+// validatorEntry returns a report entry for a validation failure found in the
+// file at path by the named validator.
+func validatorEntry(name, version, path, msg string) report.Entry {
+	return report.Entry{
+		Check:       ValidateFilesName,
+		Severity:    report.SeverityError,
+		Path:        path,
+		Code:        report.CodeFileFormatInvalid,
+		Message:     msg,
+		Tool:        name,
+		ToolVersion: version,
+	}
+}
+
+// supportedFiles returns the paths of the files in ff with a format that v can
+// validate, in path order.
+func supportedFiles(v fvalidate.Validator, ff fformat.FileFormats) []string {
 	allowedIds := v.FormatIDs()
 
-	for _, f := range ff {
+	var paths []string
+	for path, f := range ff {
 		if slices.Contains(allowedIds, f.ID) {
-			canValidate = true
-			break
+			paths = append(paths, path)
 		}
 	}
+	slices.Sort(paths)
+
+	return paths
+}
+
+// validateFiles validates each file in paths with v, running concurrency
+// validations at a time, and calls validated after each file, one call at a
+// time. The results are returned in paths order, and validation stops at the
+// first error, cancelling the validations in progress.
+func validateFiles(
+	ctx context.Context,
+	v fvalidate.Validator,
+	paths []string,
+	concurrency int,
+	validated func(),
+) ([]fvalidate.FileResult, error) {
+	var (
+		mu       sync.Mutex
+		results  = make([][]fvalidate.FileResult, len(paths))
+		firstErr error
+	)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
 
-	if !canValidate {
-		return "", nil
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(concurrency, len(paths)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				out, err := v.Validate(ctx, paths[i])
+
+				mu.Lock()
+				if err != nil && firstErr == nil {
+					firstErr = err
+					cancel()
+				}
+				results[i] = out
+				validated()
+				mu.Unlock()
+			}
+		}()
 	}
 
-	out, err := v.Validate(path)
-	if err != nil {
-		return "", err
+	// Stop sending files once a validation fails (ctx is canceled) or the
+	// activity is canceled.
+send:
+	for i := range paths {
+		select {
+		case <-ctx.Done():
+			break send
+		case jobs <- i:
+		}
+	}
+	close(jobs)
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	return out, nil
+	return slices.Concat(results...), nil
 }
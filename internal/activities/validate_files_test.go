@@ -1,13 +1,18 @@
 package activities_test
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"path/filepath"
 	"testing"
+	"time"
 
 	temporalsdk_activity "go.temporal.io/sdk/activity"
+	temporalsdk_temporal "go.temporal.io/sdk/temporal"
 	temporalsdk_testsuite "go.temporal.io/sdk/testsuite"
+	temporalsdk_worker "go.temporal.io/sdk/worker"
+	temporalsdk_workflow "go.temporal.io/sdk/workflow"
 	"go.uber.org/mock/gomock"
 	"gotest.tools/v3/assert"
 	"gotest.tools/v3/fs"
@@ -17,9 +22,16 @@ import (
 	fake_fformat "github.com/artefactual-sdps/preprocessing-sfa/internal/fformat/fake"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/fvalidate"
 	fake_fvalidate "github.com/artefactual-sdps/preprocessing-sfa/internal/fvalidate/fake"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/progress"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/report"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/sip"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/toolinfo"
 )
 
+// testActivityRunID is the workflow run ID of the activities executed by the
+// Temporal test environment.
+const testActivityRunID = "default-test-run-id"
+
 func TestValidateFiles(t *testing.T) {
 	t.Parallel()
 
@@ -76,31 +88,76 @@ func TestValidateFiles(t *testing.T) {
 		expectVld func(*fake_fvalidate.MockValidatorMockRecorder)
 		want      activities.ValidateFilesResult
 		wantErr   string
+
+		// wantProgress is the FilesValidated counter last reported.
+		wantProgress progress.Counter
 	}{
 		{
-			name:     "Validates a PDF/A file",
-			params:   activities.ValidateFilesParams{SIP: digitizedAIP},
-			expectId: defaultIdentifierMock,
+			name:         "Validates a PDF/A file",
+			wantProgress: progress.Counter{Done: 1, Total: 1},
+			params:       activities.ValidateFilesParams{SIP: digitizedAIP},
+			expectId:     defaultIdentifierMock,
 			expectVld: func(m *fake_fvalidate.MockValidatorMockRecorder) {
 				m.Scope().Return(fvalidate.TargetTypeDir)
 				m.FormatIDs().Return([]string{"fmt/354"})
-				m.Validate(digitizedAIP.ContentPath).Return("", nil)
+				m.Validate(gomock.Any(), digitizedAIP.ContentPath).Return(nil, nil)
+				m.Name().Return("veraPDF")
+				m.Version(gomock.Any()).Return("1.24.1", nil)
+			},
+			want: activities.ValidateFilesResult{
+				Tools: []toolinfo.Tool{{Name: "veraPDF", Version: "1.24.1"}},
 			},
 		},
 		{
-			name:     "Reports PDF validation errors",
-			params:   activities.ValidateFilesParams{SIP: digitizedAIP},
-			expectId: defaultIdentifierMock,
+			name:         "Reports PDF validation errors",
+			wantProgress: progress.Counter{Done: 1, Total: 1},
+			params:       activities.ValidateFilesParams{SIP: digitizedAIP},
+			expectId:     defaultIdentifierMock,
 			expectVld: func(m *fake_fvalidate.MockValidatorMockRecorder) {
 				m.Scope().Return(fvalidate.TargetTypeDir)
 				m.FormatIDs().Return([]string{"fmt/354"})
-				m.Validate(digitizedAIP.ContentPath).Return(
-					"One or more PDF/A files are invalid",
+				m.Validate(gomock.Any(), digitizedAIP.ContentPath).Return(
+					[]fvalidate.FileResult{
+						{
+							Path: filepath.Join(digitizedAIP.ContentPath, "d_0000001", "test.pdf"),
+							Failures: []string{
+								"ISO 19005-1:2005 6.1.2-1: The % character of the file header shall occur at byte offset 0",
+								"ISO 19005-1:2005 6.1.3-1: The file trailer dictionary shall contain the ID keyword",
+							},
+						},
+					},
 					nil,
 				)
+				m.Name().Return("veraPDF")
+				m.Version(gomock.Any()).Return("1.24.1", nil)
 			},
 			want: activities.ValidateFilesResult{
-				Failures: []string{"One or more PDF/A files are invalid"},
+				Failures: []string{
+					`"content/content/d_0000001/test.pdf" failed veraPDF 1.24.1 validation: ` +
+						"ISO 19005-1:2005 6.1.2-1: The % character of the file header shall occur at byte offset 0; " +
+						"ISO 19005-1:2005 6.1.3-1: The file trailer dictionary shall contain the ID keyword",
+				},
+				Entries: []report.Entry{
+					{
+						Check:       activities.ValidateFilesName,
+						Severity:    report.SeverityError,
+						Path:        "content/content/d_0000001/test.pdf",
+						Code:        report.CodeFileFormatInvalid,
+						Message:     "ISO 19005-1:2005 6.1.2-1: The % character of the file header shall occur at byte offset 0",
+						Tool:        "veraPDF",
+						ToolVersion: "1.24.1",
+					},
+					{
+						Check:       activities.ValidateFilesName,
+						Severity:    report.SeverityError,
+						Path:        "content/content/d_0000001/test.pdf",
+						Code:        report.CodeFileFormatInvalid,
+						Message:     "ISO 19005-1:2005 6.1.3-1: The file trailer dictionary shall contain the ID keyword",
+						Tool:        "veraPDF",
+						ToolVersion: "1.24.1",
+					},
+				},
+				Tools: []toolinfo.Tool{{Name: "veraPDF", Version: "1.24.1"}},
 			},
 		},
 		{
@@ -110,8 +167,8 @@ func TestValidateFiles(t *testing.T) {
 			expectVld: func(m *fake_fvalidate.MockValidatorMockRecorder) {
 				m.Scope().Return(fvalidate.TargetTypeDir)
 				m.FormatIDs().Return([]string{"fmt/354"})
-				m.Validate(digitizedAIP.ContentPath).Return(
-					"",
+				m.Validate(gomock.Any(), digitizedAIP.ContentPath).Return(
+					nil,
 					errors.New("validate: file not found: /fake/path"),
 				)
 			},
@@ -124,8 +181,8 @@ func TestValidateFiles(t *testing.T) {
 			expectVld: func(m *fake_fvalidate.MockValidatorMockRecorder) {
 				m.Scope().Return(fvalidate.TargetTypeDir)
 				m.FormatIDs().Return([]string{"fmt/354"})
-				m.Validate(digitizedAIP.ContentPath).Return(
-					"",
+				m.Validate(gomock.Any(), digitizedAIP.ContentPath).Return(
+					nil,
 					fvalidate.NewSystemError(
 						"veraPDF",
 						1,
@@ -137,11 +194,68 @@ func TestValidateFiles(t *testing.T) {
 			wantErr: "PDF/A validation failed with an application error",
 		},
 		{
-			name:     "Error when validator scope is not supported",
+			name:         "Validates each file with a file validator",
+			wantProgress: progress.Counter{Done: 1, Total: 1},
+			params:       activities.ValidateFilesParams{SIP: digitizedAIP},
+			expectId:     defaultIdentifierMock,
+			expectVld: func(m *fake_fvalidate.MockValidatorMockRecorder) {
+				m.Scope().Return(fvalidate.TargetTypeFile)
+				m.FormatIDs().Return([]string{"fmt/354"})
+				m.Validate(gomock.Any(), filepath.Join(digitizedAIP.ContentPath, "d_0000001", "test.pdf")).Return(
+					[]fvalidate.FileResult{
+						{
+							Path:     filepath.Join(digitizedAIP.ContentPath, "d_0000001", "test.pdf"),
+							Failures: []string{"Invalid page tree"},
+						},
+					},
+					nil,
+				)
+				m.Name().Return("JHOVE")
+				m.Version(gomock.Any()).Return("1.28.0", nil)
+			},
+			want: activities.ValidateFilesResult{
+				Failures: []string{
+					`"content/content/d_0000001/test.pdf" failed JHOVE 1.28.0 validation: Invalid page tree`,
+				},
+				Entries: []report.Entry{
+					{
+						Check:       activities.ValidateFilesName,
+						Severity:    report.SeverityError,
+						Path:        "content/content/d_0000001/test.pdf",
+						Code:        report.CodeFileFormatInvalid,
+						Message:     "Invalid page tree",
+						Tool:        "JHOVE",
+						ToolVersion: "1.28.0",
+					},
+				},
+				Tools: []toolinfo.Tool{{Name: "JHOVE", Version: "1.28.0"}},
+			},
+		},
+		{
+			name:     "Returns a file validator error",
 			params:   activities.ValidateFilesParams{SIP: digitizedAIP},
 			expectId: defaultIdentifierMock,
 			expectVld: func(m *fake_fvalidate.MockValidatorMockRecorder) {
 				m.Scope().Return(fvalidate.TargetTypeFile)
+				m.FormatIDs().Return([]string{"fmt/354", "fmt/101"})
+				m.Validate(gomock.Any(), filepath.Join(digitizedAIP.ContentPath, "d_0000001", "test.pdf")).Return(
+					nil,
+					errors.New("validate: permission denied"),
+				).MaxTimes(1)
+				m.Validate(
+					gomock.Any(),
+					filepath.Join(digitizedAIP.ContentPath, "d_0000001", "Prozess_Digitalisierung_PREMIS.xml"),
+				).Return(nil, nil).MaxTimes(1)
+			},
+			wantErr: "validateFiles: validate: permission denied",
+		},
+		{
+			name:     "Error when validator scope is not supported",
+			params:   activities.ValidateFilesParams{SIP: digitizedAIP},
+			expectId: defaultIdentifierMock,
+			expectVld: func(m *fake_fvalidate.MockValidatorMockRecorder) {
+				m.FormatIDs().Return([]string{"fmt/354"})
+				m.Scope().Return(fvalidate.TargetType(-1))
 			},
 			wantErr: "validateFiles: unsupported validator scope",
 		},
@@ -169,7 +283,6 @@ func TestValidateFiles(t *testing.T) {
 				)
 			},
 			expectVld: func(m *fake_fvalidate.MockValidatorMockRecorder) {
-				m.Scope().Return(fvalidate.TargetTypeDir)
 				m.FormatIDs().Return([]string{"fmt/354"})
 			},
 		},
@@ -193,8 +306,12 @@ func TestValidateFiles(t *testing.T) {
 				tt.expectVld(mockVdr.EXPECT())
 			}
 
+			store := progress.NewStore()
+			env.SetWorkerOptions(temporalsdk_worker.Options{
+				BackgroundActivityContext: progress.WithStore(context.Background(), store),
+			})
 			env.RegisterActivityWithOptions(
-				activities.NewValidateFiles(mockIdr, mockVdr).Execute,
+				activities.NewValidateFiles(mockIdr, 0, mockVdr).Execute,
 				temporalsdk_activity.RegisterOptions{Name: activities.ValidateFilesName},
 			)
 
@@ -214,6 +331,74 @@ func TestValidateFiles(t *testing.T) {
 			_ = enc.Get(&result)
 
 			assert.DeepEqual(t, result, tt.want)
+			assert.Equal(t, store.Counters(testActivityRunID)[progress.FilesValidated], tt.wantProgress)
 		})
 	}
 }
+
+func TestValidateFilesHeartbeats(t *testing.T) {
+	t.Parallel()
+
+	s, err := sip.New(fs.NewDir(t, "",
+		fs.WithDir("content",
+			fs.WithDir("d_0000001",
+				fs.WithFile("00000001_PREMIS.xml", ""),
+			),
+		),
+		fs.WithDir("header",
+			fs.WithFile("metadata.xml", ""),
+		),
+	).Path())
+	assert.NilError(t, err)
+
+	ctrl := gomock.NewController(t)
+	mockIdr := fake_fformat.NewMockIdentifier(ctrl)
+	mockIdr.EXPECT().Identify(gomock.Any()).Return(&fformat.FileFormat{Namespace: "PRONOM", ID: "fmt/101"}, nil).AnyTimes()
+
+	// The validator takes longer than the heartbeat timeout to validate a
+	// single file.
+	mockVdr := fake_fvalidate.NewMockValidator(ctrl)
+	mockVdr.EXPECT().Scope().Return(fvalidate.TargetTypeFile)
+	mockVdr.EXPECT().FormatIDs().Return([]string{"fmt/101"})
+	mockVdr.EXPECT().Validate(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, path string) ([]fvalidate.FileResult, error) {
+			select {
+			case <-time.After(5 * time.Second):
+				return nil, nil
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		},
+	)
+	mockVdr.EXPECT().Name().Return("xmllint")
+	mockVdr.EXPECT().Version(gomock.Any()).Return("20913", nil)
+
+	ts := &temporalsdk_testsuite.WorkflowTestSuite{}
+	env := ts.NewTestWorkflowEnvironment()
+	env.SetTestTimeout(time.Minute)
+	env.RegisterActivityWithOptions(
+		activities.NewValidateFiles(mockIdr, 0, mockVdr).Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.ValidateFilesName},
+	)
+
+	var result activities.ValidateFilesResult
+	env.ExecuteWorkflow(func(ctx temporalsdk_workflow.Context) error {
+		ctx = temporalsdk_workflow.WithActivityOptions(ctx, temporalsdk_workflow.ActivityOptions{
+			StartToCloseTimeout: time.Minute,
+			HeartbeatTimeout:    2 * time.Second,
+			RetryPolicy:         &temporalsdk_temporal.RetryPolicy{MaximumAttempts: 1},
+		})
+
+		return temporalsdk_workflow.ExecuteActivity(
+			ctx,
+			activities.ValidateFilesName,
+			&activities.ValidateFilesParams{SIP: s},
+		).Get(ctx, &result)
+	})
+
+	assert.Assert(t, env.IsWorkflowCompleted())
+	assert.NilError(t, env.GetWorkflowError())
+	assert.DeepEqual(t, result, activities.ValidateFilesResult{
+		Tools: []toolinfo.Tool{{Name: "xmllint", Version: "20913"}},
+	})
+}
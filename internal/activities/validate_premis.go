@@ -11,6 +11,7 @@ import (
 
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/fsutil"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/premis"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/report"
 )
 
 const ValidatePREMISName = "ValidatePREMIS"
@@ -24,10 +25,15 @@ type (
 	ValidatePREMISParams struct {
 		// Path of the PREMIS XML file to be validated.
 		Path string
+
+		// SIPPath is the path of the SIP containing the PREMIS file, it's used
+		// to report the PREMIS file path relative to the SIP (optional).
+		SIPPath string
 	}
 
 	ValidatePREMISResult struct {
 		Failures []string
+		Entries  []report.Entry
 	}
 )
 
@@ -37,16 +43,30 @@ func NewValidatePREMIS(v xmlvalidate.XSDValidator) *ValidatePREMIS {
 
 // Execute validates the given PREMIS file against an XSD.
 func (a *ValidatePREMIS) Execute(ctx context.Context, params *ValidatePREMISParams) (*ValidatePREMISResult, error) {
-	var failures []string
-
+	res := &ValidatePREMISResult{}
 	logger := temporal.GetLogger(ctx)
 
+	path := filepath.Base(params.Path)
+	if params.SIPPath != "" {
+		path = relPath(params.SIPPath, params.Path)
+	}
+	addFailure := func(code report.Code, msg string) {
+		res.Failures = append(res.Failures, msg)
+		res.Entries = append(res.Entries, report.Entry{
+			Check:    ValidatePREMISName,
+			Severity: report.SeverityError,
+			Path:     path,
+			Code:     code,
+			Message:  msg,
+		})
+	}
+
 	if !fsutil.FileExists(params.Path) {
-		failures = append(
-			failures,
+		addFailure(
+			report.CodeLogicalMetadataMissing,
 			fmt.Sprintf("file not found: %s", filepath.Base(params.Path)),
 		)
-		return &ValidatePREMISResult{Failures: failures}, nil
+		return res, nil
 	}
 
 	xsd, err := a.xsdPath()
@@ -60,13 +80,13 @@ func (a *ValidatePREMIS) Execute(ctx context.Context, params *ValidatePREMISPara
 	}
 	if out != "" {
 		logger.Info("PREMIS validation failed", "file", params.Path, "output", out)
-		failures = append(
-			failures,
+		addFailure(
+			report.CodeLogicalMetadataInvalid,
 			fmt.Sprintf("%s does not match expected metadata requirements", filepath.Base(params.Path)),
 		)
 	}
 
-	return &ValidatePREMISResult{Failures: failures}, nil
+	return res, nil
 }
 
 // xsdPath returns the path to a local PREMIS v3 XSD file, creating the file if
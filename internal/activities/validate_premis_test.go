@@ -17,6 +17,7 @@ import (
 	"gotest.tools/v3/fs"
 
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/activities"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/report"
 )
 
 var premisXML = `<?xml version="1.0" encoding="UTF-8"?>
@@ -64,6 +65,12 @@ func newFakeValidator() *fakeValidator {
 func TestValidatePREMIS(t *testing.T) {
 	t.Parallel()
 
+	sipWithPREMIS := fs.NewDir(t, "enduro-test",
+		fs.WithDir("additional",
+			fs.WithFile("premis.xml", premisXML),
+		),
+	)
+
 	tests := []struct {
 		name      string
 		validator xmlvalidate.XSDValidator
@@ -90,6 +97,35 @@ func TestValidatePREMIS(t *testing.T) {
 			},
 			want: activities.ValidatePREMISResult{
 				Failures: []string{"premis.xml does not match expected metadata requirements"},
+				Entries: []report.Entry{
+					{
+						Check:    activities.ValidatePREMISName,
+						Severity: report.SeverityError,
+						Path:     "premis.xml",
+						Code:     report.CodeLogicalMetadataInvalid,
+						Message:  "premis.xml does not match expected metadata requirements",
+					},
+				},
+			},
+		},
+		{
+			name:      "Reports the PREMIS path relative to the SIP",
+			validator: newFakeValidator().WithMsg("premis.xml:12: parser error"),
+			params: activities.ValidatePREMISParams{
+				Path:    sipWithPREMIS.Join("additional", "premis.xml"),
+				SIPPath: sipWithPREMIS.Path(),
+			},
+			want: activities.ValidatePREMISResult{
+				Failures: []string{"premis.xml does not match expected metadata requirements"},
+				Entries: []report.Entry{
+					{
+						Check:    activities.ValidatePREMISName,
+						Severity: report.SeverityError,
+						Path:     "additional/premis.xml",
+						Code:     report.CodeLogicalMetadataInvalid,
+						Message:  "premis.xml does not match expected metadata requirements",
+					},
+				},
 			},
 		},
 		{
@@ -100,6 +136,15 @@ func TestValidatePREMIS(t *testing.T) {
 			},
 			want: activities.ValidatePREMISResult{
 				Failures: []string{"file not found: premis.xml"},
+				Entries: []report.Entry{
+					{
+						Check:    activities.ValidatePREMISName,
+						Severity: report.SeverityError,
+						Path:     "premis.xml",
+						Code:     report.CodeLogicalMetadataMissing,
+						Message:  "file not found: premis.xml",
+					},
+				},
 			},
 		},
 		{
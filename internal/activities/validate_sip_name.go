@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/report"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/sip"
 )
 
@@ -15,24 +16,59 @@ type ValidateSIPNameParams struct {
 
 type ValidateSIPNameResult struct {
 	Failures []string
+	Entries  []report.Entry
 }
 
-type ValidateSIPName struct{}
+type ValidateSIPName struct {
+	rules sip.NamingRules
+}
 
-func NewValidateSIPName() *ValidateSIPName {
-	return &ValidateSIPName{}
+func NewValidateSIPName(rules sip.NamingRules) *ValidateSIPName {
+	return &ValidateSIPName{rules: rules}
 }
 
+// Execute checks the SIP name against the naming rule of the SIP type. SIP
+// types without a naming rule accept any name.
 func (a *ValidateSIPName) Execute(
 	ctx context.Context,
 	params *ValidateSIPNameParams,
 ) (*ValidateSIPNameResult, error) {
-	var failures []string
+	res := &ValidateSIPNameResult{}
+
+	rule, ok := a.rules[params.SIP.Type]
+	if !ok {
+		return res, nil
+	}
+
+	match, err := rule.Match(params.SIP.Name())
+	if err != nil {
+		return nil, fmt.Errorf("ValidateSIPName: %s: %v", params.SIP.Type, err)
+	}
+	if !match {
+		msg := nameFailure(params.SIP, rule)
+		res.Failures = append(res.Failures, msg)
+		res.Entries = append(res.Entries, report.Entry{
+			Check:    ValidateSIPNameName,
+			Severity: report.SeverityError,
+			Code:     report.CodeSIPInvalidName,
+			Message:  msg,
+		})
+	}
+
+	return res, nil
+}
 
-	// Check SIP name for naming standards.
-	if !params.SIP.HasValidName() {
-		failures = append(failures, fmt.Sprintf("SIP name %q violates naming standard", params.SIP.Name()))
+// nameFailure describes the naming rule the SIP name doesn't match.
+func nameFailure(s sip.SIP, rule sip.NamingRule) string {
+	msg := fmt.Sprintf(
+		"SIP name %q violates the %s naming standard, expected: %s",
+		s.Name(),
+		s.Type,
+		rule.Description,
+	)
+	if rule.Example != "" {
+		msg += fmt.Sprintf(" (e.g. %q)", rule.Example)
 	}
 
-	return &ValidateSIPNameResult{Failures: failures}, nil
+	return msg
 }
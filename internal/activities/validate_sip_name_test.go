@@ -11,9 +11,13 @@ import (
 	"gotest.tools/v3/fs"
 
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/activities"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/enums"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/report"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/sip"
 )
 
+const aipNamingDescription = `[<prefix>_]AIP[_<suffix>], with name parts separated by "_" or "-"`
+
 func TestValidateSIPName(t *testing.T) {
 	t.Parallel()
 
@@ -57,8 +61,39 @@ func TestValidateSIPName(t *testing.T) {
 	)
 	assert.NilError(t, err)
 
+	digitizedAIPName := "little-Test-AIP-Digitization"
+	digitizedAIP, err := sip.New(
+		filepath.Join(aipTempDir(t, digitizedAIPName, true), digitizedAIPName),
+	)
+	assert.NilError(t, err)
+
+	digitizedAIPUnderscoreName := "digitized_AIP_012345"
+	digitizedAIPUnderscore, err := sip.New(
+		filepath.Join(aipTempDir(t, digitizedAIPUnderscoreName, true), digitizedAIPUnderscoreName),
+	)
+	assert.NilError(t, err)
+
+	digitizedAIPBadName := "SIP_20010106_Vecteur_someref"
+	digitizedAIPBad, err := sip.New(
+		filepath.Join(aipTempDir(t, digitizedAIPBadName, true), digitizedAIPBadName),
+	)
+	assert.NilError(t, err)
+
+	bornDigitalAIPName := "Born-Digital-AIP"
+	bornDigitalAIP, err := sip.New(
+		filepath.Join(aipTempDir(t, bornDigitalAIPName, false), bornDigitalAIPName),
+	)
+	assert.NilError(t, err)
+
+	bornDigitalAIPBadName := "Born_Digital_Package"
+	bornDigitalAIPBad, err := sip.New(
+		filepath.Join(aipTempDir(t, bornDigitalAIPBadName, false), bornDigitalAIPBadName),
+	)
+	assert.NilError(t, err)
+
 	tests := []struct {
 		name    string
+		rules   sip.NamingRules
 		params  activities.ValidateSIPNameParams
 		want    activities.ValidateSIPNameResult
 		wantErr string
@@ -81,10 +116,23 @@ func TestValidateSIPName(t *testing.T) {
 			want: activities.ValidateSIPNameResult{
 				Failures: []string{
 					fmt.Sprintf(
-						"SIP name %q violates naming standard",
+						"SIP name %q violates the BornDigitalSIP naming standard, expected: SIP_<YYYYMMDD>_<office>[_<reference>] (e.g. %q)",
 						bornDigitalSIPBadName,
+						"SIP_20201201_someoffice_someref",
 					),
 				},
+				Entries: []report.Entry{
+					{
+						Check:    activities.ValidateSIPNameName,
+						Severity: report.SeverityError,
+						Code:     report.CodeSIPInvalidName,
+						Message: fmt.Sprintf(
+							"SIP name %q violates the BornDigitalSIP naming standard, expected: SIP_<YYYYMMDD>_<office>[_<reference>] (e.g. %q)",
+							bornDigitalSIPBadName,
+							"SIP_20201201_someoffice_someref",
+						),
+					},
+				},
 			},
 		},
 		{
@@ -101,21 +149,151 @@ func TestValidateSIPName(t *testing.T) {
 			want: activities.ValidateSIPNameResult{
 				Failures: []string{
 					fmt.Sprintf(
-						"SIP name %q violates naming standard",
+						"SIP name %q violates the DigitizedSIP naming standard, expected: SIP_<YYYYMMDD>_Vecteur_<reference> (e.g. %q)",
 						digitizedSIPBadName,
+						"SIP_20201201_Vecteur_someref",
 					),
 				},
+				Entries: []report.Entry{
+					{
+						Check:    activities.ValidateSIPNameName,
+						Severity: report.SeverityError,
+						Code:     report.CodeSIPInvalidName,
+						Message: fmt.Sprintf(
+							"SIP name %q violates the DigitizedSIP naming standard, expected: SIP_<YYYYMMDD>_Vecteur_<reference> (e.g. %q)",
+							digitizedSIPBadName,
+							"SIP_20201201_Vecteur_someref",
+						),
+					},
+				},
 			},
 		},
+		{
+			name:   "Validates the name of a digitized AIP",
+			params: activities.ValidateSIPNameParams{SIP: digitizedAIP},
+		},
+		{
+			name:   "Validates the name of a digitized AIP with underscores",
+			params: activities.ValidateSIPNameParams{SIP: digitizedAIPUnderscore},
+		},
+		{
+			name:   "Validates the name of a digitized AIP with a bad name",
+			params: activities.ValidateSIPNameParams{SIP: digitizedAIPBad},
+			want: activities.ValidateSIPNameResult{
+				Failures: []string{
+					fmt.Sprintf(
+						"SIP name %q violates the DigitizedAIP naming standard, expected: %s (e.g. %q)",
+						digitizedAIPBadName,
+						aipNamingDescription,
+						"AIP_20201201_Vecteur_someref",
+					),
+				},
+				Entries: []report.Entry{
+					{
+						Check:    activities.ValidateSIPNameName,
+						Severity: report.SeverityError,
+						Code:     report.CodeSIPInvalidName,
+						Message: fmt.Sprintf(
+							"SIP name %q violates the DigitizedAIP naming standard, expected: %s (e.g. %q)",
+							digitizedAIPBadName,
+							aipNamingDescription,
+							"AIP_20201201_Vecteur_someref",
+						),
+					},
+				},
+			},
+		},
+		{
+			name:   "Validates the name of a born digital AIP",
+			params: activities.ValidateSIPNameParams{SIP: bornDigitalAIP},
+		},
+		{
+			name:   "Validates the name of a born digital AIP with a bad name",
+			params: activities.ValidateSIPNameParams{SIP: bornDigitalAIPBad},
+			want: activities.ValidateSIPNameResult{
+				Failures: []string{
+					fmt.Sprintf(
+						"SIP name %q violates the BornDigitalAIP naming standard, expected: %s (e.g. %q)",
+						bornDigitalAIPBadName,
+						aipNamingDescription,
+						"AIP_20201201_someoffice_someref",
+					),
+				},
+				Entries: []report.Entry{
+					{
+						Check:    activities.ValidateSIPNameName,
+						Severity: report.SeverityError,
+						Code:     report.CodeSIPInvalidName,
+						Message: fmt.Sprintf(
+							"SIP name %q violates the BornDigitalAIP naming standard, expected: %s (e.g. %q)",
+							bornDigitalAIPBadName,
+							aipNamingDescription,
+							"AIP_20201201_someoffice_someref",
+						),
+					},
+				},
+			},
+		},
+		{
+			name: "Validates the name with a configured naming rule",
+			rules: sip.NamingRules{
+				enums.SIPTypeBornDigitalAIP: {
+					Pattern:     `^Born-Digital-AIP$`,
+					Description: "Born-Digital-AIP",
+				},
+			},
+			params: activities.ValidateSIPNameParams{SIP: bornDigitalAIP},
+		},
+		{
+			name: "Reports a configured naming rule without example",
+			rules: sip.NamingRules{
+				enums.SIPTypeBornDigitalAIP: {
+					Pattern:     `^AIP_\d{8}$`,
+					Description: "AIP_<YYYYMMDD>",
+				},
+			},
+			params: activities.ValidateSIPNameParams{SIP: bornDigitalAIP},
+			want: activities.ValidateSIPNameResult{
+				Failures: []string{
+					`SIP name "Born-Digital-AIP" violates the BornDigitalAIP naming standard, expected: AIP_<YYYYMMDD>`,
+				},
+				Entries: []report.Entry{
+					{
+						Check:    activities.ValidateSIPNameName,
+						Severity: report.SeverityError,
+						Code:     report.CodeSIPInvalidName,
+						Message:  `SIP name "Born-Digital-AIP" violates the BornDigitalAIP naming standard, expected: AIP_<YYYYMMDD>`,
+					},
+				},
+			},
+		},
+		{
+			name:   "Accepts any name when the SIP type has no naming rule",
+			rules:  sip.NamingRules{},
+			params: activities.ValidateSIPNameParams{SIP: bornDigitalSIPBad},
+		},
+		{
+			name: "Errors when the naming rule pattern is invalid",
+			rules: sip.NamingRules{
+				enums.SIPTypeBornDigitalSIP: {Pattern: "[", Description: "Invalid"},
+			},
+			params:  activities.ValidateSIPNameParams{SIP: bornDigitalSIP},
+			wantErr: "ValidateSIPName: BornDigitalSIP: invalid naming rule pattern: error parsing regexp: missing closing ]",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
+			rules := tt.rules
+			if rules == nil {
+				rules = sip.DefaultNamingRules()
+			}
+
 			ts := &temporalsdk_testsuite.WorkflowTestSuite{}
 			env := ts.NewTestActivityEnvironment()
 			env.RegisterActivityWithOptions(
-				activities.NewValidateSIPName().Execute,
+				activities.NewValidateSIPName(rules).Execute,
 				temporalsdk_activity.RegisterOptions{Name: activities.ValidateSIPNameName},
 			)
 
@@ -176,3 +354,22 @@ func digitizedSIPTempDir(t *testing.T, sipName string) string {
 		),
 	).Path()
 }
+
+func aipTempDir(t *testing.T, aipName string, digitized bool) string {
+	var dirOps []fs.PathOp
+	if digitized {
+		dirOps = append(dirOps, fs.WithFile("Prozess_Digitalisierung_PREMIS.xml", ""))
+	}
+
+	return fs.NewDir(t, "",
+		fs.WithDir(aipName,
+			fs.WithDir("additional"),
+			fs.WithDir("content",
+				fs.WithDir("content",
+					fs.WithDir("d_0000001", dirOps...),
+				),
+				fs.WithDir("header"),
+			),
+		),
+	).Path()
+}
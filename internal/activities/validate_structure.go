@@ -3,12 +3,13 @@ package activities
 import (
 	"context"
 	"fmt"
-	"io/fs"
 	"path/filepath"
 	"slices"
 	"strings"
 
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/enums"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/inventory"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/report"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/sip"
 )
 
@@ -22,6 +23,7 @@ type (
 
 	ValidateStructureResult struct {
 		Failures []string
+		Entries  []report.Entry
 	}
 )
 
@@ -54,38 +56,36 @@ func (a *ValidateStructure) Execute(
 	ctx context.Context,
 	params *ValidateStructureParams,
 ) (*ValidateStructureResult, error) {
-	var failures []string
+	inv, err := inventory.Load(params.SIP.Path)
+	if err != nil {
+		return nil, fmt.Errorf("ValidateStructure: %v", err)
+	}
 
-	res, err := validateStructure(params.SIP)
-	failures = reportFailures(res, params.SIP)
+	res := validateStructure(params.SIP, inv)
+	failures, entries := reportFailures(res, params.SIP)
 
-	return &ValidateStructureResult{Failures: failures}, err
+	return &ValidateStructureResult{Failures: failures, Entries: entries}, nil
 }
 
-// validateStructure walks the SIP directory tree, counts directory children and
-// checks for structural issues like invalid names or missing directories and
-// files.
-func validateStructure(sip sip.SIP) (*validationResult, error) {
+// validateStructure goes through the SIP inventory, counts directory children
+// and checks for structural issues like invalid names or missing directories
+// and files.
+func validateStructure(sip sip.SIP, inv *inventory.Inventory) *validationResult {
 	res := &validationResult{}
 
-	// Walk the SIP directory tree.
-	err := filepath.WalkDir(sip.Path, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-
-		relativePath, err := filepath.Rel(sip.Path, path)
-		if err != nil {
-			return fmt.Errorf("ValidateStructure: relative path: %w", err)
-		}
+	// The inventory entries are in walk order, parent directories come before
+	// their children.
+	for _, e := range inv.Entries {
+		relativePath := e.Path
+		path := filepath.Join(sip.Path, relativePath)
 
 		// Validate name.
-		if !validateName(d.Name()) {
+		if !validateName(filepath.Base(path)) {
 			res.invalidNames = append(res.invalidNames, relativePath)
 		}
 
 		// Add directories to the list of dirs to check for emptiness later.
-		if d.IsDir() {
+		if e.Dir {
 			res.dirs = append(res.dirs, dir{path: relativePath})
 		} else {
 			res.fileCount += 1
@@ -93,7 +93,7 @@ func validateStructure(sip sip.SIP) (*validationResult, error) {
 
 		// Skip the rest of the checks for the SIP base path.
 		if path == sip.Path {
-			return nil
+			continue
 		}
 
 		// Add this node to its parent directory's child count.
@@ -107,13 +107,13 @@ func validateStructure(sip sip.SIP) (*validationResult, error) {
 
 		// Check for unexpected top level directories.
 		if parentPath == "." {
-			if d.IsDir() && !slices.Contains(sip.TopLevelPaths, path) {
+			if e.Dir && !slices.Contains(sip.TopLevelPaths, path) {
 				res.extraDirs = append(res.extraDirs, relativePath)
 			}
 		}
 
 		// Check for unexpected files in the content directory.
-		if filepath.Dir(path) == sip.ContentPath && !d.IsDir() {
+		if filepath.Dir(path) == sip.ContentPath && !e.Dir {
 			res.extraFiles = append(res.extraFiles, relativePath)
 		}
 
@@ -135,33 +135,51 @@ func validateStructure(sip sip.SIP) (*validationResult, error) {
 		if path == sip.LogicalMDPath {
 			res.hasLogicalMDFile = true
 		}
-
-		return nil
-	})
-	if err != nil {
-		return nil, fmt.Errorf("ValidateStructure: %v", err)
 	}
 
-	return res, nil
+	return res
 }
 
 // reportFailures takes the result of validateStructure and returns a list of
-// human-readable failure messages.
-func reportFailures(res *validationResult, sip sip.SIP) []string {
-	var failures []string
+// human-readable failure messages and the matching report entries.
+func reportFailures(res *validationResult, sip sip.SIP) ([]string, []report.Entry) {
+	var (
+		failures []string
+		entries  []report.Entry
+	)
+	add := func(code report.Code, path, msg string) {
+		failures = append(failures, msg)
+		entries = append(entries, report.Entry{
+			Check:    ValidateStructureName,
+			Severity: report.SeverityError,
+			Path:     path,
+			Code:     code,
+			Message:  msg,
+		})
+	}
+	rel := func(path string) string {
+		if r, err := filepath.Rel(sip.Path, path); err == nil {
+			return r
+		}
+		return path
+	}
 
 	// Report an empty SIP and stop further checks to avoid reporting multiple
 	// failures that are a consequence of the SIP being empty.
 	if len(res.dirs) == 1 && res.fileCount == 0 {
-		failures = append(failures, "The SIP is empty")
-		return failures
+		add(report.CodeStructureEmptySIP, "", "The SIP is empty")
+		return failures, entries
 	}
 
 	// Report empty directories.
 	hasEmptyDir := false
 	for _, node := range res.dirs {
 		if node.children == 0 {
-			failures = append(failures, fmt.Sprintf("An empty directory has been found - %s", node.path))
+			add(
+				report.CodeStructureEmptyDirectory,
+				node.path,
+				fmt.Sprintf("An empty directory has been found - %s", node.path),
+			)
 			hasEmptyDir = true
 		}
 	}
@@ -171,46 +189,58 @@ func reportFailures(res *validationResult, sip sip.SIP) []string {
 
 	// Report invalid file/directory names.
 	for _, path := range res.invalidNames {
-		failures = append(failures, fmt.Sprintf("Name %q contains invalid character(s)", path))
+		add(
+			report.CodeStructureInvalidName,
+			path,
+			fmt.Sprintf("Name %q contains invalid character(s)", path),
+		)
 	}
 
 	// Report missing content directory.
 	if !res.hasContentDir {
-		failures = append(failures, "Content folder is missing")
+		add(report.CodeStructureMissingContentDirectory, rel(sip.ContentPath), "Content folder is missing")
 	}
 
 	// Report missing XSD directory.
 	if !res.hasXSDDir {
-		failures = append(failures, "XSD folder is missing")
+		add(report.CodeStructureMissingXSDDirectory, rel(sip.XSDPath), "XSD folder is missing")
 	}
 
 	// Report missing metadata file.
 	if !res.hasMetadataFile {
-		failures = append(failures, fmt.Sprintf(
-			"%s is missing", filepath.Base(sip.MetadataPath),
-		))
+		add(
+			report.CodeStructureMissingFile,
+			rel(sip.MetadataPath),
+			fmt.Sprintf("%s is missing", filepath.Base(sip.MetadataPath)),
+		)
 	}
 
 	// Report missing UpdatedAreldaMetadata file (AIPs only).
 	if sip.IsAIP() && !res.hasUpdatedAreldaMDFile {
-		failures = append(failures, fmt.Sprintf(
-			"%s is missing", filepath.Base(sip.UpdatedAreldaMDPath),
-		))
+		add(
+			report.CodeStructureMissingFile,
+			rel(sip.UpdatedAreldaMDPath),
+			fmt.Sprintf("%s is missing", filepath.Base(sip.UpdatedAreldaMDPath)),
+		)
 	}
 
 	// Report missing logical metadata file (AIPs only).
 	if sip.IsAIP() && !res.hasLogicalMDFile {
-		failures = append(failures, fmt.Sprintf("%s is missing", filepath.Base(sip.LogicalMDPath)))
+		add(
+			report.CodeStructureMissingFile,
+			rel(sip.LogicalMDPath),
+			fmt.Sprintf("%s is missing", filepath.Base(sip.LogicalMDPath)),
+		)
 	}
 
 	// Report unexpected directories.
 	for _, path := range res.extraDirs {
-		failures = append(failures, fmt.Sprintf("Unexpected directory: %q", path))
+		add(report.CodeStructureUnexpectedDirectory, path, fmt.Sprintf("Unexpected directory: %q", path))
 	}
 
 	// Report unexpected files.
 	for _, path := range res.extraFiles {
-		failures = append(failures, fmt.Sprintf("Unexpected file: %q", path))
+		add(report.CodeStructureUnexpectedFile, path, fmt.Sprintf("Unexpected file: %q", path))
 	}
 
 	// Report more than one dossier in the content dir for digitized SIPs and
@@ -219,14 +249,18 @@ func reportFailures(res *validationResult, sip sip.SIP) []string {
 		for _, d := range res.dirs {
 			if filepath.Join(sip.Path, d.path) == sip.ContentPath {
 				if d.children > 1 {
-					failures = append(failures, "More than one dossier in the content directory")
+					add(
+						report.CodeStructureMultipleDossiers,
+						d.path,
+						"More than one dossier in the content directory",
+					)
 				}
 				break
 			}
 		}
 	}
 
-	return failures
+	return failures, entries
 }
 
 // validateName checks that all characters in the name are valid. Valid
@@ -9,6 +9,7 @@ import (
 	"gotest.tools/v3/fs"
 
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/activities"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/report"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/sip"
 )
 
@@ -139,6 +140,16 @@ func badNamesSIP(t *testing.T) sip.SIP {
 	return testSIP(t, path)
 }
 
+func structureEntry(code report.Code, path, msg string) report.Entry {
+	return report.Entry{
+		Check:    activities.ValidateStructureName,
+		Severity: report.SeverityError,
+		Path:     path,
+		Code:     code,
+		Message:  msg,
+	}
+}
+
 func TestValidateStructure(t *testing.T) {
 	t.Parallel()
 
@@ -159,6 +170,9 @@ func TestValidateStructure(t *testing.T) {
 				Failures: []string{
 					"The SIP is empty",
 				},
+				Entries: []report.Entry{
+					structureEntry(report.CodeStructureEmptySIP, "", "The SIP is empty"),
+				},
 			},
 		},
 		{
@@ -176,6 +190,11 @@ func TestValidateStructure(t *testing.T) {
 					"XSD folder is missing",
 					"metadata.xml is missing",
 				},
+				Entries: []report.Entry{
+					structureEntry(report.CodeStructureMissingContentDirectory, "content", "Content folder is missing"),
+					structureEntry(report.CodeStructureMissingXSDDirectory, "header/xsd/arelda.xsd", "XSD folder is missing"),
+					structureEntry(report.CodeStructureMissingFile, "header/metadata.xml", "metadata.xml is missing"),
+				},
 			},
 		},
 		{
@@ -186,6 +205,18 @@ func TestValidateStructure(t *testing.T) {
 					`Unexpected directory: "unexpected"`,
 					`Unexpected file: "content/unexpected.txt"`,
 				},
+				Entries: []report.Entry{
+					structureEntry(
+						report.CodeStructureUnexpectedDirectory,
+						"unexpected",
+						`Unexpected directory: "unexpected"`,
+					),
+					structureEntry(
+						report.CodeStructureUnexpectedFile,
+						"content/unexpected.txt",
+						`Unexpected file: "content/unexpected.txt"`,
+					),
+				},
 			},
 		},
 		{
@@ -204,6 +235,10 @@ func TestValidateStructure(t *testing.T) {
 					"Content folder is missing",
 					"XSD folder is missing",
 				},
+				Entries: []report.Entry{
+					structureEntry(report.CodeStructureMissingContentDirectory, "content", "Content folder is missing"),
+					structureEntry(report.CodeStructureMissingXSDDirectory, "header/xsd/arelda.xsd", "XSD folder is missing"),
+				},
 			},
 		},
 		{
@@ -215,6 +250,13 @@ func TestValidateStructure(t *testing.T) {
 			params: activities.ValidateStructureParams{SIP: digitizedSIP(t, true)},
 			want: activities.ValidateStructureResult{
 				Failures: []string{"More than one dossier in the content directory"},
+				Entries: []report.Entry{
+					structureEntry(
+						report.CodeStructureMultipleDossiers,
+						"content",
+						"More than one dossier in the content directory",
+					),
+				},
 			},
 		},
 		{
@@ -226,6 +268,23 @@ func TestValidateStructure(t *testing.T) {
 					"Name \"content/d_0000001/00000001$_PREMIS.xml\" contains invalid character(s)",
 					"Name \"header/content!.txt\" contains invalid character(s)",
 				},
+				Entries: []report.Entry{
+					structureEntry(
+						report.CodeStructureInvalidName,
+						"content/d_0000001/00000001$.jp2",
+						"Name \"content/d_0000001/00000001$.jp2\" contains invalid character(s)",
+					),
+					structureEntry(
+						report.CodeStructureInvalidName,
+						"content/d_0000001/00000001$_PREMIS.xml",
+						"Name \"content/d_0000001/00000001$_PREMIS.xml\" contains invalid character(s)",
+					),
+					structureEntry(
+						report.CodeStructureInvalidName,
+						"header/content!.txt",
+						"Name \"header/content!.txt\" contains invalid character(s)",
+					),
+				},
 			},
 		},
 		{
@@ -240,6 +299,13 @@ func TestValidateStructure(t *testing.T) {
 					"An empty directory has been found - content/content/d_0000001",
 					"Please remove the empty directories and update the metadata manifest accordingly",
 				},
+				Entries: []report.Entry{
+					structureEntry(
+						report.CodeStructureEmptyDirectory,
+						"content/content/d_0000001",
+						"An empty directory has been found - content/content/d_0000001",
+					),
+				},
 			},
 		},
 		{
@@ -264,6 +330,33 @@ func TestValidateStructure(t *testing.T) {
 					"UpdatedAreldaMetadata.xml is missing",
 					"AIP-1234-premis.xml is missing",
 				},
+				Entries: []report.Entry{
+					structureEntry(
+						report.CodeStructureMissingContentDirectory,
+						"content/content",
+						"Content folder is missing",
+					),
+					structureEntry(
+						report.CodeStructureMissingXSDDirectory,
+						"content/header/xsd/arelda.xsd",
+						"XSD folder is missing",
+					),
+					structureEntry(
+						report.CodeStructureMissingFile,
+						"content/header/old/SIP/metadata.xml",
+						"metadata.xml is missing",
+					),
+					structureEntry(
+						report.CodeStructureMissingFile,
+						"additional/UpdatedAreldaMetadata.xml",
+						"UpdatedAreldaMetadata.xml is missing",
+					),
+					structureEntry(
+						report.CodeStructureMissingFile,
+						"additional/AIP-1234-premis.xml",
+						"AIP-1234-premis.xml is missing",
+					),
+				},
 			},
 		},
 	}
@@ -10,22 +10,37 @@ import (
 	"fmt"
 	"hash"
 	"io"
-	"io/fs"
 	"os"
 	"path/filepath"
 	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	goset "github.com/deckarep/golang-set/v2"
+	"go.artefactual.dev/tools/temporal"
 
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/inventory"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/manifest"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/progress"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/report"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/sip"
 )
 
 const VerifyManifestName = "verify-manifest"
 
+// DefaultChecksumConcurrency is the default number of files hashed
+// concurrently when verifying the SIP checksums.
+const DefaultChecksumConcurrency = 4
+
+// checksumHeartbeatInterval is the interval between the checkpoints saved
+// while the SIP files are hashed or identified.
+const checksumHeartbeatInterval = 10 * time.Second
+
 type (
-	VerifyManifest       struct{}
+	VerifyManifest struct {
+		concurrency int
+	}
 	VerifyManifestParams struct {
 		SIP sip.SIP
 	}
@@ -34,16 +49,27 @@ type (
 		ManifestFailures []string
 		MissingFiles     []string
 		UnexpectedFiles  []string
+		Entries          []report.Entry
 	}
 )
 
-func NewVerifyManifest() *VerifyManifest {
-	return &VerifyManifest{}
+// NewVerifyManifest returns a VerifyManifest activity hashing concurrency
+// files at a time, or DefaultChecksumConcurrency files if concurrency is not
+// positive.
+func NewVerifyManifest(concurrency int) *VerifyManifest {
+	if concurrency <= 0 {
+		concurrency = DefaultChecksumConcurrency
+	}
+
+	return &VerifyManifest{concurrency: concurrency}
 }
 
 // Execute parses a SIP's manifest and verifies it against the actual files in
 // the SIP directory. Any missing or unexpected files on disk are reported as
-// failures.
+// failures. The files are hashed with the manifest checksum algorithm and
+// FixityAlgorithm in a single read. When the SIP has been inventoried, the
+// checksums are written to the SIP checksums file for the activities that run
+// afterwards.
 func (a *VerifyManifest) Execute(ctx context.Context, params *VerifyManifestParams) (*VerifyManifestResult, error) {
 	m, err := getManifest(params.SIP)
 	if err != nil {
@@ -51,31 +77,70 @@ func (a *VerifyManifest) Execute(ctx context.Context, params *VerifyManifestPara
 	}
 	manifestSet := goset.NewSetFromMapKeys(m.Files)
 
-	sipFiles, err := sipFiles(params.SIP)
+	inv, err := inventory.Load(params.SIP.Path)
 	if err != nil {
 		return nil, fmt.Errorf("verify manifest: get SIP contents: %v", err)
 	}
+	sipFiles := sipFiles(params.SIP, inv)
+	_, err = os.Stat(inventory.Path(params.SIP.Path))
+	share := err == nil
 
-	badChecksums, err := verifyChecksums(m.Files, sipFiles, params.SIP.Path)
+	badChecksums, err := verifyChecksums(ctx, m.Files, sipFiles, params.SIP.Path, inv, a.concurrency, share)
 	if err != nil {
 		return nil, fmt.Errorf("verify checksums: %v", err)
 	}
 
+	res := &VerifyManifestResult{}
 	sipBase := filepath.Base(params.SIP.Path)
-	missing := missingFiles(sipBase, manifestSet, sipFiles)
-	unexpected := unexpectedFiles(sipBase, manifestSet, sipFiles)
+	for _, p := range missingFiles(manifestSet, sipFiles) {
+		msg := fmt.Sprintf("Missing file: %s", filepath.Join(sipBase, p))
+		res.MissingFiles = append(res.MissingFiles, msg)
+		res.Entries = append(res.Entries, manifestEntry(report.CodeManifestMissingFile, p, msg))
+	}
+	for _, p := range unexpectedFiles(manifestSet, sipFiles) {
+		msg := fmt.Sprintf("Unexpected file: %s", filepath.Join(sipBase, p))
+		res.UnexpectedFiles = append(res.UnexpectedFiles, msg)
+		res.Entries = append(res.Entries, manifestEntry(report.CodeManifestUnexpectedFile, p, msg))
+	}
 
-	var manifestFailures []string
 	if !slices.Contains(manifest.AllowedSchemaVersions, m.SchemaVersion) {
-		manifestFailures = []string{fmt.Sprintf("Unsupported schema version: %s", m.SchemaVersion)}
+		msg := fmt.Sprintf("Unsupported schema version: %s", m.SchemaVersion)
+		res.ManifestFailures = []string{msg}
+		res.Entries = append(
+			res.Entries,
+			manifestEntry(
+				report.CodeManifestUnsupportedSchemaVersion,
+				relPath(params.SIP.Path, params.SIP.ManifestPath),
+				msg,
+			),
+		)
+	}
+
+	for _, f := range badChecksums {
+		res.ChecksumFailures = append(res.ChecksumFailures, f.Message)
+		res.Entries = append(res.Entries, f)
+	}
+
+	return res, nil
+}
+
+func manifestEntry(code report.Code, path, msg string) report.Entry {
+	return report.Entry{
+		Check:    VerifyManifestName,
+		Severity: report.SeverityError,
+		Path:     path,
+		Code:     code,
+		Message:  msg,
 	}
+}
 
-	return &VerifyManifestResult{
-		ChecksumFailures: badChecksums,
-		ManifestFailures: manifestFailures,
-		MissingFiles:     missing,
-		UnexpectedFiles:  unexpected,
-	}, nil
+// relPath returns path relative to root, or path if it can't be made
+// relative.
+func relPath(root, path string) string {
+	if r, err := filepath.Rel(root, path); err == nil {
+		return r
+	}
+	return path
 }
 
 // getManifest parses the SIP manifest and returns a Manifest.
@@ -84,8 +149,15 @@ func getManifest(s sip.SIP) (*manifest.Manifest, error) {
 	if err != nil {
 		return nil, fmt.Errorf("open: %v", err)
 	}
+	defer f.Close()
+
+	return parseManifest(s, f)
+}
 
-	m, err := manifest.Parse(f)
+// parseManifest parses the manifest of the s SIP from r. The returned manifest
+// file paths are relative to the SIP root directory.
+func parseManifest(s sip.SIP, r io.Reader) (*manifest.Manifest, error) {
+	m, err := manifest.Parse(r)
 	if err != nil {
 		return nil, err
 	}
@@ -102,144 +174,361 @@ func getManifest(s sip.SIP) (*manifest.Manifest, error) {
 	return m, nil
 }
 
-// sipFiles recursively walks dir's tree and returns the set of all file
-// (excluding directory) paths found.
-func sipFiles(s sip.SIP) (goset.Set[string], error) {
-	root := s.Path
+// sipFiles returns the set of all file (excluding directory) paths in the SIP
+// inventory.
+func sipFiles(s sip.SIP, inv *inventory.Inventory) goset.Set[string] {
+	root := "."
 	if s.IsAIP() {
-		root = filepath.Join(s.Path, "content")
+		root = "content"
 	}
 
 	paths := goset.NewSet[string]()
-	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-
-		if d.IsDir() {
-			return nil
-		}
-
-		p, err := filepath.Rel(s.Path, path)
-		if err != nil {
-			return err
-		}
+	for _, p := range inv.Files(root) {
+		p = filepath.Join(root, p)
 
 		// SIPs don't include metadata.xml in the manifest, so ignore the file
 		// here.
 		if s.IsSIP() && p == "header/metadata.xml" {
-			return nil
+			continue
 		}
 
 		paths.Add(p)
-
-		return nil
-	})
-	if err != nil {
-		return nil, err
 	}
 
-	return paths, nil
+	return paths
 }
 
-// missingFiles returns the list of all files that are in manifest but not
-// filesys.
-func missingFiles(base string, manifest, filesys goset.Set[string]) []string {
-	var missing []string
-	if s := manifest.Difference(filesys).ToSlice(); len(s) > 0 {
-		slices.Sort(s)
-		for _, p := range s {
-			fp := filepath.Join(base, p)
-			missing = append(missing, fmt.Sprintf("Missing file: %s", fp))
-		}
-	}
-	return missing
+// missingFiles returns the sorted list of all files that are in manifest but
+// not filesys.
+func missingFiles(manifest, filesys goset.Set[string]) []string {
+	s := manifest.Difference(filesys).ToSlice()
+	slices.Sort(s)
+	return s
 }
 
-// unexpectedFiles returns the list of all files that are in filesys but not
-// manifest.
-func unexpectedFiles(base string, manifest, filesys goset.Set[string]) []string {
-	var unexpected []string
-	if s := filesys.Difference(manifest).ToSlice(); len(s) > 0 {
-		slices.Sort(s)
-		for _, p := range s {
-			fp := filepath.Join(base, p)
-			unexpected = append(unexpected, fmt.Sprintf("Unexpected file: %s", fp))
-		}
-	}
-	return unexpected
+// unexpectedFiles returns the sorted list of all files that are in filesys but
+// not manifest.
+func unexpectedFiles(manifest, filesys goset.Set[string]) []string {
+	s := filesys.Difference(manifest).ToSlice()
+	slices.Sort(s)
+	return s
+}
+
+// checksumCheckpoint is the checksum verification state recorded in the
+// activity heartbeat details, so a retried attempt can skip the files already
+// verified.
+type checksumCheckpoint struct {
+	// Verified is the number of files verified, in path order.
+	Verified int `json:"verified"`
+
+	// Failures are the checksum failures found in the verified files.
+	Failures []report.Entry `json:"failures,omitempty"`
 }
 
 // verifyChecksums checks that each manifestFiles file checksum matches the
 // checksum generated from the actual file contents. If a file is on the
 // manifest but missing from the filesystem, or vice versa, it will be skipped
-// with no validation message.  The root is the absolute path to the root
+// with no validation message. The root is the absolute path to the root
 // directory of the SIP, and is prefixed to each relative file path in the
 // manifest to create an absolute path the file.
+//
+// The checksums recorded in the SIP inventory are used when available, other
+// files are hashed by up to concurrency workers. The files verified so far, in
+// path order, and their failures are recorded in the activity heartbeat
+// details so a retried attempt can resume from the last checkpoint. If share
+// is true, the checksums are saved to the SIP checksums file periodically, and
+// the checkpoint only covers the files whose checksums have been saved.
 func verifyChecksums(
+	ctx context.Context,
 	manifestFiles map[string]*manifest.File,
 	sipFiles goset.Set[string],
 	root string,
-) ([]string, error) {
-	var failures []string
+	inv *inventory.Inventory,
+	concurrency int,
+	share bool,
+) ([]report.Entry, error) {
+	// Only the manifest files found on the filesystem are hashed.
+	var paths []string
+	for path := range manifestFiles {
+		if sipFiles.Contains(path) {
+			paths = append(paths, path)
+		}
+	}
+	slices.Sort(paths)
+
+	var cp checksumCheckpoint
+	if c, ok := progress.LastDetails(ctx, progress.FilesHashed, &cp); !ok || c.Total != int64(len(paths)) {
+		cp = checksumCheckpoint{}
+	}
+
+	sums := inventory.Checksums{}
+	if share {
+		var err error
+		if sums, err = inventory.ReadChecksums(root); err != nil {
+			return nil, err
+		}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	var (
+		mu       sync.Mutex
+		results  = make([]*report.Entry, len(paths))
+		done     = make([]bool, len(paths))
+		saved    = cp
+		reporter = progress.NewReporter(ctx, progress.FilesHashed)
+		firstErr error
+	)
+
+	// heartbeat records the saved checkpoint, it must be called with mu
+	// locked. Failures are only appended to cp, so saved isn't modified.
+	heartbeat := func() {
+		reporter.ReportDetails(
+			progress.Counter{Done: int64(cp.Verified), Total: int64(len(paths))},
+			saved,
+		)
+	}
+
+	// save writes the checksums calculated so far and moves the recorded
+	// checkpoint to the current one when they are shared, and heartbeats. It
+	// must be called with mu locked.
+	save := func() error {
+		if share {
+			if err := inventory.WriteChecksums(root, sums); err != nil {
+				return err
+			}
+			saved = cp
+		}
+		heartbeat()
+
+		return nil
+	}
+
+	mu.Lock()
+	heartbeat()
+	mu.Unlock()
+
+	// Save periodically, as hashing a large file may take longer than the
+	// heartbeat timeout.
+	stop := every(ctx, checksumHeartbeatInterval, func() {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if err := save(); err != nil {
+			temporal.GetLogger(ctx).Info("Unable to save the checksums checkpoint.", "error", err)
+		}
+	})
+	defer stop()
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range max(concurrency, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				entry, fileSums, err := verifyChecksum(ctx, root, paths[i], manifestFiles[paths[i]], inv)
+
+				mu.Lock()
+				if err != nil {
+					if firstErr == nil {
+						firstErr = err
+					}
+					cancel()
+					mu.Unlock()
+					continue
+				}
+				if share {
+					sums[paths[i]] = fileSums
+				}
+
+				// Advance the checkpoint over the contiguous verified files.
+				results[i], done[i] = entry, true
+				for cp.Verified < len(paths) && done[cp.Verified] {
+					if results[cp.Verified] != nil {
+						cp.Failures = append(cp.Failures, *results[cp.Verified])
+					}
+					cp.Verified++
+				}
+				if !share {
+					saved = cp
+				}
+				heartbeat()
+				mu.Unlock()
+			}
+		}()
+	}
 
-	for path, file := range manifestFiles {
-		// Check if file exists on filesystem.
-		if !sipFiles.Contains(path) {
+	for i := cp.Verified; i < len(paths); i++ {
+		select {
+		case jobs <- i:
 			continue
+		case <-ctx.Done():
 		}
+		break
+	}
+	close(jobs)
+	wg.Wait()
+	stop()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if err := save(); err != nil {
+		return nil, err
+	}
+
+	return cp.Failures, nil
+}
 
-		// Attempt to generate hash from filesystem file contents.
-		hashResult, err := generateHash(filepath.Join(root, path), file.Checksum.Algorithm)
+// verifyChecksum returns a failure if the checksum of the file at path doesn't
+// match the file manifest checksum, or nil if it matches, and the file
+// checksums by algorithm. The file is only hashed if its checksums are not in
+// the inventory.
+func verifyChecksum(
+	ctx context.Context,
+	root, path string,
+	file *manifest.File,
+	inv *inventory.Inventory,
+) (*report.Entry, map[string]string, error) {
+	alg := file.Checksum.Algorithm
+	sums := make(map[string]string, 2)
+	for _, a := range []string{alg, FixityAlgorithm} {
+		if sum, ok := inv.Checksum(path, a); ok {
+			sums[a] = sum
+		}
+	}
+	if sums[alg] == "" || sums[FixityAlgorithm] == "" {
+		var err error
+		sums, err = generateHashes(ctx, filepath.Join(root, path), alg, FixityAlgorithm)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
+	}
 
-		// Compare hash to expected value.
-		if hashResult != strings.ToLower(file.Checksum.Hash) {
-			failures = append(
-				failures,
-				fmt.Sprintf(
-					"Checksum mismatch for %q (expected: %q, got: %q)",
-					path,
-					file.Checksum.Hash,
-					hashResult,
-				),
-			)
-		}
+	hashResult := sums[alg]
+	if hashResult == strings.ToLower(file.Checksum.Hash) {
+		return nil, sums, nil
 	}
-	slices.Sort(failures)
 
-	return failures, nil
+	entry := manifestEntry(
+		report.CodeManifestChecksumMismatch,
+		path,
+		fmt.Sprintf(
+			"Checksum mismatch for %q (expected: %q, got: %q)",
+			path,
+			file.Checksum.Hash,
+			hashResult,
+		),
+	)
+
+	return &entry, sums, nil
+}
+
+// every calls f every interval until ctx is done or the returned stop
+// function is called. Calling stop waits for a running f call to return, and
+// can be called more than once.
+func every(ctx context.Context, interval time.Duration, f func()) (stop func()) {
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-done:
+				return
+			case <-ticker.C:
+				f()
+			}
+		}
+	}()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			ticker.Stop()
+			close(done)
+			<-stopped
+		})
+	}
 }
 
 // Return a hexadecimal encoded hash string generated from the contents
 // of the file at path.
-func generateHash(path, alg string) (string, error) {
-	var h hash.Hash
-
-	switch alg {
-	case "MD5":
-		h = md5.New() // #nosec: G401 -- not used for security.
-	case "SHA-1":
-		h = sha1.New() // #nosec: G401 -- not used for security.
-	case "SHA-256":
-		h = sha256.New() // #nosec: G401 -- not used for security.
-	case "SHA-512":
-		h = sha512.New() // #nosec: G401 -- not used for security.
-	default:
-		return "", fmt.Errorf("hash algorithm %q is not supported", alg)
+func generateHash(ctx context.Context, path, alg string) (string, error) {
+	sums, err := generateHashes(ctx, path, alg)
+	if err != nil {
+		return "", err
+	}
+
+	return sums[alg], nil
+}
+
+// generateHashes returns the hexadecimal encoded hashes of the contents of the
+// file at path keyed by algorithm, reading the file only once.
+func generateHashes(ctx context.Context, path string, algs ...string) (map[string]string, error) {
+	hashes := make(map[string]hash.Hash, len(algs))
+	writers := make([]io.Writer, 0, len(algs))
+	for _, alg := range algs {
+		if _, ok := hashes[alg]; ok {
+			continue
+		}
+
+		var h hash.Hash
+		switch alg {
+		case "MD5":
+			h = md5.New() // #nosec: G401 -- not used for security.
+		case "SHA-1":
+			h = sha1.New() // #nosec: G401 -- not used for security.
+		case "SHA-256":
+			h = sha256.New() // #nosec: G401 -- not used for security.
+		case "SHA-512":
+			h = sha512.New() // #nosec: G401 -- not used for security.
+		default:
+			return nil, fmt.Errorf("hash algorithm %q is not supported", alg)
+		}
+		hashes[alg] = h
+		writers = append(writers, h)
 	}
 
 	f, err := os.Open(path) // #nosec: G304 -- trusted path.
 	if err != nil {
-		return "", fmt.Errorf("open file: %v", err)
+		return nil, fmt.Errorf("open file: %v", err)
 	}
 	defer f.Close()
 
-	if _, err := io.Copy(h, f); err != nil {
-		return "", fmt.Errorf("copy contents: %v", err)
+	if _, err := io.Copy(io.MultiWriter(writers...), ctxReader{ctx: ctx, r: f}); err != nil {
+		return nil, fmt.Errorf("copy contents: %v", err)
+	}
+
+	sums := make(map[string]string, len(hashes))
+	for alg, h := range hashes {
+		sums[alg] = hex.EncodeToString(h.Sum(nil))
 	}
 
-	return hex.EncodeToString(h.Sum(nil)), nil
+	return sums, nil
+}
+
+// ctxReader stops reading from r when ctx is done, so hashing a large file
+// can be canceled.
+type ctxReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (r ctxReader) Read(p []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return r.r.Read(p)
 }
@@ -1,16 +1,20 @@
 package activities_test
 
 import (
+	"context"
 	"fmt"
 	"path/filepath"
 	"testing"
 
 	temporalsdk_activity "go.temporal.io/sdk/activity"
 	temporalsdk_testsuite "go.temporal.io/sdk/testsuite"
+	temporalsdk_worker "go.temporal.io/sdk/worker"
 	"gotest.tools/v3/assert"
 	"gotest.tools/v3/fs"
 
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/activities"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/progress"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/report"
 )
 
 const (
@@ -338,6 +342,16 @@ const (
 `
 )
 
+func manifestEntry(code report.Code, path, msg string) report.Entry {
+	return report.Entry{
+		Check:    activities.VerifyManifestName,
+		Severity: report.SeverityError,
+		Path:     path,
+		Code:     code,
+		Message:  msg,
+	}
+}
+
 func TestVerifyManifest(t *testing.T) {
 	t.Parallel()
 
@@ -511,6 +525,18 @@ func TestVerifyManifest(t *testing.T) {
 						filepath.Base(missingFilesSIP.Path()),
 					),
 				},
+				Entries: []report.Entry{
+					manifestEntry(
+						report.CodeManifestMissingFile,
+						"content/content/d_0000001/00000001.jp2",
+						fmt.Sprintf("Missing file: %s/content/content/d_0000001/00000001.jp2", filepath.Base(missingFilesSIP.Path())),
+					),
+					manifestEntry(
+						report.CodeManifestMissingFile,
+						"content/header/xsd/arelda.xsd",
+						fmt.Sprintf("Missing file: %s/content/header/xsd/arelda.xsd", filepath.Base(missingFilesSIP.Path())),
+					),
+				},
 			},
 		},
 		{
@@ -532,6 +558,18 @@ func TestVerifyManifest(t *testing.T) {
 						filepath.Base(extraFilesSIP.Path()),
 					),
 				},
+				Entries: []report.Entry{
+					manifestEntry(
+						report.CodeManifestUnexpectedFile,
+						"content/content/d_0000001/extra_file.txt",
+						fmt.Sprintf("Unexpected file: %s/content/content/d_0000001/extra_file.txt", filepath.Base(extraFilesSIP.Path())),
+					),
+					manifestEntry(
+						report.CodeManifestUnexpectedFile,
+						"content/header/xsd/extra.xsd",
+						fmt.Sprintf("Unexpected file: %s/content/header/xsd/extra.xsd", filepath.Base(extraFilesSIP.Path())),
+					),
+				},
 			},
 		},
 		{
@@ -572,6 +610,18 @@ func TestVerifyManifest(t *testing.T) {
 					`Checksum mismatch for "content/content/d_0000001/00000001.jp2" (expected: "827ccb0eea8a706c4c34a16891f84e7b", got: "2714364e3a0ac68e8bf9b898b31ff303")`,
 					`Checksum mismatch for "content/header/old/SIP/metadata.xml" (expected: "2c5afa141670292c96c3c111c47b83b5", got: "dff24b6a34ff7ab645cb477e090bee5f")`,
 				},
+				Entries: []report.Entry{
+					manifestEntry(
+						report.CodeManifestChecksumMismatch,
+						"content/content/d_0000001/00000001.jp2",
+						`Checksum mismatch for "content/content/d_0000001/00000001.jp2" (expected: "827ccb0eea8a706c4c34a16891f84e7b", got: "2714364e3a0ac68e8bf9b898b31ff303")`,
+					),
+					manifestEntry(
+						report.CodeManifestChecksumMismatch,
+						"content/header/old/SIP/metadata.xml",
+						`Checksum mismatch for "content/header/old/SIP/metadata.xml" (expected: "2c5afa141670292c96c3c111c47b83b5", got: "dff24b6a34ff7ab645cb477e090bee5f")`,
+					),
+				},
 			},
 		},
 		{
@@ -589,6 +639,13 @@ func TestVerifyManifest(t *testing.T) {
 			},
 			want: activities.VerifyManifestResult{
 				ManifestFailures: []string{"Unsupported schema version: 5.1"},
+				Entries: []report.Entry{
+					manifestEntry(
+						report.CodeManifestUnsupportedSchemaVersion,
+						"additional/UpdatedAreldaMetadata.xml",
+						"Unsupported schema version: 5.1",
+					),
+				},
 			},
 		},
 	}
@@ -599,7 +656,7 @@ func TestVerifyManifest(t *testing.T) {
 			ts := &temporalsdk_testsuite.WorkflowTestSuite{}
 			env := ts.NewTestActivityEnvironment()
 			env.RegisterActivityWithOptions(
-				activities.NewVerifyManifest().Execute,
+				activities.NewVerifyManifest(0).Execute,
 				temporalsdk_activity.RegisterOptions{Name: activities.VerifyManifestName},
 			)
 
@@ -621,3 +678,154 @@ func TestVerifyManifest(t *testing.T) {
 		})
 	}
 }
+
+func TestVerifyManifestChecksums(t *testing.T) {
+	t.Parallel()
+
+	type checkpoint struct {
+		Verified int            `json:"verified"`
+		Failures []report.Entry `json:"failures,omitempty"`
+	}
+
+	mismatchedSIP := func(t *testing.T) activities.VerifyManifestParams {
+		return activities.VerifyManifestParams{
+			SIP: testSIP(
+				t,
+				fs.NewDir(t, "Test_Checksums",
+					fs.WithDir("additional",
+						fs.WithFile("UpdatedAreldaMetadata.xml", digitizedAIPUpdatedArelda),
+					),
+					fs.WithDir("content",
+						fs.WithDir("content",
+							fs.WithDir("d_0000001",
+								fs.WithFile("00000001.jp2", "wrong checksum"),
+								fs.WithFile("00000001_PREMIS.xml", "abcdef"),
+								fs.WithFile("00000002.jp2", "67890"),
+								fs.WithFile("00000002_PREMIS.xml", "ghijk"),
+								fs.WithFile("Prozess_Digitalisierung_PREMIS.xml", "lmnop"),
+							),
+						),
+						fs.WithDir("header",
+							fs.WithDir("old",
+								fs.WithDir("SIP",
+									fs.WithFile("metadata.xml", "also wrong checksum"),
+								),
+							),
+							fs.WithDir("xsd",
+								fs.WithFile("arelda.xsd", "vwxyz"),
+							),
+						),
+					),
+				).Path(),
+			),
+		}
+	}
+
+	jp2Failure := manifestEntry(
+		report.CodeManifestChecksumMismatch,
+		"content/content/d_0000001/00000001.jp2",
+		`Checksum mismatch for "content/content/d_0000001/00000001.jp2" (expected: "827ccb0eea8a706c4c34a16891f84e7b", got: "2714364e3a0ac68e8bf9b898b31ff303")`,
+	)
+	metadataFailure := manifestEntry(
+		report.CodeManifestChecksumMismatch,
+		"content/header/old/SIP/metadata.xml",
+		`Checksum mismatch for "content/header/old/SIP/metadata.xml" (expected: "2c5afa141670292c96c3c111c47b83b5", got: "dff24b6a34ff7ab645cb477e090bee5f")`,
+	)
+
+	tests := []struct {
+		name        string
+		concurrency int
+		heartbeat   *progress.Heartbeat
+		canceled    bool
+		want        []report.Entry
+		wantErr     string
+	}{
+		{
+			name:        "Verifies checksums with a single worker",
+			concurrency: 1,
+			want:        []report.Entry{jp2Failure, metadataFailure},
+		},
+		{
+			name:        "Verifies checksums with more workers than files",
+			concurrency: 10,
+			want:        []report.Entry{jp2Failure, metadataFailure},
+		},
+		{
+			name:        "Resumes from the last heartbeat checkpoint",
+			concurrency: 2,
+			heartbeat: &progress.Heartbeat{
+				Update: progress.Update{
+					Name:    progress.FilesHashed,
+					Counter: progress.Counter{Done: 5, Total: 7},
+				},
+				// The recorded failure is kept, and the checksums of the
+				// first five files are not verified again.
+				Details: checkpoint{
+					Verified: 5,
+					Failures: []report.Entry{jp2Failure},
+				},
+			},
+			want: []report.Entry{jp2Failure, metadataFailure},
+		},
+		{
+			name:        "Skips the checksums verified by a previous attempt",
+			concurrency: 2,
+			heartbeat: &progress.Heartbeat{
+				Update: progress.Update{
+					Name:    progress.FilesHashed,
+					Counter: progress.Counter{Done: 5, Total: 7},
+				},
+				Details: checkpoint{Verified: 5},
+			},
+			want: []report.Entry{metadataFailure},
+		},
+		{
+			name:        "Ignores a checkpoint for a different file list",
+			concurrency: 2,
+			heartbeat: &progress.Heartbeat{
+				Update: progress.Update{
+					Name:    progress.FilesHashed,
+					Counter: progress.Counter{Done: 5, Total: 8},
+				},
+				Details: checkpoint{Verified: 5},
+			},
+			want: []report.Entry{jp2Failure, metadataFailure},
+		},
+		{
+			name:     "Returns an error when canceled",
+			canceled: true,
+			wantErr:  "context canceled",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ts := &temporalsdk_testsuite.WorkflowTestSuite{}
+			env := ts.NewTestActivityEnvironment()
+			env.RegisterActivityWithOptions(
+				activities.NewVerifyManifest(tt.concurrency).Execute,
+				temporalsdk_activity.RegisterOptions{Name: activities.VerifyManifestName},
+			)
+			if tt.heartbeat != nil {
+				env.SetHeartbeatDetails(tt.heartbeat)
+			}
+			if tt.canceled {
+				ctx, cancel := context.WithCancel(context.Background())
+				cancel()
+				env.SetWorkerOptions(temporalsdk_worker.Options{BackgroundActivityContext: ctx})
+			}
+
+			future, err := env.ExecuteActivity(activities.VerifyManifestName, mismatchedSIP(t))
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NilError(t, err)
+
+			var res activities.VerifyManifestResult
+			future.Get(&res)
+			assert.DeepEqual(t, res.Entries, tt.want)
+		})
+	}
+}
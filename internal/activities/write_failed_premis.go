@@ -0,0 +1,298 @@
+package activities
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/fformat"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/inventory"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/premis"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/report"
+)
+
+const WriteFailedPREMISName = "write-failed-premis"
+
+type (
+	WriteFailedPREMIS struct {
+		// Random number generator for generating UUIDs. Can be set to a
+		// deterministic generator for testing purposes.
+		rng io.Reader
+	}
+	WriteFailedPREMISParams struct {
+		// Path of the failed SIP, it can be the original archive or the
+		// extracted SIP directory. No objects are recorded if it's empty or
+		// the SIP doesn't exist anymore.
+		Path string
+
+		// DestPath is the directory where the premis.xml file is written.
+		DestPath string
+
+		// Report of the failed preprocessing run, its tasks are recorded as
+		// PREMIS events.
+		Report report.Report
+	}
+	WriteFailedPREMISResult struct {
+		// Path of the premis.xml file.
+		Path string
+	}
+)
+
+func NewWriteFailedPREMIS(rng io.Reader) *WriteFailedPREMIS {
+	return &WriteFailedPREMIS{rng: rng}
+}
+
+// failedObject is a file of a failed SIP recorded as a PREMIS object.
+type failedObject struct {
+	// path of the file relative to the SIP root directory, "." if the SIP is
+	// an archive.
+	path   string
+	name   string
+	size   int64
+	format *fformat.FileFormat
+}
+
+// Execute writes a premis.xml file to DestPath recording the tasks of a failed
+// preprocessing run. Each file of the SIP is added as a PREMIS object, linked
+// to an event for each task concerning the file, with the task outcome for
+// that file and the validation failures found in the file as outcome details.
+// The tasks that don't concern any file, e.g. the SIP name validation, are
+// recorded as a single event that isn't linked to any object.
+func (a *WriteFailedPREMIS) Execute(
+	ctx context.Context,
+	params *WriteFailedPREMISParams,
+) (*WriteFailedPREMISResult, error) {
+	objects, err := failedObjects(params.Path)
+	if err != nil {
+		return nil, fmt.Errorf("WriteFailedPREMIS: %v", err)
+	}
+
+	doc, err := premis.NewDoc()
+	if err != nil {
+		return nil, fmt.Errorf("WriteFailedPREMIS: %v", err)
+	}
+
+	for _, o := range objects {
+		id, err := uuid.NewRandomFromReader(a.rng)
+		if err != nil {
+			return nil, fmt.Errorf("WriteFailedPREMIS: generate UUID: %v", err)
+		}
+
+		object := premis.Object{
+			IdType:       "UUID",
+			IdValue:      id.String(),
+			OriginalName: o.name,
+			Size:         &o.size,
+			Format:       objectFormat(o.format),
+		}
+		if err := premis.AppendObjectXML(doc, object); err != nil {
+			return nil, fmt.Errorf("WriteFailedPREMIS: %v", err)
+		}
+	}
+
+	// Add the events once all the objects exist, as PREMIS requires the
+	// objects to be listed before the events.
+	PREMISEl := doc.FindElement("/premis:premis")
+	objectEls := PREMISEl.FindElements("./premis:object")
+	agent := premis.AgentDefault()
+	for _, t := range params.Report.Tasks {
+		var linked bool
+		for i, o := range objects {
+			if !o.concernedBy(t, params.Report.Entries) {
+				continue
+			}
+			linked = true
+
+			event, err := a.taskEvent(t, params.Report.Entries, o, agent)
+			if err != nil {
+				return nil, fmt.Errorf("WriteFailedPREMIS: %v", err)
+			}
+			premis.AddEventElement(PREMISEl, event)
+			premis.LinkEventToObject(objectEls[i], event)
+		}
+
+		if !linked {
+			event, err := a.taskEvent(t, params.Report.Entries, failedObject{path: "."}, agent)
+			if err != nil {
+				return nil, fmt.Errorf("WriteFailedPREMIS: %v", err)
+			}
+			premis.AddEventElement(PREMISEl, event)
+		}
+	}
+
+	if err := premis.AppendAgentXML(doc, agent); err != nil {
+		return nil, fmt.Errorf("WriteFailedPREMIS: %v", err)
+	}
+
+	if err := os.MkdirAll(params.DestPath, 0o750); err != nil {
+		return nil, fmt.Errorf("WriteFailedPREMIS: %v", err)
+	}
+
+	path := filepath.Join(params.DestPath, "premis.xml")
+	doc.Indent(2)
+	if err := doc.WriteToFile(path); err != nil {
+		return nil, fmt.Errorf("WriteFailedPREMIS: %v", err)
+	}
+
+	return &WriteFailedPREMISResult{Path: path}, nil
+}
+
+// taskEvent returns the event recording the t task outcome for the o object.
+func (a *WriteFailedPREMIS) taskEvent(
+	t report.Task,
+	entries []report.Entry,
+	o failedObject,
+	agent premis.Agent,
+) (premis.Event, error) {
+	id, err := uuid.NewRandomFromReader(a.rng)
+	if err != nil {
+		return premis.Event{}, fmt.Errorf("generate UUID: %v", err)
+	}
+
+	return premis.Event{
+		Summary:      taskEventSummary(t, entries, o),
+		IdType:       "UUID",
+		IdValue:      id.String(),
+		DateTime:     t.CompletedAt.Format(time.RFC3339),
+		AgentIdType:  agent.IdType,
+		AgentIdValue: agent.IdValue,
+	}, nil
+}
+
+// failedObjects returns the files of the SIP at path. A SIP directory is
+// listed from its inventory, with the file formats if they were identified,
+// and an archive is a single object. There are no objects if the SIP doesn't
+// exist.
+func failedObjects(path string) ([]failedObject, error) {
+	if path == "" {
+		return nil, nil
+	}
+
+	fi, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if !fi.IsDir() {
+		return []failedObject{{path: ".", name: fi.Name(), size: fi.Size()}}, nil
+	}
+
+	inv, err := inventory.Load(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var objects []failedObject
+	for _, p := range inv.Files(".") {
+		e, _ := inv.Entry(p)
+		objects = append(objects, failedObject{path: p, name: p, size: e.Size, format: e.Format})
+	}
+
+	return objects, nil
+}
+
+// concernedBy returns true if the t task concerns the object: every task
+// concerns a SIP archive, the tasks checking every SIP file concern all the
+// files, the file format tasks concern the identified content files, and any
+// task concerns the files where it found a failure.
+func (o failedObject) concernedBy(t report.Task, entries []report.Entry) bool {
+	if o.path == "." {
+		return true
+	}
+
+	switch t.Name {
+	case "Extract SIP", "Unbag SIP", "Validate SIP structure", "Verify SIP manifest", "Verify SIP checksums":
+		return true
+	case "Identify SIP file formats", "Verify digitization PREMIS", "Check for disallowed file formats",
+		"Validate SIP file formats":
+		if o.format != nil {
+			return true
+		}
+	}
+
+	for _, e := range entries {
+		if e.Task == t.Name && e.Path != "" && o.affectedBy(e) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// affectedBy returns true if the e report entry applies to the object: the
+// entry is about the object file, one of its parent directories or the whole
+// SIP.
+func (o failedObject) affectedBy(e report.Entry) bool {
+	if o.path == "." || e.Path == "" || e.Path == o.path {
+		return true
+	}
+
+	return strings.HasPrefix(o.path, e.Path+string(filepath.Separator))
+}
+
+// taskEventSummary returns the summary of the event recording the t task
+// outcome for the o object. The failures found by the task in the object
+// are added as outcome details.
+func taskEventSummary(t report.Task, entries []report.Entry, o failedObject) premis.EventSummary {
+	// Only the first line of the task message is used, the failure lines
+	// that follow are added for each object.
+	message, _, _ := strings.Cut(t.Message, "\n")
+	summary := premis.EventSummary{
+		Type:          taskEventType(t.Name),
+		Detail:        fmt.Sprintf("name=%q", t.Name),
+		OutcomeDetail: message,
+	}
+
+	var found bool
+	for _, e := range entries {
+		if e.Task != t.Name {
+			continue
+		}
+		found = true
+		if o.affectedBy(e) {
+			summary.Failures = append(summary.Failures, e.Message)
+		}
+	}
+
+	switch {
+	case t.Outcome == "success":
+		summary.Outcome = "valid"
+	case len(summary.Failures) > 0:
+		summary.Outcome = "invalid"
+	case found:
+		// The task only found failures in other files.
+		summary.Outcome = "valid"
+		summary.OutcomeDetail = "No failures found in this file"
+	case t.Outcome == "system failure":
+		summary.Outcome = "error"
+	default:
+		summary.Outcome = "invalid"
+	}
+
+	return summary
+}
+
+// taskEventType returns the PREMIS event type of a preprocessing task.
+func taskEventType(name string) string {
+	switch name {
+	case "Calculate SIP checksum":
+		return "message digest calculation"
+	case "Extract SIP", "Unbag SIP":
+		return "unpacking"
+	case "Verify SIP checksums":
+		return "fixity check"
+	default:
+		return "validation"
+	}
+}
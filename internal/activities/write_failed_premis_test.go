@@ -0,0 +1,243 @@
+package activities_test
+
+import (
+	"fmt"
+	pseudorand "math/rand"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beevik/etree"
+	temporalsdk_activity "go.temporal.io/sdk/activity"
+	temporalsdk_testsuite "go.temporal.io/sdk/testsuite"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/activities"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/fformat"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/inventory"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/premis"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/report"
+)
+
+// objectEvents returns the events linked to each PREMIS object, keyed by
+// object original name, as "<detail>: <outcome> [<outcome detail notes>]"
+// strings.
+func objectEvents(t *testing.T, doc *etree.Document) map[string][]string {
+	t.Helper()
+
+	events := make(map[string]*etree.Element)
+	for _, el := range doc.FindElements("/premis:premis/premis:event") {
+		events[el.FindElement("./premis:eventIdentifier/premis:eventIdentifierValue").Text()] = el
+	}
+
+	got := make(map[string][]string)
+	for _, obj := range doc.FindElements("/premis:premis/premis:object") {
+		name := obj.FindElement("./premis:originalName").Text()
+		for _, link := range obj.FindElements("./premis:linkingEventIdentifier/premis:linkingEventIdentifierValue") {
+			ev := events[link.Text()]
+			assert.Assert(t, ev != nil)
+
+			var notes []string
+			for _, n := range ev.FindElements(".//premis:eventOutcomeDetailNote") {
+				notes = append(notes, n.Text())
+			}
+			got[name] = append(got[name], fmt.Sprintf(
+				"%s: %s [%s]",
+				ev.FindElement("./premis:eventDetailInformation/premis:eventDetail").Text(),
+				ev.FindElement("./premis:eventOutcomeInformation/premis:eventOutcome").Text(),
+				strings.Join(notes, "; "),
+			))
+		}
+	}
+
+	return got
+}
+
+func TestWriteFailedPREMIS(t *testing.T) {
+	t.Parallel()
+
+	completed := time.Date(2024, 6, 1, 10, 0, 1, 0, time.UTC)
+	rep := report.Report{
+		SIPID:   "52fdfc07-2182-454f-963f-5f0f9a621d72",
+		SIPName: "SIP_20201201_Vecteur.zip",
+		Outcome: "content error",
+		Tasks: []report.Task{
+			{
+				Name:        "Validate SIP name",
+				Outcome:     "success",
+				Message:     "SIP name matches expected naming convention for the identified structure type",
+				CompletedAt: completed,
+			},
+			{
+				Name:        "Verify SIP checksums",
+				Outcome:     "validation failure",
+				Message:     "Content error: SIP checksums do not match file contents.\n\n- Checksum mismatch",
+				CompletedAt: completed,
+			},
+			{
+				Name:        "Validate SIP file formats",
+				Outcome:     "validation failure",
+				Message:     "Content error: file format validation has failed.\n\n- Invalid PDF/A file",
+				CompletedAt: completed,
+			},
+			{
+				Name:        "Validate SIP metadata",
+				Outcome:     "validation failure",
+				Message:     "Content error: metadata validation has failed.",
+				CompletedAt: completed,
+			},
+		},
+		Entries: []report.Entry{
+			{
+				Check:    activities.VerifyManifestName,
+				Task:     "Verify SIP checksums",
+				Severity: report.SeverityError,
+				Path:     "content/d_0000001/00000001.jp2",
+				Code:     report.CodeManifestChecksumMismatch,
+				Message:  `Checksum mismatch for "content/d_0000001/00000001.jp2"`,
+			},
+			{
+				Check:    activities.ValidateFilesName,
+				Task:     "Validate SIP file formats",
+				Severity: report.SeverityError,
+				Path:     "content/d_0000001/00000002.jp2",
+				Code:     report.CodeFileFormatInvalid,
+				Message:  "ISO 19005-1:2005 6.1.3-1: The file trailer dictionary shall contain the ID keyword",
+			},
+			{
+				Check:    "xmlvalidate",
+				Task:     "Validate SIP metadata",
+				Severity: report.SeverityError,
+				Path:     "header/metadata.xml",
+				Code:     report.CodeMetadataInvalid,
+				Message:  "Invalid metadata",
+			},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		src        *fs.Dir
+		path       string
+		identified bool
+		want       map[string][]string
+		wantEvents int
+		wantErr    string
+	}{
+		{
+			name: "Writes a premis.xml file for a SIP directory",
+			src: fs.NewDir(t, "",
+				fs.WithDir("SIP_20201201_Vecteur",
+					fs.WithDir("content",
+						fs.WithDir("d_0000001",
+							fs.WithFile("00000001.jp2", "12345"),
+							fs.WithFile("00000002.jp2", "67890"),
+						),
+					),
+					fs.WithDir("header",
+						fs.WithFile("metadata.xml", "<xml/>"),
+					),
+				),
+			),
+			path:       "SIP_20201201_Vecteur",
+			identified: true,
+			want: map[string][]string{
+				"content/d_0000001/00000001.jp2": {
+					`name="Verify SIP checksums": invalid [Content error: SIP checksums do not match file contents.; Checksum mismatch for "content/d_0000001/00000001.jp2"]`,
+					`name="Validate SIP file formats": valid [No failures found in this file]`,
+				},
+				"content/d_0000001/00000002.jp2": {
+					`name="Verify SIP checksums": valid [No failures found in this file]`,
+					`name="Validate SIP file formats": invalid [Content error: file format validation has failed.; ISO 19005-1:2005 6.1.3-1: The file trailer dictionary shall contain the ID keyword]`,
+				},
+				"header/metadata.xml": {
+					`name="Verify SIP checksums": valid [No failures found in this file]`,
+					`name="Validate SIP metadata": invalid [Content error: metadata validation has failed.; Invalid metadata]`,
+				},
+			},
+			wantEvents: 7,
+		},
+		{
+			name: "Writes a premis.xml file for a SIP archive",
+			src: fs.NewDir(t, "",
+				fs.WithFile("SIP_20201201_Vecteur.zip", "archive"),
+			),
+			path: "SIP_20201201_Vecteur.zip",
+			want: map[string][]string{
+				"SIP_20201201_Vecteur.zip": {
+					`name="Validate SIP name": valid [SIP name matches expected naming convention for the identified structure type]`,
+					`name="Verify SIP checksums": invalid [Content error: SIP checksums do not match file contents.; Checksum mismatch for "content/d_0000001/00000001.jp2"]`,
+					`name="Validate SIP file formats": invalid [Content error: file format validation has failed.; ISO 19005-1:2005 6.1.3-1: The file trailer dictionary shall contain the ID keyword]`,
+					`name="Validate SIP metadata": invalid [Content error: metadata validation has failed.; Invalid metadata]`,
+				},
+			},
+			wantEvents: 4,
+		},
+		{
+			name:       "Writes the events without objects when the SIP doesn't exist",
+			src:        fs.NewDir(t, ""),
+			path:       "missing",
+			want:       map[string][]string{},
+			wantEvents: 4,
+		},
+		{
+			name: "Errors when the SIP inventory is invalid",
+			src: fs.NewDir(t, "",
+				fs.WithDir("SIP_20201201_Vecteur"),
+				fs.WithFile(".SIP_20201201_Vecteur.inventory.json", "{"),
+			),
+			path:    "SIP_20201201_Vecteur",
+			wantErr: "WriteFailedPREMIS: ",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			if tt.identified {
+				inv, err := inventory.Scan(tt.src.Join(tt.path))
+				assert.NilError(t, err)
+				for i, e := range inv.Entries {
+					if filepath.Ext(e.Path) == ".jp2" {
+						inv.Entries[i].Format = &fformat.FileFormat{Namespace: "pronom", ID: "x-fmt/392"}
+					}
+				}
+				assert.NilError(t, inventory.Write(tt.src.Join(tt.path), inv))
+			}
+
+			ts := &temporalsdk_testsuite.WorkflowTestSuite{}
+			env := ts.NewTestActivityEnvironment()
+			env.RegisterActivityWithOptions(
+				activities.NewWriteFailedPREMIS(pseudorand.New(pseudorand.NewSource(1))).Execute, // #nosec G404
+				temporalsdk_activity.RegisterOptions{Name: activities.WriteFailedPREMISName},
+			)
+
+			dest := filepath.Join(t.TempDir(), "failed")
+			future, err := env.ExecuteActivity(
+				activities.WriteFailedPREMISName,
+				&activities.WriteFailedPREMISParams{
+					Path:     tt.src.Join(tt.path),
+					DestPath: dest,
+					Report:   rep,
+				},
+			)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NilError(t, err)
+
+			var res activities.WriteFailedPREMISResult
+			assert.NilError(t, future.Get(&res))
+			assert.Equal(t, res.Path, filepath.Join(dest, "premis.xml"))
+
+			doc, err := premis.ParseFile(res.Path)
+			assert.NilError(t, err)
+			assert.DeepEqual(t, objectEvents(t, doc), tt.want)
+			assert.Equal(t, len(doc.FindElements("/premis:premis/premis:event")), tt.wantEvents)
+			assert.Equal(t, len(doc.FindElements("/premis:premis/premis:agent")), 1)
+		})
+	}
+}
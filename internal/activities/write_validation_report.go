@@ -0,0 +1,40 @@
+package activities
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/pips"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/report"
+)
+
+const WriteValidationReportName = "write-validation-report"
+
+type (
+	WriteValidationReport       struct{}
+	WriteValidationReportParams struct {
+		PIP    pips.PIP
+		Report report.Report
+	}
+	WriteValidationReportResult struct {
+		Path string
+	}
+)
+
+func NewWriteValidationReport() *WriteValidationReport {
+	return &WriteValidationReport{}
+}
+
+// Execute writes the validation report as JSON to the PIP metadata directory.
+func (a *WriteValidationReport) Execute(
+	ctx context.Context,
+	params *WriteValidationReportParams,
+) (*WriteValidationReportResult, error) {
+	path, err := report.Write(filepath.Join(params.PIP.Path, "metadata"), params.Report)
+	if err != nil {
+		return nil, fmt.Errorf("WriteValidationReport: %v", err)
+	}
+
+	return &WriteValidationReportResult{Path: path}, nil
+}
@@ -0,0 +1,86 @@
+package activities_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	temporalsdk_activity "go.temporal.io/sdk/activity"
+	temporalsdk_testsuite "go.temporal.io/sdk/testsuite"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/activities"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/enums"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/pips"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/report"
+)
+
+const expectedPIPReport = `{
+    "sipId": "52fdfc07-2182-454f-963f-5f0f9a621d72",
+    "sipName": "SIP_20201201_Vecteur.zip",
+    "outcome": "success",
+    "tasks": [],
+    "entries": []
+}`
+
+func TestWriteValidationReport(t *testing.T) {
+	t.Parallel()
+
+	rep := report.New("52fdfc07-2182-454f-963f-5f0f9a621d72", "SIP_20201201_Vecteur.zip")
+	rep.Outcome = "success"
+
+	tests := []struct {
+		name    string
+		pip     pips.PIP
+		wantFS  fs.Manifest
+		wantErr string
+	}{
+		{
+			name: "Writes the validation report to the PIP metadata directory",
+			pip: pips.New(
+				fs.NewDir(t, "",
+					fs.WithDir("metadata"),
+				).Path(),
+				enums.SIPTypeBornDigitalSIP,
+			),
+			wantFS: fs.Expected(t,
+				fs.WithDir("metadata",
+					fs.WithMode(0o755),
+					fs.WithFile(report.Filename, expectedPIPReport, fs.WithMode(0o644)),
+				),
+			),
+		},
+		{
+			name:    "Errors when the PIP metadata directory doesn't exist",
+			pip:     pips.New(fs.NewDir(t, "").Path(), enums.SIPTypeBornDigitalSIP),
+			wantErr: "WriteValidationReport: write validation report:",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ts := &temporalsdk_testsuite.WorkflowTestSuite{}
+			env := ts.NewTestActivityEnvironment()
+			env.RegisterActivityWithOptions(
+				activities.NewWriteValidationReport().Execute,
+				temporalsdk_activity.RegisterOptions{Name: activities.WriteValidationReportName},
+			)
+
+			enc, err := env.ExecuteActivity(
+				activities.WriteValidationReportName,
+				&activities.WriteValidationReportParams{PIP: tt.pip, Report: *rep},
+			)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NilError(t, err)
+
+			var result activities.WriteValidationReportResult
+			_ = enc.Get(&result)
+			assert.Equal(t, result.Path, filepath.Join(tt.pip.Path, "metadata", report.Filename))
+			assert.Assert(t, fs.Equal(tt.pip.Path, tt.wantFS))
+		})
+	}
+}
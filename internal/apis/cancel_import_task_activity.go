@@ -0,0 +1,78 @@
+package apis
+
+import (
+	"context"
+	"fmt"
+
+	"go.artefactual.dev/tools/temporal"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/apis/gen"
+)
+
+const CancelImportTaskActivityName = "cancel-apis-import-task"
+
+type (
+	CancelImportTaskActivity struct {
+		client Client
+	}
+
+	CancelImportTaskParams struct {
+		TaskID      string
+		Reason      string
+		CancelledBy string
+	}
+
+	CancelImportTaskResult struct{}
+)
+
+func NewCancelImportTaskActivity(client Client) *CancelImportTaskActivity {
+	return &CancelImportTaskActivity{client: client}
+}
+
+// Execute cancels an open APIS import task so it doesn't have to be closed by
+// hand in APIS when ingest is stopped after the task was created.
+func (a *CancelImportTaskActivity) Execute(
+	ctx context.Context,
+	params *CancelImportTaskParams,
+) (*CancelImportTaskResult, error) {
+	req := &gen.CancelImportTaskRequest{}
+	if params.Reason != "" {
+		req.Reason = gen.NewOptNilString(params.Reason)
+	}
+	if params.CancelledBy != "" {
+		req.CancelledBy = gen.NewOptNilString(params.CancelledBy)
+	}
+
+	res, err := a.client.APIImporttasksIDCancelPost(
+		ctx,
+		req,
+		gen.APIImporttasksIDCancelPostParams{ID: params.TaskID},
+	)
+	if err != nil {
+		return nil, fmt.Errorf("cancel APIS import task: %v", err)
+	}
+
+	switch t := res.(type) {
+	case *gen.APIImporttasksIDCancelPostNoContent:
+		return &CancelImportTaskResult{}, nil
+	case *gen.APIImporttasksIDCancelPostUnauthorized:
+		return nil, temporal.NewNonRetryableError(fmt.Errorf("cancel APIS import task: unauthorized"))
+	case *gen.APIImporttasksIDCancelPostNotFound:
+		return nil, temporal.NewNonRetryableError(fmt.Errorf(
+			"cancel APIS import task: task not found: %s",
+			problemDetail(t.Detail),
+		))
+	case *gen.APIImporttasksIDCancelPostConflict:
+		return nil, temporal.NewNonRetryableError(fmt.Errorf(
+			"cancel APIS import task: conflict: %s",
+			problemDetail(t.Detail),
+		))
+	case *gen.APIImporttasksIDCancelPostInternalServerError:
+		return nil, fmt.Errorf(
+			"cancel APIS import task: server error: %s",
+			problemDetail(t.Detail),
+		)
+	default:
+		return nil, temporal.NewNonRetryableError(fmt.Errorf("cancel APIS import task: unexpected response"))
+	}
+}
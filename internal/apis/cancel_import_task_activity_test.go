@@ -0,0 +1,182 @@
+package apis_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	temporalsdk_activity "go.temporal.io/sdk/activity"
+	temporalsdk_testsuite "go.temporal.io/sdk/testsuite"
+	"go.uber.org/mock/gomock"
+	"gotest.tools/v3/assert"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/apis"
+	fake_apis "github.com/artefactual-sdps/preprocessing-sfa/internal/apis/fake"
+	apisgen "github.com/artefactual-sdps/preprocessing-sfa/internal/apis/gen"
+)
+
+func TestCancelImportTaskActivity(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		params  apis.CancelImportTaskParams
+		expect  func(*testing.T, *fake_apis.MockClientMockRecorder)
+		wantErr string
+	}{
+		{
+			name: "cancels import task",
+			params: apis.CancelImportTaskParams{
+				TaskID:      "task-000001",
+				Reason:      "Ingest canceled",
+				CancelledBy: "archivist@example.com",
+			},
+			expect: func(t *testing.T, m *fake_apis.MockClientMockRecorder) {
+				t.Helper()
+				m.APIImporttasksIDCancelPost(
+					gomock.Any(),
+					gomock.Any(),
+					apisgen.APIImporttasksIDCancelPostParams{ID: "task-000001"},
+				).DoAndReturn(
+					func(
+						_ context.Context,
+						req apisgen.APIImporttasksIDCancelPostReq,
+						_ apisgen.APIImporttasksIDCancelPostParams,
+					) (apisgen.APIImporttasksIDCancelPostRes, error) {
+						payload, ok := req.(*apisgen.CancelImportTaskRequest)
+						assert.Assert(t, ok)
+						assert.DeepEqual(t, payload, &apisgen.CancelImportTaskRequest{
+							Reason:      apisgen.NewOptNilString("Ingest canceled"),
+							CancelledBy: apisgen.NewOptNilString("archivist@example.com"),
+						})
+
+						return &apisgen.APIImporttasksIDCancelPostNoContent{}, nil
+					},
+				)
+			},
+		},
+		{
+			name:   "omits empty reason and user",
+			params: apis.CancelImportTaskParams{TaskID: "task-000002"},
+			expect: func(t *testing.T, m *fake_apis.MockClientMockRecorder) {
+				t.Helper()
+				m.APIImporttasksIDCancelPost(
+					gomock.Any(),
+					&apisgen.CancelImportTaskRequest{},
+					apisgen.APIImporttasksIDCancelPostParams{ID: "task-000002"},
+				).Return(
+					&apisgen.APIImporttasksIDCancelPostNoContent{},
+					nil,
+				)
+			},
+		},
+		{
+			name:   "returns not found error",
+			params: apis.CancelImportTaskParams{TaskID: "task-000003"},
+			expect: func(t *testing.T, m *fake_apis.MockClientMockRecorder) {
+				t.Helper()
+				m.APIImporttasksIDCancelPost(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+					&apisgen.APIImporttasksIDCancelPostNotFound{
+						Detail: apisgen.NewOptNilString("import task does not exist"),
+					},
+					nil,
+				)
+			},
+			wantErr: "cancel APIS import task: task not found: import task does not exist",
+		},
+		{
+			name:   "returns conflict error",
+			params: apis.CancelImportTaskParams{TaskID: "task-000004"},
+			expect: func(t *testing.T, m *fake_apis.MockClientMockRecorder) {
+				t.Helper()
+				m.APIImporttasksIDCancelPost(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+					&apisgen.APIImporttasksIDCancelPostConflict{
+						Detail: apisgen.NewOptNilString("import task has already been imported"),
+					},
+					nil,
+				)
+			},
+			wantErr: "cancel APIS import task: conflict: import task has already been imported",
+		},
+		{
+			name:   "returns conflict error without details",
+			params: apis.CancelImportTaskParams{TaskID: "task-000005"},
+			expect: func(t *testing.T, m *fake_apis.MockClientMockRecorder) {
+				t.Helper()
+				m.APIImporttasksIDCancelPost(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+					&apisgen.APIImporttasksIDCancelPostConflict{},
+					nil,
+				)
+			},
+			wantErr: "cancel APIS import task: conflict: no additional details",
+		},
+		{
+			name:   "returns unauthorized error",
+			params: apis.CancelImportTaskParams{TaskID: "task-000006"},
+			expect: func(t *testing.T, m *fake_apis.MockClientMockRecorder) {
+				t.Helper()
+				m.APIImporttasksIDCancelPost(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+					&apisgen.APIImporttasksIDCancelPostUnauthorized{
+						Detail: apisgen.NewOptNilString("unauthorized"),
+					},
+					nil,
+				)
+			},
+			wantErr: "cancel APIS import task: unauthorized",
+		},
+		{
+			name:   "returns server error",
+			params: apis.CancelImportTaskParams{TaskID: "task-000007"},
+			expect: func(t *testing.T, m *fake_apis.MockClientMockRecorder) {
+				t.Helper()
+				m.APIImporttasksIDCancelPost(gomock.Any(), gomock.Any(), gomock.Any()).Return(
+					&apisgen.APIImporttasksIDCancelPostInternalServerError{
+						Detail: apisgen.NewOptNilString("database unavailable"),
+					},
+					nil,
+				)
+			},
+			wantErr: "cancel APIS import task: server error: database unavailable",
+		},
+		{
+			name:   "returns client error",
+			params: apis.CancelImportTaskParams{TaskID: "task-000008"},
+			expect: func(t *testing.T, m *fake_apis.MockClientMockRecorder) {
+				t.Helper()
+				m.APIImporttasksIDCancelPost(gomock.Any(), gomock.Any(), gomock.Any()).
+					Return(nil, errors.New("error from client"))
+			},
+			wantErr: "cancel APIS import task: error from client",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ctrl := gomock.NewController(t)
+			client := fake_apis.NewMockClient(ctrl)
+			if tt.expect != nil {
+				tt.expect(t, client.EXPECT())
+			}
+
+			suite := temporalsdk_testsuite.WorkflowTestSuite{}
+			env := suite.NewTestActivityEnvironment()
+			env.RegisterActivityWithOptions(
+				apis.NewCancelImportTaskActivity(client).Execute,
+				temporalsdk_activity.RegisterOptions{Name: apis.CancelImportTaskActivityName},
+			)
+
+			future, err := env.ExecuteActivity(apis.CancelImportTaskActivityName, &tt.params)
+			if tt.wantErr != "" {
+				assert.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			assert.NilError(t, err)
+
+			var result apis.CancelImportTaskResult
+			assert.NilError(t, future.Get(&result))
+			assert.DeepEqual(t, result, apis.CancelImportTaskResult{})
+		})
+	}
+}
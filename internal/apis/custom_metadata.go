@@ -12,11 +12,26 @@ const (
 	DecisionOptionCancelIngest      = "Cancel ingest"
 	DecisionOptionContinueOverwrite = "Continue and overwrite"
 	DecisionOptionContinueAppend    = "Continue and append"
+
+	// DefaultUser is the APIS user recorded for the actions that aren't
+	// attributed to a user decision.
+	DefaultUser = "sfa-enduro"
 )
 
 type CustomMetadata struct {
 	ImportTaskID string `json:"importTaskId"`
 	Decision     string `json:"decision"`
+	DecidedBy    string `json:"decidedBy,omitempty"`
+}
+
+// User returns the user who made the APIS decision, or DefaultUser if there
+// was no decision or it wasn't attributed to a user.
+func (m CustomMetadata) User() string {
+	if m.DecidedBy == "" {
+		return DefaultUser
+	}
+
+	return m.DecidedBy
 }
 
 func (m CustomMetadata) Marshal() ([]byte, error) {
@@ -23,6 +23,22 @@ func TestCustomMetadataMarshal(t *testing.T) {
 		assert.Equal(t, string(data), `{"importTaskId":"task-000001","decision":"Continue and append"}`)
 	})
 
+	t.Run("marshals the decision user", func(t *testing.T) {
+		t.Parallel()
+
+		data, err := apis.CustomMetadata{
+			ImportTaskID: "task-000001",
+			Decision:     "Cancel ingest",
+			DecidedBy:    "archivist@example.com",
+		}.Marshal()
+		assert.NilError(t, err)
+		assert.Equal(
+			t,
+			string(data),
+			`{"importTaskId":"task-000001","decision":"Cancel ingest","decidedBy":"archivist@example.com"}`,
+		)
+	})
+
 	t.Run("rejects missing task ID", func(t *testing.T) {
 		t.Parallel()
 
@@ -63,6 +79,17 @@ func TestCustomMetadataUnmarshal(t *testing.T) {
 	})
 }
 
+func TestCustomMetadataUser(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, apis.CustomMetadata{ImportTaskID: "task-000001"}.User(), apis.DefaultUser)
+	assert.Equal(
+		t,
+		apis.CustomMetadata{ImportTaskID: "task-000001", DecidedBy: "archivist@example.com"}.User(),
+		"archivist@example.com",
+	)
+}
+
 func TestCustomMetadataImportBehaviour(t *testing.T) {
 	t.Parallel()
 
@@ -8,6 +8,7 @@ import (
 	"go.artefactual.dev/tools/temporal"
 
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/apis/gen"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/progress"
 )
 
 const PollImportTaskStatusActivityName = "poll-apis-import-task-status"
@@ -49,6 +50,8 @@ func (a *PollImportTaskStatusActivity) Execute(
 	ticker := time.NewTicker(a.pollInterval)
 	defer ticker.Stop()
 
+	reporter := progress.NewReporter(ctx, progress.APISAnalysis)
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -63,6 +66,10 @@ func (a *PollImportTaskStatusActivity) Execute(
 
 			switch status := res.(type) {
 			case *gen.ImportTaskStatusResponse:
+				if pct, ok := status.AnalysisProgressInPercent.Get(); ok {
+					reporter.Report(progress.Counter{Done: int64(pct), Total: 100})
+				}
+
 				done, err := analysisComplete(status)
 				if err != nil {
 					return nil, err
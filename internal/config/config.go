@@ -12,8 +12,11 @@ import (
 	"go.artefactual.dev/ssclient"
 
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/apis"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/enums"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/fformat"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/fvalidate"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/persistence"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/sip"
 )
 
 type ConfigurationValidator interface {
@@ -98,6 +101,12 @@ type PreprocessingConfig struct {
 	// WorkflowName is the preprocessing Temporal workflow name (required).
 	WorkflowName string
 
+	// ValidationWorkflowName is the Temporal workflow name of the
+	// validation-only preprocessing workflow, which runs the SIP validation
+	// tasks without preparing the SIP for ingest (optional). The workflow is
+	// not registered when empty.
+	ValidationWorkflowName string
+
 	// SharedPath is a file path that both Preprocessing and Enduro can access
 	// (required).
 	//
@@ -106,6 +115,12 @@ type PreprocessingConfig struct {
 	// Enduro and preservation processing.
 	SharedPath string
 
+	// FailedSIPsPath is the directory where SIPs that fail preprocessing with
+	// a content error are moved to, alongside a validation report, and where
+	// the premis.xml file of every failed run is written (optional). Failed
+	// SIPs are left in SharedPath and failed runs aren't recorded when empty.
+	FailedSIPsPath string
+
 	// CheckDuplicates enables or disables a check for SIPs that have already
 	// been processed. When enabled, the persistence configuration below will
 	// be required, and a SIP that has already been processed will fail the
@@ -116,7 +131,15 @@ type PreprocessingConfig struct {
 	BagCreate   bagcreate.Config
 
 	FileFormat   ffvalidate.Config
+	FileIdentify fformat.Config
 	FileValidate fvalidate.Config
+
+	// Naming configures the SIP naming rules (optional).
+	Naming NamingConfig
+
+	// ChecksumConcurrency is the number of SIP files hashed concurrently when
+	// verifying the manifest checksums (optional, defaults to 4).
+	ChecksumConcurrency int
 }
 
 func (c PreprocessingConfig) Validate() error {
@@ -133,6 +156,18 @@ func (c PreprocessingConfig) Validate() error {
 		errs = errors.Join(errs, fmt.Errorf("Preprocessing.BagCreate: %v", err))
 	}
 
+	if err := c.FileIdentify.Validate(); err != nil {
+		errs = errors.Join(errs, fmt.Errorf("Preprocessing.FileIdentify: %v", err))
+	}
+
+	if err := c.FileValidate.Validate(); err != nil {
+		errs = errors.Join(errs, fmt.Errorf("Preprocessing.FileValidate: %v", err))
+	}
+
+	if err := c.Naming.Validate(); err != nil {
+		errs = errors.Join(errs, err)
+	}
+
 	if c.CheckDuplicates {
 		if c.Persistence.DSN == "" {
 			errs = errors.Join(errs, errRequired("Preprocessing.Persistence.DSN"))
@@ -145,6 +180,53 @@ func (c PreprocessingConfig) Validate() error {
 	return errs
 }
 
+// NamingConfig sets the naming rule of each SIP type. The default rule of a
+// SIP type is used when its pattern is empty.
+type NamingConfig struct {
+	DigitizedAIP   sip.NamingRule
+	DigitizedSIP   sip.NamingRule
+	BornDigitalAIP sip.NamingRule
+	BornDigitalSIP sip.NamingRule
+}
+
+func (c NamingConfig) configured() sip.NamingRules {
+	return sip.NamingRules{
+		enums.SIPTypeDigitizedAIP:   c.DigitizedAIP,
+		enums.SIPTypeDigitizedSIP:   c.DigitizedSIP,
+		enums.SIPTypeBornDigitalAIP: c.BornDigitalAIP,
+		enums.SIPTypeBornDigitalSIP: c.BornDigitalSIP,
+	}
+}
+
+func (c NamingConfig) Validate() error {
+	var errs error
+
+	rules := c.configured()
+	for _, t := range enums.SIPTypeNames() {
+		rule := rules[enums.SIPType(t)]
+		if rule.Pattern == "" {
+			continue
+		}
+		if err := rule.Validate(); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("Preprocessing.Naming.%s: %v", t, err))
+		}
+	}
+
+	return errs
+}
+
+// Rules returns the naming rules of all the SIP types.
+func (c NamingConfig) Rules() sip.NamingRules {
+	rules := sip.DefaultNamingRules()
+	for t, rule := range c.configured() {
+		if rule.Pattern != "" {
+			rules[t] = rule
+		}
+	}
+
+	return rules
+}
+
 type PoststorageConfig struct {
 	// WorkflowName is the poststorage Temporal workflow name (required).
 	WorkflowName string
@@ -204,6 +286,7 @@ func Read(config *Config, configFile string) (found bool, configFileUsed string,
 	v.SetDefault("Temporal.Namespace", "default")
 	v.SetDefault("Worker.MaxConcurrentSessions", 1)
 	v.SetDefault("Preprocessing.BagCreate.ChecksumAlgorithm", "sha512")
+	v.SetDefault("Preprocessing.FileValidate.Builtin.Enabled", true)
 
 	if configFile != "" {
 		// Viper will not return a viper.ConfigFileNotFoundError error when
@@ -12,8 +12,11 @@ import (
 
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/apis"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/config"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/enums"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/fformat"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/fvalidate"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/persistence"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/sip"
 )
 
 const testConfig = `# Config
@@ -27,7 +30,9 @@ maxConcurrentSessions = 1
 taskQueue = "sfa-enduro"
 [preprocessing]
 workflowName = "preprocessing"
+validationWorkflowName = "preprocessing-validation"
 sharedPath = "/home/preprocessing/shared"
+failedSIPsPath = "/home/preprocessing/failed-sips"
 checkDuplicates = true
 [preprocessing.persistence]
 dsn = "file:/path/to/fake.db"
@@ -37,8 +42,34 @@ migrate = true
 checksumAlgorithm = "md5"
 [preprocessing.fileFormat]
 allowlistPath = "/home/preprocessing/.config/allowed_file_formats.csv"
+[preprocessing.fileIdentify]
+signatureFile = "/home/preprocessing/.config/siegfried/default.sig"
+ambiguous = "warn"
+concurrency = 8
+[preprocessing.filevalidate]
+concurrency = 2
 [preprocessing.filevalidate.verapdf]
 path = "/opt/verapdf/verapdf"
+timeout = "30m"
+[preprocessing.filevalidate.jhove]
+path = "/opt/jhove/jhove"
+timeout = "2m"
+[preprocessing.filevalidate.builtin]
+enabled = false
+[[preprocessing.filevalidate.tools]]
+name = "MediaConch"
+command = ["/usr/bin/mediaconch", "--policy=/etc/mediaconch/policy.xml", "{path}"]
+puids = ["fmt/569"]
+scope = "file"
+validExitCodes = [0]
+invalidExitCodes = [1, 2]
+agentURL = "https://mediaarea.net/MediaConch"
+versionCommand = ["/usr/bin/mediaconch", "--version"]
+timeout = "5m"
+[preprocessing.naming.digitizedAIP]
+pattern = '^AIP_\d{8}_[a-z]+$'
+description = "AIP_<YYYYMMDD>_<name>"
+example = "AIP_20201201_vecteur"
 [poststorage]
 workflowName = "poststorage"
 workingDir = "/tmp"
@@ -98,9 +129,11 @@ func TestConfig(t *testing.T) {
 					PollInterval: apis.DefaultPollInterval,
 				},
 				Preprocessing: config.PreprocessingConfig{
-					WorkflowName:    "preprocessing",
-					SharedPath:      "/home/preprocessing/shared",
-					CheckDuplicates: true,
+					WorkflowName:           "preprocessing",
+					ValidationWorkflowName: "preprocessing-validation",
+					SharedPath:             "/home/preprocessing/shared",
+					FailedSIPsPath:         "/home/preprocessing/failed-sips",
+					CheckDuplicates:        true,
 					Persistence: persistence.Config{
 						DSN:     "file:/path/to/fake.db",
 						Driver:  "sqlite3",
@@ -112,9 +145,44 @@ func TestConfig(t *testing.T) {
 					FileFormat: ffvalidate.Config{
 						AllowlistPath: "/home/preprocessing/.config/allowed_file_formats.csv",
 					},
+					FileIdentify: fformat.Config{
+						SignatureFile: "/home/preprocessing/.config/siegfried/default.sig",
+						Ambiguous:     fformat.AmbiguityWarn,
+						Concurrency:   8,
+					},
 					FileValidate: fvalidate.Config{
 						VeraPDF: fvalidate.VeraPDFConfig{
-							Path: "/opt/verapdf/verapdf",
+							Path:    "/opt/verapdf/verapdf",
+							Timeout: 30 * time.Minute,
+						},
+						JHOVE: fvalidate.JHOVEConfig{
+							Path:    "/opt/jhove/jhove",
+							Timeout: 2 * time.Minute,
+						},
+						Tools: []fvalidate.ToolConfig{
+							{
+								Name: "MediaConch",
+								Command: []string{
+									"/usr/bin/mediaconch",
+									"--policy=/etc/mediaconch/policy.xml",
+									"{path}",
+								},
+								PUIDs:            []string{"fmt/569"},
+								Scope:            "file",
+								ValidExitCodes:   []int{0},
+								InvalidExitCodes: []int{1, 2},
+								AgentURL:         "https://mediaarea.net/MediaConch",
+								VersionCommand:   []string{"/usr/bin/mediaconch", "--version"},
+								Timeout:          5 * time.Minute,
+							},
+						},
+						Concurrency: 2,
+					},
+					Naming: config.NamingConfig{
+						DigitizedAIP: sip.NamingRule{
+							Pattern:     `^AIP_\d{8}_[a-z]+$`,
+							Description: "AIP_<YYYYMMDD>_<name>",
+							Example:     "AIP_20201201_vecteur",
 						},
 					},
 				},
@@ -184,6 +252,71 @@ checksumAlgorithm = "unknown"
 			wantErr: `invalid configuration
 Preprocessing.BagCreate: ChecksumAlgorithm: invalid value "unknown", must be one of (md5, sha1, sha256, sha512)`,
 		},
+		{
+			name:       "Errors when a naming rule is invalid",
+			configFile: "preprocessing.toml",
+			toml: `# Config
+[temporal]
+address = "host:port"
+[worker]
+taskQueue = "sfa-enduro"
+[preprocessing]
+workflowName = "preprocessing"
+sharedPath = "/home/preprocessing/shared"
+[preprocessing.naming.bornDigitalSIP]
+pattern = "^SIP_("
+` + validPoststorageConfig,
+			wantFound: true,
+			wantErr: "invalid configuration\n" +
+				"Preprocessing.Naming.BornDigitalSIP: Pattern: error parsing regexp: missing closing ): `^SIP_(`\n" +
+				"Description: missing required value",
+		},
+		{
+			name:       "Errors when a file validation tool is invalid",
+			configFile: "preprocessing.toml",
+			toml: `# Config
+[temporal]
+address = "host:port"
+[worker]
+taskQueue = "sfa-enduro"
+[preprocessing]
+workflowName = "preprocessing"
+sharedPath = "/home/preprocessing/shared"
+[[preprocessing.filevalidate.tools]]
+name = "MediaConch"
+command = ["/usr/bin/mediaconch"]
+puids = ["fmt/569"]
+scope = "sip"
+agentURL = "https://mediaarea.net/MediaConch"
+[[preprocessing.filevalidate.tools]]
+name = "MediaConch"
+puids = ["fmt/569"]
+agentURL = "https://mediaarea.net/MediaConch"
+` + validPoststorageConfig,
+			wantFound: true,
+			wantErr: "invalid configuration\n" +
+				`Preprocessing.FileValidate: Tools[0]: Scope: invalid value "sip", must be one of (file, dir)` + "\n" +
+				"Tools[1]: Command: missing required value\n" +
+				`Tools[1]: Name: duplicate value "MediaConch"`,
+		},
+		{
+			name:       "Errors when the file identification policy is invalid",
+			configFile: "preprocessing.toml",
+			toml: `# Config
+[temporal]
+address = "host:port"
+[worker]
+taskQueue = "sfa-enduro"
+[preprocessing]
+workflowName = "preprocessing"
+sharedPath = "/home/preprocessing/shared"
+[preprocessing.fileIdentify]
+ambiguous = "ignore"
+` + validPoststorageConfig,
+			wantFound: true,
+			wantErr: "invalid configuration\n" +
+				`Preprocessing.FileIdentify: Ambiguous: invalid value "ignore", must be one of (best, warn, fail)`,
+		},
 		{
 			name:       "Errors when persistence configuration is missing",
 			configFile: "preprocessing.toml",
@@ -239,6 +372,9 @@ url = "http://apis.example.test"
 					BagCreate: bagcreate.Config{
 						ChecksumAlgorithm: "sha512",
 					},
+					FileValidate: fvalidate.Config{
+						Builtin: fvalidate.BuiltinConfig{Enabled: true},
+					},
 				},
 				Poststorage: config.PoststorageConfig{
 					WorkflowName: "poststorage",
@@ -350,6 +486,9 @@ token = "mock-token"
 					BagCreate: bagcreate.Config{
 						ChecksumAlgorithm: "sha512",
 					},
+					FileValidate: fvalidate.Config{
+						Builtin: fvalidate.BuiltinConfig{Enabled: true},
+					},
 				},
 				Poststorage: config.PoststorageConfig{
 					WorkflowName: "poststorage",
@@ -411,3 +550,18 @@ token = "mock-token"
 		})
 	}
 }
+
+func TestNamingConfigRules(t *testing.T) {
+	t.Parallel()
+
+	rule := sip.NamingRule{
+		Pattern:     `^AIP_\d{8}_[a-z]+$`,
+		Description: "AIP_<YYYYMMDD>_<name>",
+		Example:     "AIP_20201201_vecteur",
+	}
+	cfg := config.NamingConfig{DigitizedAIP: rule}
+
+	want := sip.DefaultNamingRules()
+	want[enums.SIPTypeDigitizedAIP] = rule
+	assert.DeepEqual(t, cfg.Rules(), want)
+}
@@ -0,0 +1,57 @@
+package fformat
+
+import "fmt"
+
+// AmbiguityPolicy sets how file format identifications that are ambiguous
+// (several candidate formats) or unknown (no format) are handled.
+type AmbiguityPolicy string
+
+const (
+	// AmbiguityBest uses the candidate with the best identification basis for
+	// ambiguous identifications, and reports unknown formats as warnings.
+	AmbiguityBest AmbiguityPolicy = "best"
+
+	// AmbiguityWarn uses the candidate with the best identification basis
+	// for ambiguous identifications, and reports both ambiguous and unknown
+	// identifications as warnings.
+	AmbiguityWarn AmbiguityPolicy = "warn"
+
+	// AmbiguityFail reports ambiguous and unknown identifications as content
+	// errors, failing the SIP.
+	AmbiguityFail AmbiguityPolicy = "fail"
+)
+
+type Config struct {
+	// SignatureFile is the path of a Siegfried signature file, e.g. built
+	// with `roy build` from a newer PRONOM release, including the container
+	// signatures. The signatures embedded in the worker are used when empty.
+	SignatureFile string
+
+	// Ambiguous is the policy for ambiguous and unknown identifications, one
+	// of "best" (default), "warn" or "fail".
+	Ambiguous AmbiguityPolicy
+
+	// Concurrency is the number of Siegfried instances identifying files
+	// concurrently (optional, defaults to 4).
+	Concurrency int
+}
+
+// Validate returns an error if the configuration is not valid. The signature
+// file is checked when it's loaded.
+func (c Config) Validate() error {
+	switch c.Ambiguous {
+	case "", AmbiguityBest, AmbiguityWarn, AmbiguityFail:
+		return nil
+	default:
+		return fmt.Errorf("Ambiguous: invalid value %q, must be one of (best, warn, fail)", c.Ambiguous)
+	}
+}
+
+// Policy returns the configured ambiguity policy, AmbiguityBest by default.
+func (c Config) Policy() AmbiguityPolicy {
+	if c.Ambiguous == "" {
+		return AmbiguityBest
+	}
+
+	return c.Ambiguous
+}
@@ -13,6 +13,7 @@ import (
 	reflect "reflect"
 
 	fformat "github.com/artefactual-sdps/preprocessing-sfa/internal/fformat"
+	premis "github.com/artefactual-sdps/preprocessing-sfa/internal/premis"
 	gomock "go.uber.org/mock/gomock"
 )
 
@@ -79,6 +80,44 @@ func (c *MockIdentifierIdentifyCall) DoAndReturn(f func(string) (*fformat.FileFo
 	return c
 }
 
+// PREMISAgent mocks base method.
+func (m *MockIdentifier) PREMISAgent() premis.Agent {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PREMISAgent")
+	ret0, _ := ret[0].(premis.Agent)
+	return ret0
+}
+
+// PREMISAgent indicates an expected call of PREMISAgent.
+func (mr *MockIdentifierMockRecorder) PREMISAgent() *MockIdentifierPREMISAgentCall {
+	mr.mock.ctrl.T.Helper()
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PREMISAgent", reflect.TypeOf((*MockIdentifier)(nil).PREMISAgent))
+	return &MockIdentifierPREMISAgentCall{Call: call}
+}
+
+// MockIdentifierPREMISAgentCall wrap *gomock.Call
+type MockIdentifierPREMISAgentCall struct {
+	*gomock.Call
+}
+
+// Return rewrite *gomock.Call.Return
+func (c *MockIdentifierPREMISAgentCall) Return(arg0 premis.Agent) *MockIdentifierPREMISAgentCall {
+	c.Call = c.Call.Return(arg0)
+	return c
+}
+
+// Do rewrite *gomock.Call.Do
+func (c *MockIdentifierPREMISAgentCall) Do(f func() premis.Agent) *MockIdentifierPREMISAgentCall {
+	c.Call = c.Call.Do(f)
+	return c
+}
+
+// DoAndReturn rewrite *gomock.Call.DoAndReturn
+func (c *MockIdentifierPREMISAgentCall) DoAndReturn(f func() premis.Agent) *MockIdentifierPREMISAgentCall {
+	c.Call = c.Call.DoAndReturn(f)
+	return c
+}
+
 // Version mocks base method.
 func (m *MockIdentifier) Version() string {
 	m.ctrl.T.Helper()
@@ -3,11 +3,11 @@ package fformat
 import (
 	"context"
 	"errors"
+	"fmt"
 	"io/fs"
 	"path/filepath"
 
-	"go.artefactual.dev/tools/temporal"
-
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/premis"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/sip"
 )
 
@@ -18,8 +18,15 @@ type Identifier interface {
 
 	// Version returns the file format identification software version.
 	Version() string
+
+	// PREMISAgent returns a PREMIS agent representing the file format
+	// identification software.
+	PREMISAgent() premis.Agent
 }
 
+// UnknownID is the ID of a file format that couldn't be identified.
+const UnknownID = "UNKNOWN"
+
 // A FileFormat represents a file format.
 type FileFormat struct {
 	Namespace  string // Namespace of the format identifier (e.g. "PRONOM").
@@ -29,13 +36,35 @@ type FileFormat struct {
 	MIMEType   string // MIMEType of the format (e.g. "application/msword").
 	Basis      string // Basis for identification of the format (e.g. "magic").
 	Warning    string // Warning message (if any) from the format identifier.
+
+	// Candidates are the other format IDs matched by an ambiguous
+	// identification, ID being the candidate with the best basis.
+	Candidates []string `json:",omitempty"`
+}
+
+// Known returns true if the file format has been identified.
+func (ff *FileFormat) Known() bool {
+	return ff != nil && ff.ID != "" && ff.ID != UnknownID
+}
+
+// Ambiguous returns true if the file matched several file formats.
+func (ff *FileFormat) Ambiguous() bool {
+	return ff != nil && len(ff.Candidates) > 0
+}
+
+// Unidentified returns the file format of a file that couldn't be identified
+// because of err.
+func Unidentified(err error) *FileFormat {
+	return &FileFormat{ID: UnknownID, Warning: fmt.Sprintf("identification failed: %v", err)}
 }
 
 type FileFormats map[string]*FileFormat
 
+// IdentifyFormats identifies the formats of the files in the SIP content
+// directory with identifier, see IdentifyFiles. The formats are keyed by
+// absolute path.
 func IdentifyFormats(ctx context.Context, identifier Identifier, sip sip.SIP) (FileFormats, error) {
-	logger := temporal.GetLogger(ctx)
-	formats := make(FileFormats)
+	var paths []string
 	err := filepath.WalkDir(sip.ContentPath, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
@@ -45,15 +74,8 @@ func IdentifyFormats(ctx context.Context, identifier Identifier, sip sip.SIP) (F
 			return errors.New("context cancelled")
 		}
 
-		if d.IsDir() {
-			return nil
-		}
-
-		ff, err := identifier.Identify(path)
-		if err != nil {
-			logger.Info("format identification failed", "path", path)
-		} else {
-			formats[path] = ff
+		if !d.IsDir() {
+			paths = append(paths, path)
 		}
 
 		return nil
@@ -62,5 +84,15 @@ func IdentifyFormats(ctx context.Context, identifier Identifier, sip sip.SIP) (F
 		return nil, err
 	}
 
+	ffs, err := IdentifyFiles(ctx, identifier, paths)
+	if err != nil {
+		return nil, err
+	}
+
+	formats := make(FileFormats, len(paths))
+	for i, path := range paths {
+		formats[path] = ffs[i]
+	}
+
 	return formats, nil
 }
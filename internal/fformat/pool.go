@@ -0,0 +1,162 @@
+package fformat
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"go.artefactual.dev/tools/temporal"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/premis"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/progress"
+)
+
+// DefaultConcurrency is the default number of identifiers in a Pool.
+const DefaultConcurrency = 4
+
+// Pool is an Identifier sharing a fixed set of identifiers between concurrent
+// callers. Each identifier identifies one file at a time and is reused for
+// the next file, so Identify blocks while all the identifiers are busy.
+type Pool struct {
+	identifiers chan Identifier
+	first       Identifier
+}
+
+var _ Identifier = (*Pool)(nil)
+
+// NewPool returns a Pool of size identifiers created by newIdentifier, or of
+// DefaultConcurrency identifiers if size is not positive.
+func NewPool(size int, newIdentifier func() (Identifier, error)) (*Pool, error) {
+	if size <= 0 {
+		size = DefaultConcurrency
+	}
+
+	p := &Pool{identifiers: make(chan Identifier, size)}
+	for range size {
+		idr, err := newIdentifier()
+		if err != nil {
+			return nil, err
+		}
+		if p.first == nil {
+			p.first = idr
+		}
+		p.identifiers <- idr
+	}
+
+	return p, nil
+}
+
+// NewSiegfriedPool returns a Pool of cfg.Concurrency Siegfried identifiers,
+// all using the cfg signature file.
+func NewSiegfriedPool(cfg Config) (*Pool, error) {
+	return NewPool(cfg.Concurrency, func() (Identifier, error) {
+		return NewSiegfried(cfg)
+	})
+}
+
+// Identify identifies the file at path with the first available identifier.
+func (p *Pool) Identify(path string) (*FileFormat, error) {
+	idr := <-p.identifiers
+	defer func() { p.identifiers <- idr }()
+
+	return idr.Identify(path)
+}
+
+// Size returns the number of identifiers in the pool.
+func (p *Pool) Size() int {
+	return cap(p.identifiers)
+}
+
+func (p *Pool) Version() string {
+	return p.first.Version()
+}
+
+func (p *Pool) PREMISAgent() premis.Agent {
+	return p.first.PREMISAgent()
+}
+
+// Concurrency returns the number of files identifier identifies concurrently:
+// the number of identifiers of a Pool, one for other identifiers.
+func Concurrency(identifier Identifier) int {
+	if p, ok := identifier.(*Pool); ok {
+		return p.Size()
+	}
+
+	return 1
+}
+
+// IdentifyFiles identifies the files in paths with identifier and returns
+// their formats in the paths order. A Pool identifier identifies as many files
+// concurrently as it has identifiers, other identifiers one file at a time.
+// Progress is reported as the FilesIdentified counter, which also heartbeats
+// the calling activity. A file that can't be identified has an Unidentified
+// format, only a cancelled ctx returns an error.
+func IdentifyFiles(ctx context.Context, identifier Identifier, paths []string) ([]*FileFormat, error) {
+	var (
+		formats  = make([]*FileFormat, len(paths))
+		counter  = progress.Counter{Total: int64(len(paths))}
+		reporter = progress.NewReporter(ctx, progress.FilesIdentified)
+	)
+	reporter.Report(counter)
+
+	err := IdentifyEach(ctx, identifier, paths, func(i int, ff *FileFormat) {
+		formats[i] = ff
+		counter.Done++
+		reporter.Report(counter)
+	})
+	if err != nil {
+		return nil, errors.New("context cancelled")
+	}
+
+	return formats, nil
+}
+
+// IdentifyEach identifies the files in paths with identifier, as many files
+// concurrently as Concurrency(identifier), and calls identified with the paths
+// index and the format of each file, one call at a time. A file that can't be
+// identified has an Unidentified format. No more files are identified once
+// ctx is done, and the ctx error is returned.
+func IdentifyEach(
+	ctx context.Context,
+	identifier Identifier,
+	paths []string,
+	identified func(i int, ff *FileFormat),
+) error {
+	var (
+		logger = temporal.GetLogger(ctx)
+		mu     sync.Mutex
+	)
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(Concurrency(identifier), len(paths)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				ff, err := identifier.Identify(paths[i])
+				if err != nil {
+					logger.Info("format identification failed", "path", paths[i], "error", err)
+					ff = Unidentified(err)
+				}
+
+				mu.Lock()
+				identified(i, ff)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	for i := range paths {
+		select {
+		case jobs <- i:
+			continue
+		case <-ctx.Done():
+		}
+		break
+	}
+	close(jobs)
+	wg.Wait()
+
+	return ctx.Err()
+}
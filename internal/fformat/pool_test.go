@@ -0,0 +1,180 @@
+package fformat_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/fformat"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/premis"
+)
+
+// slowIdentifier identifies files by name after a short delay, recording the
+// maximum number of files identified at the same time by all the instances
+// sharing active.
+type slowIdentifier struct {
+	n         int
+	active    *atomic.Int32
+	maxActive *atomic.Int32
+}
+
+func (s *slowIdentifier) Identify(path string) (*fformat.FileFormat, error) {
+	n := s.active.Add(1)
+	defer s.active.Add(-1)
+	for {
+		m := s.maxActive.Load()
+		if n <= m || s.maxActive.CompareAndSwap(m, n) {
+			break
+		}
+	}
+	time.Sleep(5 * time.Millisecond)
+
+	if filepath.Ext(path) == ".bad" {
+		return nil, errors.New("read error")
+	}
+
+	return &fformat.FileFormat{ID: filepath.Base(path)}, nil
+}
+
+func (s *slowIdentifier) Version() string {
+	return fmt.Sprintf("1.0.%d", s.n)
+}
+
+func (s *slowIdentifier) PREMISAgent() premis.Agent {
+	return premis.Agent{Name: "slow " + s.Version()}
+}
+
+func newSlowPool(t *testing.T, size int) (*fformat.Pool, *atomic.Int32) {
+	t.Helper()
+
+	var active, maxActive atomic.Int32
+	var n int
+	p, err := fformat.NewPool(size, func() (fformat.Identifier, error) {
+		n++
+		return &slowIdentifier{n: n, active: &active, maxActive: &maxActive}, nil
+	})
+	assert.NilError(t, err)
+
+	return p, &maxActive
+}
+
+func TestNewPool(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Creates a pool of the default size", func(t *testing.T) {
+		t.Parallel()
+
+		p, _ := newSlowPool(t, 0)
+		assert.Equal(t, p.Size(), fformat.DefaultConcurrency)
+		assert.Equal(t, p.Version(), "1.0.1")
+		assert.DeepEqual(t, p.PREMISAgent(), premis.Agent{Name: "slow 1.0.1"})
+	})
+
+	t.Run("Errors when an identifier can't be created", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := fformat.NewPool(2, func() (fformat.Identifier, error) {
+			return nil, errors.New("invalid signature file")
+		})
+		assert.Error(t, err, "invalid signature file")
+	})
+
+	t.Run("Creates a pool of Siegfried identifiers", func(t *testing.T) {
+		t.Parallel()
+
+		p, err := fformat.NewSiegfriedPool(fformat.Config{Concurrency: 2})
+		assert.NilError(t, err)
+		assert.Equal(t, p.Size(), 2)
+
+		got, err := p.Identify("pool.go")
+		assert.NilError(t, err)
+		assert.Equal(t, got.ID, "x-fmt/111")
+	})
+}
+
+func TestIdentifyFiles(t *testing.T) {
+	t.Parallel()
+
+	var paths []string
+	for i := range 40 {
+		paths = append(paths, fmt.Sprintf("/sip/content/%02d.txt", i))
+	}
+	paths[7] = "/sip/content/07.bad"
+
+	t.Run("Identifies files concurrently in the paths order", func(t *testing.T) {
+		t.Parallel()
+
+		p, maxActive := newSlowPool(t, 3)
+		got, err := fformat.IdentifyFiles(t.Context(), p, paths)
+		assert.NilError(t, err)
+		assert.Equal(t, len(got), len(paths))
+		for i, ff := range got {
+			if i == 7 {
+				assert.DeepEqual(t, ff, &fformat.FileFormat{
+					ID:      fformat.UnknownID,
+					Warning: "identification failed: read error",
+				})
+				continue
+			}
+			assert.Equal(t, ff.ID, filepath.Base(paths[i]))
+		}
+		assert.Equal(t, maxActive.Load(), int32(3))
+	})
+
+	t.Run("Identifies one file at a time without a pool", func(t *testing.T) {
+		t.Parallel()
+
+		var active, maxActive atomic.Int32
+		idr := &slowIdentifier{active: &active, maxActive: &maxActive}
+		got, err := fformat.IdentifyFiles(t.Context(), idr, paths[:5])
+		assert.NilError(t, err)
+		assert.Equal(t, len(got), 5)
+		assert.Equal(t, maxActive.Load(), int32(1))
+	})
+
+	t.Run("Errors when the context is cancelled", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		p, _ := newSlowPool(t, 2)
+		_, err := fformat.IdentifyFiles(ctx, p, paths)
+		assert.Error(t, err, "context cancelled")
+	})
+}
+
+func TestIdentifyEach(t *testing.T) {
+	t.Parallel()
+
+	paths := []string{"/sip/content/a.txt", "/sip/content/b.bad", "/sip/content/c.txt"}
+
+	t.Run("Calls identified once for each file", func(t *testing.T) {
+		t.Parallel()
+
+		p, _ := newSlowPool(t, 2)
+		got := map[int]string{}
+		err := fformat.IdentifyEach(t.Context(), p, paths, func(i int, ff *fformat.FileFormat) {
+			got[i] = ff.ID
+		})
+		assert.NilError(t, err)
+		assert.DeepEqual(t, got, map[int]string{0: "a.txt", 1: fformat.UnknownID, 2: "c.txt"})
+	})
+
+	t.Run("Errors when the context is cancelled", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		p, _ := newSlowPool(t, 2)
+		err := fformat.IdentifyEach(ctx, p, paths, func(int, *fformat.FileFormat) {})
+		assert.ErrorIs(t, err, context.Canceled)
+	})
+}
@@ -7,7 +7,10 @@ import (
 
 	"github.com/richardlehane/siegfried"
 	"github.com/richardlehane/siegfried/pkg/config"
+	"github.com/richardlehane/siegfried/pkg/core"
 	"github.com/richardlehane/siegfried/pkg/static"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/premis"
 )
 
 // SiegfriedEmbed is an implementation of Siegfried based on the library dist.
@@ -29,8 +32,29 @@ func NewSiegfriedEmbed() *siegfriedEmbed {
 	}
 }
 
+// NewSiegfried returns a Siegfried identifier using the cfg signature file, or
+// the embedded signatures if cfg doesn't set one.
+func NewSiegfried(cfg Config) (*siegfriedEmbed, error) {
+	sf := NewSiegfriedEmbed()
+	if cfg.SignatureFile == "" {
+		return sf, nil
+	}
+
+	embed, err := siegfried.Load(cfg.SignatureFile)
+	if err != nil {
+		return nil, fmt.Errorf("load Siegfried signature file: %v", err)
+	}
+	sf.embed = embed
+	sf.signature = filepath.Base(cfg.SignatureFile)
+
+	return sf, nil
+}
+
 // Identify runs the Siegfried PRONOM file identifier on the file at path and
-// returns a FileFormat pointer or an error.
+// returns a FileFormat pointer or an error. The file format ID is UnknownID if
+// Siegfried couldn't identify the file. When several formats match, the one
+// with the best identification basis is returned, with the other matches as
+// candidates.
 func (sf *siegfriedEmbed) Identify(path string) (*FileFormat, error) {
 	f, err := os.Open(filepath.Clean(path))
 	if err != nil {
@@ -42,13 +66,27 @@ func (sf *siegfriedEmbed) Identify(path string) (*FileFormat, error) {
 	if err != nil {
 		return nil, err
 	}
-	if len(ids) > 1 {
-		return nil, fmt.Errorf("multiple file formats matched: %q", path)
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("no identification result: %q", path)
 	}
 
+	// Siegfried lists the matches of an identifier from the best basis down,
+	// the matches of other identifiers (e.g. MIME-info) are not candidates.
+	res := sf.fileFormat(ids[0])
+	for _, id := range ids[1:] {
+		if ff := sf.fileFormat(id); ff.Namespace == res.Namespace && ff.Known() {
+			res.Candidates = append(res.Candidates, ff.ID)
+		}
+	}
+
+	return res, nil
+}
+
+// fileFormat returns the FileFormat of a Siegfried identification result.
+func (sf *siegfriedEmbed) fileFormat(id core.Identification) *FileFormat {
 	// Loop through Siegfried identifier result key-value pairs
 	var res FileFormat
-	for _, kv := range sf.embed.Label(ids[0]) {
+	for _, kv := range sf.embed.Label(id) {
 		switch kv[0] {
 		case "namespace":
 			res.Namespace = kv[1]
@@ -67,9 +105,20 @@ func (sf *siegfriedEmbed) Identify(path string) (*FileFormat, error) {
 		}
 	}
 
-	return &res, nil
+	return &res
 }
 
 func (s siegfriedEmbed) Version() string {
 	return s.version
 }
+
+// PREMISAgent returns a PREMIS agent naming the Siegfried version and the
+// signature file used to identify the file formats.
+func (s siegfriedEmbed) PREMISAgent() premis.Agent {
+	return premis.Agent{
+		Type:    "software",
+		Name:    fmt.Sprintf("Siegfried %s (signature file: %s)", s.version, s.signature),
+		IdType:  "url",
+		IdValue: "https://github.com/richardlehane/siegfried",
+	}
+}
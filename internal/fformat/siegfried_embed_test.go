@@ -1,11 +1,17 @@
 package fformat_test
 
 import (
+	"fmt"
+	"path/filepath"
 	"testing"
 
+	"github.com/richardlehane/siegfried/pkg/config"
+	"github.com/richardlehane/siegfried/pkg/static"
 	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
 
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/fformat"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/premis"
 )
 
 func TestSiegfriedEmbed(t *testing.T) {
@@ -28,6 +34,20 @@ func TestSiegfriedEmbed(t *testing.T) {
 		})
 	})
 
+	t.Run("Identifies an unknown file format", func(t *testing.T) {
+		t.Parallel()
+
+		dir := fs.NewDir(t, "", fs.WithFile("data", "\x00\x01\x02\x03"))
+
+		sf := fformat.NewSiegfriedEmbed()
+		got, err := sf.Identify(dir.Join("data"))
+		assert.NilError(t, err)
+		assert.Equal(t, got.ID, fformat.UnknownID)
+		assert.Equal(t, got.Warning, "no match")
+		assert.Assert(t, !got.Known())
+		assert.Assert(t, !got.Ambiguous())
+	})
+
 	t.Run("Errors when file not found", func(t *testing.T) {
 		t.Parallel()
 
@@ -37,6 +57,62 @@ func TestSiegfriedEmbed(t *testing.T) {
 	})
 }
 
+func TestNewSiegfried(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Uses the embedded signatures by default", func(t *testing.T) {
+		t.Parallel()
+
+		sf, err := fformat.NewSiegfried(fformat.Config{})
+		assert.NilError(t, err)
+		assert.Equal(t, sf.PREMISAgent().Name, fmt.Sprintf(
+			"Siegfried %s (signature file: %s)", sf.Version(), config.SignatureBase(),
+		))
+	})
+
+	t.Run("Loads a signature file", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "pronom-custom.sig")
+		assert.NilError(t, static.New().Save(path))
+
+		sf, err := fformat.NewSiegfried(fformat.Config{SignatureFile: path})
+		assert.NilError(t, err)
+		assert.Equal(t, sf.PREMISAgent().Name, fmt.Sprintf(
+			"Siegfried %s (signature file: pronom-custom.sig)", sf.Version(),
+		))
+
+		got, err := sf.Identify("siegfried_embed.go")
+		assert.NilError(t, err)
+		assert.Equal(t, got.ID, "x-fmt/111")
+	})
+
+	t.Run("Errors when the signature file is invalid", func(t *testing.T) {
+		t.Parallel()
+
+		dir := fs.NewDir(t, "", fs.WithFile("invalid.sig", "not a signature file"))
+
+		_, err := fformat.NewSiegfried(fformat.Config{SignatureFile: dir.Join("invalid.sig")})
+		assert.Error(
+			t,
+			err,
+			"load Siegfried signature file: not a siegfried signature file; try running `sf -update`",
+		)
+	})
+}
+
+func TestSiegfriedEmbedPREMISAgent(t *testing.T) {
+	t.Parallel()
+
+	sf := fformat.NewSiegfriedEmbed()
+	assert.DeepEqual(t, sf.PREMISAgent(), premis.Agent{
+		Type:    "software",
+		Name:    fmt.Sprintf("Siegfried %s (signature file: %s)", sf.Version(), config.SignatureBase()),
+		IdType:  "url",
+		IdValue: "https://github.com/richardlehane/siegfried",
+	})
+}
+
 func BenchmarkSiegfried(b *testing.B) {
 	b.Run("SiegfriedEmbed", func(b *testing.B) {
 		sf := fformat.NewSiegfriedEmbed()
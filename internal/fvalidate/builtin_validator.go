@@ -0,0 +1,301 @@
+package fvalidate
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"  // Register the GIF decoder.
+	_ "image/jpeg" // Register the JPEG decoder.
+	_ "image/png"  // Register the PNG decoder.
+	"io"
+	"os"
+	"slices"
+
+	"golang.org/x/net/html/charset"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/fsutil"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/premis"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/version"
+)
+
+// maxDecodePixels is the largest image, in pixels, fully decoded by the image
+// validator. Only the header of larger images is decoded to limit memory use.
+const maxDecodePixels = 100_000_000
+
+// builtinValidator validates the structure of a file without an external
+// tool. It finds corrupt or truncated files, but doesn't check full
+// compliance with the format specification.
+type builtinValidator struct {
+	name      string
+	formatIDs []string
+
+	// check returns an error describing why the file is invalid.
+	check func(f *os.File) error
+}
+
+var _ Validator = (*builtinValidator)(nil)
+
+// BuiltinValidators returns all the built-in validators.
+func BuiltinValidators() []Validator {
+	return BuiltinValidatorsExcept()
+}
+
+// BuiltinValidatorsExcept returns the built-in validators without the formats
+// validated by vdrs, so a file isn't validated twice and its failures reported
+// by both validators. The built-in validators left without a format are
+// omitted.
+func BuiltinValidatorsExcept(vdrs ...Validator) []Validator {
+	var claimed []string
+	for _, v := range vdrs {
+		claimed = append(claimed, v.FormatIDs()...)
+	}
+
+	var res []Validator
+	for _, v := range []*builtinValidator{
+		NewXMLValidator(),
+		NewJSONValidator(),
+		NewCSVValidator(),
+		NewImageValidator(),
+		NewJP2Validator(),
+		NewTIFFValidator(),
+	} {
+		v.formatIDs = slices.DeleteFunc(v.formatIDs, func(id string) bool {
+			return slices.Contains(claimed, id)
+		})
+		if len(v.formatIDs) > 0 {
+			res = append(res, v)
+		}
+	}
+
+	return res
+}
+
+// NewXMLValidator returns a validator checking that XML files are
+// well-formed.
+func NewXMLValidator() *builtinValidator {
+	return &builtinValidator{
+		name: "Go XML parser",
+		formatIDs: []string{
+			"fmt/101",   // Extensible Markup Language 1.0
+			"x-fmt/280", // XML Schema Definition
+		},
+		check: checkXML,
+	}
+}
+
+// NewJSONValidator returns a validator checking that JSON files can be
+// parsed.
+func NewJSONValidator() *builtinValidator {
+	return &builtinValidator{
+		name: "Go JSON parser",
+		formatIDs: []string{
+			"fmt/817", // JSON Data Interchange Format
+		},
+		check: checkJSON,
+	}
+}
+
+// NewCSVValidator returns a validator checking that CSV files can be parsed,
+// with the same number of fields in every record.
+func NewCSVValidator() *builtinValidator {
+	return &builtinValidator{
+		name: "Go CSV parser",
+		formatIDs: []string{
+			"x-fmt/18", // Comma Separated Values
+		},
+		check: checkCSV,
+	}
+}
+
+// NewImageValidator returns a validator checking that JPEG, PNG and GIF images
+// can be decoded.
+func NewImageValidator() *builtinValidator {
+	return &builtinValidator{
+		name: "Go image decoder",
+		formatIDs: []string{
+			"fmt/41",    // Raw JPEG Stream
+			"fmt/42",    // JFIF 1.00
+			"fmt/43",    // JFIF 1.01
+			"fmt/44",    // JFIF 1.02
+			"x-fmt/398", // Exif Compressed Image 2.0
+			"x-fmt/390", // Exif Compressed Image 2.1
+			"x-fmt/391", // Exif Compressed Image 2.2
+			"fmt/11",    // PNG 1.0
+			"fmt/12",    // PNG 1.1
+			"fmt/13",    // PNG 1.2
+			"fmt/3",     // GIF 87a
+			"fmt/4",     // GIF 89a
+		},
+		check: checkImage,
+	}
+}
+
+// NewJP2Validator returns a validator checking the box structure of JP2
+// files.
+func NewJP2Validator() *builtinValidator {
+	return &builtinValidator{
+		name: "JP2 box structure check",
+		formatIDs: []string{
+			"x-fmt/392", // JP2 (JPEG 2000 part 1)
+		},
+		check: checkJP2,
+	}
+}
+
+// NewTIFFValidator returns a validator walking the image file directory (IFD)
+// chain of TIFF files.
+func NewTIFFValidator() *builtinValidator {
+	return &builtinValidator{
+		name: "TIFF IFD check",
+		formatIDs: []string{
+			"fmt/353", // Tagged Image File Format
+			"fmt/7",   // TIFF 3
+			"fmt/8",   // TIFF 4
+			"fmt/9",   // TIFF 5
+			"fmt/10",  // TIFF 6
+		},
+		check: checkTIFF,
+	}
+}
+
+func (v *builtinValidator) FormatIDs() []string {
+	return v.formatIDs
+}
+
+func (v *builtinValidator) Name() string {
+	return v.name
+}
+
+// PREMISAgent returns the default agent, the built-in validators are run by
+// preprocessing itself.
+func (v *builtinValidator) PREMISAgent(ctx context.Context) premis.Agent {
+	return premis.AgentDefault()
+}
+
+func (v *builtinValidator) Scope() TargetType {
+	return TargetTypeFile
+}
+
+func (v *builtinValidator) Validate(ctx context.Context, path string) ([]FileResult, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	if !fsutil.FileExists(path) {
+		return nil, fmt.Errorf("validate: file not found: %s", path)
+	}
+
+	f, err := os.Open(path) // #nosec G304 -- trusted file path.
+	if err != nil {
+		return nil, fmt.Errorf("validate: %v", err)
+	}
+	defer f.Close()
+
+	if err := v.check(f); err != nil {
+		return []FileResult{{Path: path, Failures: []string{err.Error()}}}, nil
+	}
+
+	return nil, nil
+}
+
+// Version returns the preprocessing version, as the built-in validators are
+// part of it.
+func (v *builtinValidator) Version(ctx context.Context) (string, error) {
+	return version.Short, nil
+}
+
+func checkXML(f *os.File) error {
+	dec := xml.NewDecoder(bufio.NewReader(f))
+	dec.CharsetReader = charset.NewReaderLabel
+
+	var root bool
+	for {
+		t, err := dec.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("XML is not well-formed: %v", err)
+		}
+		if _, ok := t.(xml.StartElement); ok {
+			root = true
+		}
+	}
+	if !root {
+		return errors.New("XML is not well-formed: no root element")
+	}
+
+	return nil
+}
+
+func checkJSON(f *os.File) error {
+	dec := json.NewDecoder(bufio.NewReader(f))
+
+	// Read the tokens of a single top-level value, the decoder returns an
+	// error for any invalid syntax.
+	var depth int
+	for {
+		t, err := dec.Token()
+		if err == io.EOF {
+			return errors.New("invalid JSON: unexpected end of file")
+		}
+		if err != nil {
+			return fmt.Errorf("invalid JSON: %v", err)
+		}
+		if d, ok := t.(json.Delim); ok {
+			if d == '{' || d == '[' {
+				depth++
+			} else {
+				depth--
+			}
+		}
+		if depth == 0 {
+			break
+		}
+	}
+
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("invalid JSON: unexpected data after top-level value")
+	}
+
+	return nil
+}
+
+func checkCSV(f *os.File) error {
+	r := csv.NewReader(bufio.NewReader(f))
+	r.ReuseRecord = true
+
+	for {
+		_, err := r.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid CSV: %v", err)
+		}
+	}
+}
+
+func checkImage(f *os.File) error {
+	cfg, format, err := image.DecodeConfig(bufio.NewReader(f))
+	if err != nil {
+		return fmt.Errorf("image can't be decoded: %v", err)
+	}
+	if cfg.Width*cfg.Height > maxDecodePixels {
+		return nil
+	}
+
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("image can't be read: %v", err)
+	}
+	if _, _, err := image.Decode(bufio.NewReader(f)); err != nil {
+		return fmt.Errorf("%s image can't be decoded: %v", format, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,309 @@
+package fvalidate_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/fvalidate"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/premis"
+)
+
+func encodeImage(t *testing.T, encode func(*bytes.Buffer, image.Image) error) []byte {
+	t.Helper()
+
+	var buf bytes.Buffer
+	assert.NilError(t, encode(&buf, image.NewRGBA(image.Rect(0, 0, 16, 16))))
+
+	return buf.Bytes()
+}
+
+// jp2Box returns a JPEG 2000 box with the given type and contents.
+func jp2Box(boxType string, contents ...[]byte) []byte {
+	c := bytes.Join(contents, nil)
+	b := binary.BigEndian.AppendUint32(nil, uint32(8+len(c))) // #nosec G115 -- small test data.
+	b = append(b, boxType...)
+
+	return append(b, c...)
+}
+
+func jp2File(boxes ...[]byte) []byte {
+	return bytes.Join(append([][]byte{
+		jp2Box("jP  ", []byte{0x0d, 0x0a, 0x87, 0x0a}),
+		jp2Box("ftyp", []byte("jp2 \x00\x00\x00\x00jp2 ")),
+	}, boxes...), nil)
+}
+
+var (
+	jp2Header     = jp2Box("jp2h", jp2Box("ihdr", make([]byte, 14)))
+	jp2Codestream = jp2Box("jp2c", []byte{0xff, 0x4f, 0xff, 0x51, 0x00, 0x29})
+)
+
+// tiffFile returns a little-endian TIFF file with an IFD at offset 8 with the
+// given tags, all SHORT values, and the next IFD offset.
+func tiffFile(next uint32, tags ...uint16) []byte {
+	b := []byte("II*\x00")
+	b = binary.LittleEndian.AppendUint32(b, 8)
+	b = binary.LittleEndian.AppendUint16(b, uint16(len(tags))) // #nosec G115 -- small test data.
+	for _, tag := range tags {
+		b = binary.LittleEndian.AppendUint16(b, tag)
+		b = binary.LittleEndian.AppendUint16(b, 3) // SHORT
+		b = binary.LittleEndian.AppendUint32(b, 1)
+		b = binary.LittleEndian.AppendUint32(b, 16)
+	}
+
+	return binary.LittleEndian.AppendUint32(b, next)
+}
+
+func TestBuiltinValidators(t *testing.T) {
+	t.Parallel()
+
+	png := encodeImage(t, func(b *bytes.Buffer, i image.Image) error { return png.Encode(b, i) })
+	jpg := encodeImage(t, func(b *bytes.Buffer, i image.Image) error { return jpeg.Encode(b, i, nil) })
+	gif := encodeImage(t, func(b *bytes.Buffer, i image.Image) error { return gif.Encode(b, i, nil) })
+
+	type test struct {
+		name      string
+		validator fvalidate.Validator
+		content   []byte
+		want      string
+	}
+	for _, tt := range []test{
+		{
+			name:      "Valid XML",
+			validator: fvalidate.NewXMLValidator(),
+			content:   []byte(`<?xml version="1.0"?><a><b/></a>`),
+		},
+		{
+			name:      "Valid XML with a declared encoding",
+			validator: fvalidate.NewXMLValidator(),
+			content:   []byte("<?xml version=\"1.0\" encoding=\"ISO-8859-1\"?><a>caf\xe9</a>"),
+		},
+		{
+			name:      "Malformed XML",
+			validator: fvalidate.NewXMLValidator(),
+			content:   []byte(`<a><b></a>`),
+			want:      "XML is not well-formed: XML syntax error on line 1: element <b> closed by </a>",
+		},
+		{
+			name:      "XML without root element",
+			validator: fvalidate.NewXMLValidator(),
+			content:   []byte(`<?xml version="1.0"?>`),
+			want:      "XML is not well-formed: no root element",
+		},
+		{
+			name:      "Valid JSON",
+			validator: fvalidate.NewJSONValidator(),
+			content:   []byte(`{"a": [1, 2, {"b": null}]}`),
+		},
+		{
+			name:      "Invalid JSON",
+			validator: fvalidate.NewJSONValidator(),
+			content:   []byte(`{"a": }`),
+			want:      "invalid JSON: missing value after object key",
+		},
+		{
+			name:      "Truncated JSON",
+			validator: fvalidate.NewJSONValidator(),
+			content:   []byte(`{"a": [1, 2`),
+			want:      "invalid JSON: unexpected end of file",
+		},
+		{
+			name:      "JSON with several top-level values",
+			validator: fvalidate.NewJSONValidator(),
+			content:   []byte(`{} {}`),
+			want:      "invalid JSON: unexpected data after top-level value",
+		},
+		{
+			name:      "Valid CSV",
+			validator: fvalidate.NewCSVValidator(),
+			content:   []byte("a,b\n1,\"2, 3\"\n"),
+		},
+		{
+			name:      "CSV with a wrong number of fields",
+			validator: fvalidate.NewCSVValidator(),
+			content:   []byte("a,b\n1\n"),
+			want:      "invalid CSV: record on line 2: wrong number of fields",
+		},
+		{
+			name:      "Valid PNG",
+			validator: fvalidate.NewImageValidator(),
+			content:   png,
+		},
+		{
+			name:      "Valid JPEG",
+			validator: fvalidate.NewImageValidator(),
+			content:   jpg,
+		},
+		{
+			name:      "Valid GIF",
+			validator: fvalidate.NewImageValidator(),
+			content:   gif,
+		},
+		{
+			name:      "Truncated PNG",
+			validator: fvalidate.NewImageValidator(),
+			content:   png[:len(png)-20],
+			want:      "png image can't be decoded: png: invalid format: unexpected EOF",
+		},
+		{
+			name:      "Not an image",
+			validator: fvalidate.NewImageValidator(),
+			content:   []byte("text"),
+			want:      "image can't be decoded: image: unknown format",
+		},
+		{
+			name:      "Valid JP2",
+			validator: fvalidate.NewJP2Validator(),
+			content:   jp2File(jp2Header, jp2Codestream),
+		},
+		{
+			name:      "JP2 without signature box",
+			validator: fvalidate.NewJP2Validator(),
+			content:   bytes.Join([][]byte{jp2Box("ftyp", []byte("jp2 ")), jp2Header, jp2Codestream}, nil),
+			want:      "invalid JP2 box structure: missing signature or file type box",
+		},
+		{
+			name:      "JP2 without header box",
+			validator: fvalidate.NewJP2Validator(),
+			content:   jp2File(jp2Codestream),
+			want:      "invalid JP2 box structure: codestream box found before the header box",
+		},
+		{
+			name:      "JP2 header box without image header",
+			validator: fvalidate.NewJP2Validator(),
+			content:   jp2File(jp2Box("jp2h", jp2Box("colr", make([]byte, 7))), jp2Codestream),
+			want:      "invalid JP2 header box: missing image header box",
+		},
+		{
+			name:      "Truncated JP2",
+			validator: fvalidate.NewJP2Validator(),
+			content:   jp2File(jp2Header, jp2Codestream)[:70],
+			want:      `invalid JP2 box structure: "jp2c" box at offset 62 has an invalid length 14`,
+		},
+		{
+			name:      "JP2 without codestream",
+			validator: fvalidate.NewJP2Validator(),
+			content:   jp2File(jp2Header),
+			want:      "invalid JP2 box structure: missing codestream box",
+		},
+		{
+			name:      "Valid TIFF",
+			validator: fvalidate.NewTIFFValidator(),
+			content:   tiffFile(0, 256, 257),
+		},
+		{
+			name:      "TIFF with an invalid byte order",
+			validator: fvalidate.NewTIFFValidator(),
+			content:   append([]byte("XX"), tiffFile(0, 256, 257)[2:]...),
+			want:      "invalid TIFF: invalid byte order",
+		},
+		{
+			name:      "TIFF with an IFD loop",
+			validator: fvalidate.NewTIFFValidator(),
+			content:   tiffFile(8, 256, 257),
+			want:      "invalid TIFF: IFD 1 at offset 8 loops back to a previous IFD",
+		},
+		{
+			name:      "TIFF with an IFD outside the file",
+			validator: fvalidate.NewTIFFValidator(),
+			content:   tiffFile(1000, 256, 257),
+			want:      "invalid TIFF: IFD 1 offset 1000 is outside the file",
+		},
+		{
+			name:      "Truncated TIFF",
+			validator: fvalidate.NewTIFFValidator(),
+			content:   tiffFile(0, 256, 257)[:20],
+			want:      "invalid TIFF: IFD 0 at offset 8 is truncated",
+		},
+		{
+			name:      "TIFF without image dimensions",
+			validator: fvalidate.NewTIFFValidator(),
+			content:   tiffFile(0, 256),
+			want:      "invalid TIFF: first IFD has no image width or length",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := fs.NewDir(t, "", fs.WithFile("file", string(tt.content)))
+			path := dir.Join("file")
+
+			got, err := tt.validator.Validate(t.Context(), path)
+			assert.NilError(t, err)
+			if tt.want == "" {
+				assert.Assert(t, got == nil)
+				return
+			}
+			assert.DeepEqual(t, got, []fvalidate.FileResult{{Path: path, Failures: []string{tt.want}}})
+		})
+	}
+}
+
+func TestBuiltinValidatorMissingFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "missing.xml")
+	_, err := fvalidate.NewXMLValidator().Validate(t.Context(), path)
+	assert.Error(t, err, "validate: file not found: "+path)
+}
+
+func TestBuiltinValidatorsAgent(t *testing.T) {
+	t.Parallel()
+
+	for _, v := range fvalidate.BuiltinValidators() {
+		assert.Equal(t, v.Scope(), fvalidate.TargetTypeFile)
+		assert.Assert(t, len(v.FormatIDs()) > 0)
+		assert.DeepEqual(t, v.PREMISAgent(t.Context()), premis.AgentDefault())
+	}
+}
+
+func TestBuiltinValidatorsExcept(t *testing.T) {
+	t.Parallel()
+
+	formatIDs := func(vdrs []fvalidate.Validator) map[string][]string {
+		ids := make(map[string][]string, len(vdrs))
+		for _, v := range vdrs {
+			ids[v.Name()] = v.FormatIDs()
+		}
+		return ids
+	}
+
+	t.Run("Skips the formats validated by JHOVE", func(t *testing.T) {
+		t.Parallel()
+
+		got := formatIDs(fvalidate.BuiltinValidatorsExcept(
+			fvalidate.NewJHOVEValidator("jhove", 0),
+		))
+		assert.DeepEqual(t, got["Go XML parser"], []string{"x-fmt/280"})
+		for _, ids := range got {
+			assert.Assert(t, !slices.Contains(ids, "fmt/101"))
+		}
+	})
+
+	t.Run("Omits the validators left without a format", func(t *testing.T) {
+		t.Parallel()
+
+		tool, err := fvalidate.NewToolValidator(fvalidate.ToolConfig{
+			Name:     "xmllint",
+			Command:  []string{"xmllint", "--noout", "{path}"},
+			PUIDs:    []string{"fmt/101", "x-fmt/280"},
+			AgentURL: "https://gitlab.gnome.org/GNOME/libxml2",
+		})
+		assert.NilError(t, err)
+
+		got := formatIDs(fvalidate.BuiltinValidatorsExcept(tool))
+		_, ok := got["Go XML parser"]
+		assert.Assert(t, !ok)
+		assert.Equal(t, len(got), len(fvalidate.BuiltinValidators())-1)
+	})
+}
@@ -0,0 +1,62 @@
+package fvalidate
+
+import (
+	"context"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/premis"
+)
+
+// cachedValidator is a Validator returning the version and PREMIS agent found
+// when it was created, instead of running the validator version command each
+// time.
+type cachedValidator struct {
+	Validator
+	version    string
+	versionErr error
+	agent      premis.Agent
+}
+
+var _ Validator = (*cachedValidator)(nil)
+
+// versionAgent is implemented by the validators naming their version in their
+// PREMIS agent, so the agent can be built from a version found before instead
+// of running the version command again.
+type versionAgent interface {
+	agentFor(version string, versionErr error) premis.Agent
+}
+
+var (
+	_ versionAgent = (*jhoveValidator)(nil)
+	_ versionAgent = (*toolValidator)(nil)
+	_ versionAgent = (*veraPDFValidator)(nil)
+)
+
+// NewCachedValidator returns a Validator that discovers the version and the
+// PREMIS agent of v once, and returns them on every call afterwards. The
+// version is only asked once, the agent of a validator naming its version is
+// built from it.
+func NewCachedValidator(ctx context.Context, v Validator) *cachedValidator {
+	version, err := v.Version(ctx)
+
+	var agent premis.Agent
+	if va, ok := v.(versionAgent); ok {
+		agent = va.agentFor(version, err)
+	} else {
+		agent = v.PREMISAgent(ctx)
+	}
+
+	return &cachedValidator{
+		Validator:  v,
+		version:    version,
+		versionErr: err,
+		agent:      agent,
+	}
+}
+
+func (v *cachedValidator) PREMISAgent(context.Context) premis.Agent {
+	return v.agent
+}
+
+func (v *cachedValidator) Version(context.Context) (string, error) {
+	return v.version, v.versionErr
+}
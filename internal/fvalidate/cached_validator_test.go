@@ -0,0 +1,74 @@
+package fvalidate_test
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/mock/gomock"
+	"gotest.tools/v3/assert"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/fvalidate"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/fvalidate/fake"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/premis"
+)
+
+func TestCachedValidator(t *testing.T) {
+	t.Parallel()
+
+	agent := premis.Agent{
+		Type:    "software",
+		Name:    "veraPDF 1.24.1",
+		IdType:  "url",
+		IdValue: "https://verapdf.org",
+	}
+
+	t.Run("Returns the version and agent found on creation", func(t *testing.T) {
+		t.Parallel()
+
+		mockVdr := fake.NewMockValidator(gomock.NewController(t))
+		mockVdr.EXPECT().Version(gomock.Any()).Return("1.24.1", nil).Times(1)
+		mockVdr.EXPECT().PREMISAgent(gomock.Any()).Return(agent).Times(1)
+		mockVdr.EXPECT().Name().Return("veraPDF")
+
+		v := fvalidate.NewCachedValidator(t.Context(), mockVdr)
+		for range 2 {
+			version, err := v.Version(t.Context())
+			assert.NilError(t, err)
+			assert.Equal(t, version, "1.24.1")
+			assert.DeepEqual(t, v.PREMISAgent(t.Context()), agent)
+		}
+		assert.Equal(t, v.Name(), "veraPDF")
+	})
+
+	t.Run("Returns the version error found on creation", func(t *testing.T) {
+		t.Parallel()
+
+		mockVdr := fake.NewMockValidator(gomock.NewController(t))
+		mockVdr.EXPECT().Version(gomock.Any()).Return("", errors.New("command not found")).Times(1)
+		mockVdr.EXPECT().PREMISAgent(gomock.Any()).Return(agent).Times(1)
+
+		v := fvalidate.NewCachedValidator(t.Context(), mockVdr)
+		for range 2 {
+			_, err := v.Version(t.Context())
+			assert.Error(t, err, "command not found")
+		}
+	})
+	t.Run("Runs the version command of a tool once", func(t *testing.T) {
+		t.Parallel()
+
+		calls := filepath.Join(t.TempDir(), "calls")
+		tool, err := fvalidate.NewToolValidator(toolConfig(fvalidate.ToolConfig{
+			VersionCommand: []string{"/bin/sh", "-c", "echo call >> " + calls + "; echo 24.06"},
+		}))
+		assert.NilError(t, err)
+
+		v := fvalidate.NewCachedValidator(t.Context(), tool)
+		assert.Equal(t, v.PREMISAgent(t.Context()).Name, "mediaconch 24.06")
+
+		b, err := os.ReadFile(calls)
+		assert.NilError(t, err)
+		assert.Equal(t, string(b), "call\n")
+	})
+}
@@ -0,0 +1,139 @@
+package fvalidate
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// commandWaitDelay is how long a killed command waits for its child processes
+// to close the command output before returning.
+const commandWaitDelay = 5 * time.Second
+
+// maxOutputSize is the maximum number of bytes of STDOUT and STDERR kept from a
+// validator command, the rest of the output is discarded.
+const maxOutputSize = 32 << 20
+
+// command returns a Cmd running name with args, that is killed with all its
+// child processes (e.g. the JVM started by the veraPDF and JHOVE scripts) when
+// ctx is done.
+func command(ctx context.Context, name string, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, name, args...) // #nosec: G204 -- trusted command.
+	killProcessGroup(cmd)
+	cmd.WaitDelay = commandWaitDelay
+
+	return cmd
+}
+
+// outputBuffer keeps the first limit bytes written to it and discards the
+// rest, so a command writing a lot of output can't exhaust the memory.
+type outputBuffer struct {
+	buf       bytes.Buffer
+	limit     int
+	truncated bool
+}
+
+func newOutputBuffer(limit int) *outputBuffer {
+	return &outputBuffer{limit: limit}
+}
+
+// Write never fails, so the command isn't stopped when its output is
+// truncated.
+func (b *outputBuffer) Write(p []byte) (int, error) {
+	n := len(p)
+	if free := b.limit - b.buf.Len(); n > free {
+		p = p[:max(free, 0)]
+		b.truncated = true
+	}
+	b.buf.Write(p)
+
+	return n, nil
+}
+
+func (b *outputBuffer) String() string {
+	return b.buf.String()
+}
+
+// truncatedNote returns a note saying that the output of name was truncated,
+// or an empty string if it wasn't.
+func (b *outputBuffer) truncatedNote(name string) string {
+	if !b.truncated {
+		return ""
+	}
+
+	return fmt.Sprintf("%s output truncated after %d bytes", name, b.limit)
+}
+
+// output runs c and returns its STDOUT like c.Output, keeping at most
+// maxOutputSize bytes of STDOUT and STDERR. The STDERR of an *exec.ExitError is
+// followed by a note when it was truncated.
+func output(c *exec.Cmd, name string) (*outputBuffer, error) {
+	stdout, stderr := newOutputBuffer(maxOutputSize), newOutputBuffer(maxOutputSize)
+	c.Stdout = stdout
+	c.Stderr = stderr
+
+	err := c.Run()
+	var e *exec.ExitError
+	if errors.As(err, &e) {
+		e.Stderr = []byte(withNote(stderr.String(), stderr.truncatedNote(name)))
+	}
+
+	return stdout, err
+}
+
+// withNote returns s without leading and trailing white space, followed by
+// note on a new line if note is not empty.
+func withNote(s, note string) string {
+	s = strings.TrimSpace(s)
+	if note == "" {
+		return s
+	}
+	if s == "" {
+		return note
+	}
+
+	return s + "\n" + note
+}
+
+// errorWithNote returns err followed by note, or err if note is empty.
+func errorWithNote(err error, note string) error {
+	if note == "" {
+		return err
+	}
+
+	return fmt.Errorf("%w (%s)", err, note)
+}
+
+// withTimeout returns a copy of ctx that is cancelled with an ErrTimeout cause
+// after timeout, or ctx if timeout is not positive.
+func withTimeout(ctx context.Context, timeout time.Duration) (context.Context, context.CancelFunc) {
+	if timeout <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeoutCause(ctx, timeout, ErrTimeout)
+}
+
+// contextError returns the error of a validator command stopped because ctx
+// is done, or nil if ctx is not done. A timeout of the validator is returned
+// as a system error, and any other cancellation as the ctx error.
+func contextError(ctx context.Context, validator string, timeout time.Duration) error {
+	if ctx.Err() == nil {
+		return nil
+	}
+
+	if errors.Is(context.Cause(ctx), ErrTimeout) {
+		return NewSystemError(
+			validator,
+			-1,
+			fmt.Errorf("%w after %s", ErrTimeout, timeout),
+			fmt.Sprintf("File format validation timed out, %s didn't finish within %s", validator, timeout),
+		)
+	}
+
+	return ctx.Err()
+}
@@ -0,0 +1,9 @@
+//go:build !unix
+
+package fvalidate
+
+import "os/exec"
+
+// killProcessGroup is a no-op, only the cmd process is killed when cmd is
+// cancelled.
+func killProcessGroup(cmd *exec.Cmd) {}
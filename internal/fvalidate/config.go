@@ -1,9 +1,160 @@
 package fvalidate
 
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+)
+
 type Config struct {
 	VeraPDF VeraPDFConfig
+	JHOVE   JHOVEConfig
+
+	// Tools configures additional external validation tools, each run by a
+	// generic validator (optional).
+	Tools []ToolConfig
+
+	// Builtin configures the validators that need no external tool.
+	Builtin BuiltinConfig
+
+	// Concurrency is the number of files validated concurrently by the
+	// validators that take one file at a time (optional, defaults to 4).
+	Concurrency int
+}
+
+func (c Config) Validate() error {
+	var errs error
+
+	var names []string
+	for i, t := range c.Tools {
+		if err := t.Validate(); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("Tools[%d]: %v", i, err))
+		}
+		if t.Name != "" && slices.Contains(names, t.Name) {
+			errs = errors.Join(errs, fmt.Errorf("Tools[%d]: Name: duplicate value %q", i, t.Name))
+		}
+		names = append(names, t.Name)
+	}
+
+	return errs
 }
 
 type VeraPDFConfig struct {
 	Path string
+
+	// Timeout is the maximum duration of a veraPDF run (optional). veraPDF
+	// is only stopped when the activity is cancelled when zero.
+	Timeout time.Duration
+}
+
+type JHOVEConfig struct {
+	// Path of the JHOVE command, JHOVE validation is skipped when empty.
+	Path string
+
+	// Timeout is the maximum duration of the JHOVE validation of a file
+	// (optional). JHOVE is only stopped when the activity is cancelled when
+	// zero.
+	Timeout time.Duration
+}
+
+type BuiltinConfig struct {
+	// Enabled runs the built-in validators on the formats not validated by
+	// veraPDF, JHOVE or a configured tool (default: true).
+	Enabled bool
+}
+
+// ToolConfig configures an external validation tool.
+type ToolConfig struct {
+	// Name of the tool, as shown in the validation report (required).
+	Name string
+
+	// Command is the command run to validate a path, as the executable
+	// followed by its arguments (required). The "{path}" placeholder is
+	// replaced by the validated path in each argument, the path is added as
+	// the last argument when there is no placeholder.
+	Command []string
+
+	// PUIDs are the PRONOM IDs of the formats validated by the tool
+	// (required).
+	PUIDs []string
+
+	// Scope is "file" to run the tool on each file with one of the PUIDs, or
+	// "dir" to run it once on the SIP content directory (default: "file").
+	Scope string
+
+	// ValidExitCodes are the exit codes of the tool when the validated files
+	// are valid (default: [0]).
+	ValidExitCodes []int
+
+	// InvalidExitCodes are the exit codes of the tool when a validated file is
+	// invalid (default: [1]). The output of the tool is reported as the
+	// validation failures. Any other exit code is a system error.
+	InvalidExitCodes []int
+
+	// AgentURL is the URL identifying the tool in PREMIS agents (required).
+	AgentURL string
+
+	// VersionCommand is the command that prints the tool version on the first
+	// line of its output (optional).
+	VersionCommand []string
+
+	// Timeout is the maximum duration of a tool run (optional). The tool is
+	// only stopped when the activity is cancelled when zero.
+	Timeout time.Duration
+}
+
+func (c ToolConfig) Validate() error {
+	var errs error
+
+	if c.Name == "" {
+		errs = errors.Join(errs, errors.New("Name: missing required value"))
+	}
+	if len(c.Command) == 0 || c.Command[0] == "" {
+		errs = errors.Join(errs, errors.New("Command: missing required value"))
+	}
+	if len(c.PUIDs) == 0 {
+		errs = errors.Join(errs, errors.New("PUIDs: missing required value"))
+	}
+	if _, err := parseScope(c.Scope); err != nil {
+		errs = errors.Join(errs, fmt.Errorf("Scope: %v", err))
+	}
+	for _, code := range c.validExitCodes() {
+		if slices.Contains(c.invalidExitCodes(), code) {
+			errs = errors.Join(errs, fmt.Errorf("InvalidExitCodes: %d is also a valid exit code", code))
+		}
+	}
+	if c.AgentURL == "" {
+		errs = errors.Join(errs, errors.New("AgentURL: missing required value"))
+	}
+
+	return errs
+}
+
+func (c ToolConfig) validExitCodes() []int {
+	if len(c.ValidExitCodes) == 0 {
+		return []int{0}
+	}
+
+	return c.ValidExitCodes
+}
+
+func (c ToolConfig) invalidExitCodes() []int {
+	if len(c.InvalidExitCodes) == 0 {
+		return []int{1}
+	}
+
+	return c.InvalidExitCodes
+}
+
+// parseScope returns the target type of a tool scope.
+func parseScope(s string) (TargetType, error) {
+	switch s {
+	case "", "file":
+		return TargetTypeFile, nil
+	case "dir":
+		return TargetTypeDir, nil
+	default:
+		return 0, fmt.Errorf("invalid value %q, must be one of (file, dir)", s)
+	}
 }
@@ -1,6 +1,13 @@
 package fvalidate
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrTimeout is the error wrapped by the system error of a validator that
+// didn't finish within its timeout.
+var ErrTimeout = errors.New("validator timed out")
 
 type SystemError struct {
 	validator string
@@ -29,6 +36,10 @@ func (e *SystemError) Validator() string {
 }
 
 func (e *SystemError) Error() string {
+	if errors.Is(e.err, ErrTimeout) {
+		return fmt.Sprintf("system error: %s", e.err)
+	}
+
 	return fmt.Sprintf("system error: exit code %d: %s", e.exitCode, e.err)
 }
 
@@ -10,6 +10,7 @@
 package fake
 
 import (
+	context "context"
 	reflect "reflect"
 
 	fvalidate "github.com/artefactual-sdps/preprocessing-sfa/internal/fvalidate"
@@ -118,17 +119,17 @@ func (c *MockValidatorNameCall) DoAndReturn(f func() string) *MockValidatorNameC
 }
 
 // PREMISAgent mocks base method.
-func (m *MockValidator) PREMISAgent() premis.Agent {
+func (m *MockValidator) PREMISAgent(ctx context.Context) premis.Agent {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "PREMISAgent")
+	ret := m.ctrl.Call(m, "PREMISAgent", ctx)
 	ret0, _ := ret[0].(premis.Agent)
 	return ret0
 }
 
 // PREMISAgent indicates an expected call of PREMISAgent.
-func (mr *MockValidatorMockRecorder) PREMISAgent() *MockValidatorPREMISAgentCall {
+func (mr *MockValidatorMockRecorder) PREMISAgent(ctx any) *MockValidatorPREMISAgentCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PREMISAgent", reflect.TypeOf((*MockValidator)(nil).PREMISAgent))
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PREMISAgent", reflect.TypeOf((*MockValidator)(nil).PREMISAgent), ctx)
 	return &MockValidatorPREMISAgentCall{Call: call}
 }
 
@@ -144,13 +145,13 @@ func (c *MockValidatorPREMISAgentCall) Return(arg0 premis.Agent) *MockValidatorP
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockValidatorPREMISAgentCall) Do(f func() premis.Agent) *MockValidatorPREMISAgentCall {
+func (c *MockValidatorPREMISAgentCall) Do(f func(context.Context) premis.Agent) *MockValidatorPREMISAgentCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockValidatorPREMISAgentCall) DoAndReturn(f func() premis.Agent) *MockValidatorPREMISAgentCall {
+func (c *MockValidatorPREMISAgentCall) DoAndReturn(f func(context.Context) premis.Agent) *MockValidatorPREMISAgentCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
@@ -194,18 +195,18 @@ func (c *MockValidatorScopeCall) DoAndReturn(f func() fvalidate.TargetType) *Moc
 }
 
 // Validate mocks base method.
-func (m *MockValidator) Validate(path string) (string, error) {
+func (m *MockValidator) Validate(ctx context.Context, path string) ([]fvalidate.FileResult, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Validate", path)
-	ret0, _ := ret[0].(string)
+	ret := m.ctrl.Call(m, "Validate", ctx, path)
+	ret0, _ := ret[0].([]fvalidate.FileResult)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Validate indicates an expected call of Validate.
-func (mr *MockValidatorMockRecorder) Validate(path any) *MockValidatorValidateCall {
+func (mr *MockValidatorMockRecorder) Validate(ctx, path any) *MockValidatorValidateCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Validate", reflect.TypeOf((*MockValidator)(nil).Validate), path)
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Validate", reflect.TypeOf((*MockValidator)(nil).Validate), ctx, path)
 	return &MockValidatorValidateCall{Call: call}
 }
 
@@ -215,36 +216,36 @@ type MockValidatorValidateCall struct {
 }
 
 // Return rewrite *gomock.Call.Return
-func (c *MockValidatorValidateCall) Return(arg0 string, arg1 error) *MockValidatorValidateCall {
+func (c *MockValidatorValidateCall) Return(arg0 []fvalidate.FileResult, arg1 error) *MockValidatorValidateCall {
 	c.Call = c.Call.Return(arg0, arg1)
 	return c
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockValidatorValidateCall) Do(f func(string) (string, error)) *MockValidatorValidateCall {
+func (c *MockValidatorValidateCall) Do(f func(context.Context, string) ([]fvalidate.FileResult, error)) *MockValidatorValidateCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockValidatorValidateCall) DoAndReturn(f func(string) (string, error)) *MockValidatorValidateCall {
+func (c *MockValidatorValidateCall) DoAndReturn(f func(context.Context, string) ([]fvalidate.FileResult, error)) *MockValidatorValidateCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
 
 // Version mocks base method.
-func (m *MockValidator) Version() (string, error) {
+func (m *MockValidator) Version(ctx context.Context) (string, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Version")
+	ret := m.ctrl.Call(m, "Version", ctx)
 	ret0, _ := ret[0].(string)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // Version indicates an expected call of Version.
-func (mr *MockValidatorMockRecorder) Version() *MockValidatorVersionCall {
+func (mr *MockValidatorMockRecorder) Version(ctx any) *MockValidatorVersionCall {
 	mr.mock.ctrl.T.Helper()
-	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Version", reflect.TypeOf((*MockValidator)(nil).Version))
+	call := mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Version", reflect.TypeOf((*MockValidator)(nil).Version), ctx)
 	return &MockValidatorVersionCall{Call: call}
 }
 
@@ -260,13 +261,13 @@ func (c *MockValidatorVersionCall) Return(arg0 string, arg1 error) *MockValidato
 }
 
 // Do rewrite *gomock.Call.Do
-func (c *MockValidatorVersionCall) Do(f func() (string, error)) *MockValidatorVersionCall {
+func (c *MockValidatorVersionCall) Do(f func(context.Context) (string, error)) *MockValidatorVersionCall {
 	c.Call = c.Call.Do(f)
 	return c
 }
 
 // DoAndReturn rewrite *gomock.Call.DoAndReturn
-func (c *MockValidatorVersionCall) DoAndReturn(f func() (string, error)) *MockValidatorVersionCall {
+func (c *MockValidatorVersionCall) DoAndReturn(f func(context.Context) (string, error)) *MockValidatorVersionCall {
 	c.Call = c.Call.DoAndReturn(f)
 	return c
 }
@@ -1,6 +1,10 @@
 package fvalidate
 
-import "github.com/artefactual-sdps/preprocessing-sfa/internal/premis"
+import (
+	"context"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/premis"
+)
 
 type TargetType int
 
@@ -17,17 +21,28 @@ type Validator interface {
 	// Name of the validator.
 	Name() string
 
-	// Validate validates the file or directory at path.
 	// PREMISAgent returns a PREMIS agent representing the validator.
-	PREMISAgent() premis.Agent
+	PREMISAgent(ctx context.Context) premis.Agent
 
 	// Scope of the validator, whether it targets an individual file or all the
 	// files in a directory.
 	Scope() TargetType
 
-	// Validate validates the file or directory at path.
-	Validate(path string) (string, error)
+	// Validate validates the file or directory at path, and returns the
+	// results of the files that don't comply with their format specification.
+	// External validation tools are killed when ctx is done.
+	Validate(ctx context.Context, path string) ([]FileResult, error)
 
 	// Returns the version of a validator.
-	Version() (string, error)
+	Version(ctx context.Context) (string, error)
+}
+
+// FileResult is the validation result of a single file.
+type FileResult struct {
+	// Path of the validated file.
+	Path string
+
+	// Failures describe how the file doesn't comply with its format
+	// specification (e.g. the specification rules it violates).
+	Failures []string
 }
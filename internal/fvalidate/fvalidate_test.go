@@ -0,0 +1,24 @@
+package fvalidate_test
+
+import (
+	"fmt"
+	"testing"
+
+	"gotest.tools/v3/fs"
+)
+
+// fakeCommand returns the path of a shell script that writes output to
+// STDOUT and exits with exitCode, to run in place of a validation tool.
+func fakeCommand(t *testing.T, output string, exitCode int) string {
+	t.Helper()
+
+	dir := fs.NewDir(t, "",
+		fs.WithFile(
+			"cmd",
+			fmt.Sprintf("#!/bin/sh\ncat <<'EOF'\n%s\nEOF\nexit %d\n", output, exitCode),
+			fs.WithMode(0o700),
+		),
+	)
+
+	return dir.Join("cmd")
+}
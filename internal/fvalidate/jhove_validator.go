@@ -0,0 +1,242 @@
+package fvalidate
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/fsutil"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/premis"
+)
+
+// jhoveModules maps the JHOVE modules used for validation to the
+// https://www.nationalarchives.gov.uk/pronom/ IDs of the formats they
+// validate.
+var jhoveModules = map[string][]string{
+	"JPEG2000-hul": {
+		"x-fmt/392", // JP2 (JPEG 2000 part 1)
+		"fmt/151",   // JPX (JPEG 2000 part 2)
+	},
+	"TIFF-hul": {
+		"fmt/353", // Tagged Image File Format
+		"fmt/7",   // TIFF 3
+		"fmt/8",   // TIFF 4
+		"fmt/9",   // TIFF 5
+		"fmt/10",  // TIFF 6
+	},
+	"JPEG-hul": {
+		"fmt/41",    // Raw JPEG Stream
+		"fmt/42",    // JFIF 1.00
+		"fmt/43",    // JFIF 1.01
+		"fmt/44",    // JFIF 1.02
+		"x-fmt/398", // Exif Compressed Image 2.0
+		"x-fmt/390", // Exif Compressed Image 2.1
+		"x-fmt/391", // Exif Compressed Image 2.2
+	},
+	"WAVE-hul": {
+		"fmt/1",   // Broadcast WAVE 0
+		"fmt/2",   // Broadcast WAVE 1
+		"fmt/6",   // Waveform Audio
+		"fmt/141", // Waveform Audio (PCMWAVEFORMAT)
+		"fmt/142", // Waveform Audio (WAVEFORMATEX)
+		"fmt/143", // Waveform Audio (WAVEFORMATEXTENSIBLE)
+	},
+	"XML-hul": {
+		"fmt/101", // Extensible Markup Language 1.0
+	},
+}
+
+// jhoveValidStatus is the status reported by JHOVE for files that are
+// well-formed and valid.
+const jhoveValidStatus = "Well-Formed and valid"
+
+type jhoveValidator struct {
+	cmd     string
+	timeout time.Duration
+	scope   TargetType
+}
+
+var _ Validator = (*jhoveValidator)(nil)
+
+// NewJHOVEValidator returns a validator running the JHOVE cmd, which is
+// killed if the validation of a file takes longer than timeout (if positive).
+func NewJHOVEValidator(cmd string, timeout time.Duration) *jhoveValidator {
+	return &jhoveValidator{cmd: cmd, timeout: timeout, scope: TargetTypeFile}
+}
+
+func (v *jhoveValidator) FormatIDs() []string {
+	var ids []string
+	for _, module := range slices.Sorted(maps.Keys(jhoveModules)) {
+		ids = append(ids, jhoveModules[module]...)
+	}
+
+	return ids
+}
+
+func (v *jhoveValidator) Name() string {
+	return "JHOVE"
+}
+
+func (v *jhoveValidator) PREMISAgent(ctx context.Context) premis.Agent {
+	return v.agentFor(v.Version(ctx))
+}
+
+func (v *jhoveValidator) agentFor(name string, err error) premis.Agent {
+	if err != nil || name == "" {
+		name = fmt.Sprintf("%s (version unknown)", v.Name())
+	}
+
+	return premis.Agent{
+		Type:    "software",
+		Name:    name,
+		IdType:  "url",
+		IdValue: "https://jhove.openpreservation.org",
+	}
+}
+
+func (v *jhoveValidator) Scope() TargetType {
+	return v.scope
+}
+
+// Validate validates the file at path with the JHOVE module that recognizes
+// it. The file is invalid if JHOVE doesn't report it as well-formed and valid,
+// or if it's only recognized by a module that doesn't validate its format
+// (e.g. the BYTESTREAM module).
+func (v *jhoveValidator) Validate(ctx context.Context, path string) ([]FileResult, error) {
+	// If the JHOVE cmd path is not set then skip validation.
+	if v.cmd == "" {
+		return nil, nil
+	}
+
+	if !fsutil.FileExists(path) {
+		return nil, fmt.Errorf("validate: file not found: %s", path)
+	}
+
+	ctx, cancel := withTimeout(ctx, v.timeout)
+	defer cancel()
+
+	output, err := v.run(ctx, "-h", "xml", path)
+	if err != nil {
+		if err := contextError(ctx, v.Name(), v.timeout); err != nil {
+			return nil, err
+		}
+
+		var e *exec.ExitError
+		if !errors.As(err, &e) {
+			return nil, err
+		}
+
+		return nil, NewSystemError(
+			v.Name(),
+			e.ExitCode(),
+			errors.New(string(e.Stderr)),
+			"File format validation failed with a JHOVE application error",
+		)
+	}
+
+	failures, err := parseJHOVEReport(strings.NewReader(output.String()))
+	if err != nil {
+		return nil, NewSystemError(
+			v.Name(),
+			0,
+			errorWithNote(err, output.truncatedNote(v.Name())),
+			"File format validation failed with a JHOVE application error",
+		)
+	}
+	if len(failures) == 0 {
+		return nil, nil
+	}
+
+	return []FileResult{{Path: path, Failures: failures}}, nil
+}
+
+func (v *jhoveValidator) Version(ctx context.Context) (string, error) {
+	// If the JHOVE cmd path is not set then skip returning the version.
+	if v.cmd == "" {
+		return "", nil
+	}
+
+	ctx, cancel := withTimeout(ctx, v.timeout)
+	defer cancel()
+
+	output, err := v.run(ctx, "-v")
+	if err != nil {
+		if err := contextError(ctx, v.Name(), v.timeout); err != nil {
+			return "", err
+		}
+		return "", err
+	}
+
+	lines := strings.Split(output.String(), "\n")
+
+	return lines[0], nil
+}
+
+func (v *jhoveValidator) run(ctx context.Context, args ...string) (*outputBuffer, error) {
+	return output(command(ctx, v.cmd, args...), v.Name())
+}
+
+// jhoveReport is the output of the JHOVE XML handler, only the elements
+// needed to find the file status are decoded.
+type jhoveReport struct {
+	RepInfo *jhoveRepInfo `xml:"repInfo"`
+}
+
+type jhoveRepInfo struct {
+	ReportingModule string         `xml:"reportingModule"`
+	Format          string         `xml:"format"`
+	Status          string         `xml:"status"`
+	Messages        []jhoveMessage `xml:"messages>message"`
+}
+
+type jhoveMessage struct {
+	ID       string `xml:"id,attr"`
+	Severity string `xml:"severity,attr"`
+	Text     string `xml:",chardata"`
+}
+
+// parseJHOVEReport reads the JHOVE XML handler output for a single file from
+// r, and returns the failures found if the file is not well-formed and valid.
+func parseJHOVEReport(r io.Reader) ([]string, error) {
+	var rep jhoveReport
+	if err := xml.NewDecoder(r).Decode(&rep); err != nil {
+		return nil, fmt.Errorf("parse JHOVE report: %v", err)
+	}
+	if rep.RepInfo == nil {
+		return nil, errors.New("parse JHOVE report: missing repInfo element")
+	}
+
+	info := rep.RepInfo
+	if _, ok := jhoveModules[info.ReportingModule]; !ok {
+		return []string{fmt.Sprintf(
+			"Not recognized as a well-formed file by the JHOVE format modules (reported as %q by %s)",
+			info.Format,
+			info.ReportingModule,
+		)}, nil
+	}
+	if info.Status == jhoveValidStatus {
+		return nil, nil
+	}
+
+	failures := []string{fmt.Sprintf("%s: %s", info.ReportingModule, info.Status)}
+	for _, m := range info.Messages {
+		if m.Severity == "info" {
+			continue
+		}
+
+		msg := strings.Join(strings.Fields(m.Text), " ")
+		if m.ID != "" {
+			msg = fmt.Sprintf("%s: %s", m.ID, msg)
+		}
+		failures = append(failures, msg)
+	}
+
+	return failures, nil
+}
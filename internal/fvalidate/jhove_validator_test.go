@@ -0,0 +1,180 @@
+package fvalidate_test
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/fvalidate"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/premis"
+)
+
+const jhoveReportFmt = `<?xml version="1.0" encoding="UTF-8"?>
+<jhove xmlns="http://schema.openpreservation.org/ois/xml/ns/jhove" name="Jhove" release="1.28.0" date="2023-05-18">
+  <date>2024-06-06T09:57:16+00:00</date>
+  <repInfo uri="/sip/content/d_0000001/00000001.jp2">
+    <reportingModule release="1.4.4" date="2023-03-16">%s</reportingModule>
+    <format>%s</format>
+    <status>%s</status>
+    <messages>
+      <message severity="info" id="JPEG2000-HUL-14">Codestream parsed</message>
+      <message offset="1024" severity="error" id="JPEG2000-HUL-3">
+        Invalid codestream: tile-part length mismatch
+      </message>
+      <message severity="error">No JP2 header box</message>
+    </messages>
+  </repInfo>
+</jhove>`
+
+func TestJHOVEFormatIDs(t *testing.T) {
+	t.Parallel()
+
+	got := fvalidate.NewJHOVEValidator("", 0).FormatIDs()
+	for _, id := range []string{"x-fmt/392", "fmt/353", "fmt/43", "fmt/141", "fmt/101"} {
+		assert.Assert(t, slices.Contains(got, id), "missing format ID %s", id)
+	}
+	assert.Assert(t, !slices.Contains(got, "fmt/354"))
+}
+
+func TestJHOVEName(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, fvalidate.NewJHOVEValidator("", 0).Name(), "JHOVE")
+}
+
+func TestJHOVEScope(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, fvalidate.NewJHOVEValidator("", 0).Scope(), fvalidate.TargetTypeFile)
+}
+
+func TestJHOVEValidate(t *testing.T) {
+	t.Parallel()
+
+	type test struct {
+		name    string
+		cmd     func(t *testing.T) string
+		want    func(path string) []fvalidate.FileResult
+		wantErr string
+	}
+	for _, tt := range []test{
+		{
+			name: "Does nothing when cmd is not set",
+		},
+		{
+			name: "Returns nothing when the file is well-formed and valid",
+			cmd: func(t *testing.T) string {
+				return fakeCommand(t, fmt.Sprintf(jhoveReportFmt, "JPEG2000-hul", "JPEG 2000", "Well-Formed and valid"), 0)
+			},
+		},
+		{
+			name: "Returns the failures of an invalid file",
+			cmd: func(t *testing.T) string {
+				return fakeCommand(t, fmt.Sprintf(jhoveReportFmt, "JPEG2000-hul", "JPEG 2000", "Not well-formed"), 0)
+			},
+			want: func(path string) []fvalidate.FileResult {
+				return []fvalidate.FileResult{
+					{
+						Path: path,
+						Failures: []string{
+							"JPEG2000-hul: Not well-formed",
+							"JPEG2000-HUL-3: Invalid codestream: tile-part length mismatch",
+							"No JP2 header box",
+						},
+					},
+				}
+			},
+		},
+		{
+			name: "Returns a failure when no format module recognizes the file",
+			cmd: func(t *testing.T) string {
+				return fakeCommand(t, fmt.Sprintf(jhoveReportFmt, "BYTESTREAM", "bytestream", "Well-Formed and valid"), 0)
+			},
+			want: func(path string) []fvalidate.FileResult {
+				return []fvalidate.FileResult{
+					{
+						Path: path,
+						Failures: []string{
+							`Not recognized as a well-formed file by the JHOVE format modules (reported as "bytestream" by BYTESTREAM)`,
+						},
+					},
+				}
+			},
+		},
+		{
+			name:    "Errors when JHOVE fails",
+			cmd:     func(t *testing.T) string { return fakeCommand(t, "", 1) },
+			wantErr: "system error: exit code 1: ",
+		},
+		{
+			name:    "Errors when the report can't be parsed",
+			cmd:     func(t *testing.T) string { return fakeCommand(t, "<jhove></jhove>", 0) },
+			wantErr: "system error: exit code 0: parse JHOVE report: missing repInfo element",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			cmd := ""
+			if tt.cmd != nil {
+				cmd = tt.cmd(t)
+			}
+
+			dir := fs.NewDir(t, "", fs.WithFile("00000001.jp2", "jp2"))
+			path := dir.Join("00000001.jp2")
+
+			got, err := fvalidate.NewJHOVEValidator(cmd, 0).Validate(t.Context(), path)
+			if tt.wantErr != "" {
+				assert.Error(t, err, tt.wantErr)
+				return
+			}
+
+			assert.NilError(t, err)
+			if tt.want != nil {
+				assert.DeepEqual(t, got, tt.want(path))
+			} else {
+				assert.Assert(t, got == nil)
+			}
+		})
+	}
+}
+
+func TestJHOVEValidateMissingFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "missing.jp2")
+	_, err := fvalidate.NewJHOVEValidator("jhove", 0).Validate(t.Context(), path)
+	assert.Error(t, err, fmt.Sprintf("validate: file not found: %s", path))
+}
+
+func TestJHOVEPREMISAgent(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Uses the JHOVE version as agent name", func(t *testing.T) {
+		t.Parallel()
+
+		v := fvalidate.NewJHOVEValidator(fakeCommand(t, "Jhove (Rel. 1.28.0, 2023-05-18)", 0), 0)
+		assert.DeepEqual(t, v.PREMISAgent(t.Context()), premis.Agent{
+			Type:    "software",
+			Name:    "Jhove (Rel. 1.28.0, 2023-05-18)",
+			IdType:  "url",
+			IdValue: "https://jhove.openpreservation.org",
+		})
+	})
+
+	t.Run("Notes an unknown version", func(t *testing.T) {
+		t.Parallel()
+
+		v := fvalidate.NewJHOVEValidator("", 0)
+		assert.DeepEqual(t, v.PREMISAgent(t.Context()), premis.Agent{
+			Type:    "software",
+			Name:    "JHOVE (version unknown)",
+			IdType:  "url",
+			IdValue: "https://jhove.openpreservation.org",
+		})
+	})
+}
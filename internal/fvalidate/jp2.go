@@ -0,0 +1,110 @@
+package fvalidate
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// jp2Signature is the content of the JPEG 2000 signature box.
+var jp2Signature = []byte{0x0d, 0x0a, 0x87, 0x0a}
+
+// jp2Box is a JPEG 2000 box header, with the position and length of the box
+// contents in the file.
+type jp2Box struct {
+	boxType string
+	offset  int64
+	length  int64
+}
+
+// readJP2Boxes reads the headers of the boxes found between offset start and
+// end of r.
+func readJP2Boxes(r io.ReaderAt, start, end int64) ([]jp2Box, error) {
+	var boxes []jp2Box
+	for pos := start; pos < end; {
+		var hdr [8]byte
+		if _, err := r.ReadAt(hdr[:], pos); err != nil {
+			return nil, fmt.Errorf("truncated box header at offset %d", pos)
+		}
+
+		boxType := string(hdr[4:])
+		headerLen := int64(8)
+		boxLen := int64(binary.BigEndian.Uint32(hdr[:4]))
+		switch boxLen {
+		case 0:
+			// The box extends to the end of the file.
+			boxLen = end - pos
+		case 1:
+			var xl [8]byte
+			if _, err := r.ReadAt(xl[:], pos+8); err != nil {
+				return nil, fmt.Errorf("truncated %q box header at offset %d", boxType, pos)
+			}
+			headerLen = 16
+			boxLen = int64(binary.BigEndian.Uint64(xl[:])) // #nosec G115 -- checked below.
+		}
+		if boxLen < headerLen || boxLen > end-pos {
+			return nil, fmt.Errorf("%q box at offset %d has an invalid length %d", boxType, pos, boxLen)
+		}
+
+		boxes = append(boxes, jp2Box{boxType: boxType, offset: pos + headerLen, length: boxLen - headerLen})
+		pos += boxLen
+	}
+
+	return boxes, nil
+}
+
+// checkJP2 checks that f starts with the JP2 signature and file type boxes,
+// and has a JP2 header box, starting with an image header box, before a
+// contiguous codestream box.
+func checkJP2(f *os.File) error {
+	fi, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("JP2 file can't be read: %v", err)
+	}
+
+	boxes, err := readJP2Boxes(f, 0, fi.Size())
+	if err != nil {
+		return fmt.Errorf("invalid JP2 box structure: %v", err)
+	}
+
+	if len(boxes) < 2 || boxes[0].boxType != "jP  " || boxes[1].boxType != "ftyp" {
+		return errors.New("invalid JP2 box structure: missing signature or file type box")
+	}
+	sig := make([]byte, len(jp2Signature))
+	if _, err := f.ReadAt(sig, boxes[0].offset); err != nil || !bytes.Equal(sig, jp2Signature) {
+		return errors.New("invalid JP2 box structure: invalid signature box")
+	}
+
+	var header bool
+	for _, b := range boxes[2:] {
+		switch b.boxType {
+		case "jp2h":
+			children, err := readJP2Boxes(f, b.offset, b.offset+b.length)
+			if err != nil {
+				return fmt.Errorf("invalid JP2 header box: %v", err)
+			}
+			if len(children) == 0 || children[0].boxType != "ihdr" {
+				return errors.New("invalid JP2 header box: missing image header box")
+			}
+			header = true
+		case "jp2c":
+			if !header {
+				return errors.New("invalid JP2 box structure: codestream box found before the header box")
+			}
+
+			// The codestream starts with the SOC and SIZ markers.
+			var markers [4]byte
+			if _, err := f.ReadAt(markers[:], b.offset); err != nil ||
+				!bytes.Equal(markers[:], []byte{0xff, 0x4f, 0xff, 0x51}) {
+				return errors.New("invalid JP2 codestream: missing start of codestream")
+			}
+
+			return nil
+		}
+	}
+
+	return errors.New("invalid JP2 box structure: missing codestream box")
+}
@@ -0,0 +1,99 @@
+package fvalidate
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// tiffTypeSizes are the sizes in bytes of the TIFF 6.0 field types, indexed
+// by type.
+var tiffTypeSizes = [...]int64{0, 1, 1, 2, 4, 8, 1, 1, 2, 4, 8, 4, 8}
+
+const (
+	tiffTagImageWidth  = 256
+	tiffTagImageLength = 257
+)
+
+// checkTIFF walks the image file directory (IFD) chain of f, checking that
+// every IFD, and the values referenced by its entries, are within the file,
+// and that the chain has no loops. The first IFD must have the image width
+// and length.
+func checkTIFF(f *os.File) error {
+	fi, err := f.Stat()
+	if err != nil {
+		return fmt.Errorf("TIFF file can't be read: %v", err)
+	}
+	size := fi.Size()
+
+	var hdr [8]byte
+	if _, err := f.ReadAt(hdr[:], 0); err != nil {
+		return errors.New("invalid TIFF: truncated header")
+	}
+
+	var order binary.ByteOrder
+	switch string(hdr[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return errors.New("invalid TIFF: invalid byte order")
+	}
+	if order.Uint16(hdr[2:4]) != 42 {
+		return errors.New("invalid TIFF: invalid version number")
+	}
+
+	offset := int64(order.Uint32(hdr[4:]))
+	if offset == 0 {
+		return errors.New("invalid TIFF: no image file directory")
+	}
+
+	visited := make(map[int64]bool)
+	for n := 0; offset != 0; n++ {
+		if visited[offset] {
+			return fmt.Errorf("invalid TIFF: IFD %d at offset %d loops back to a previous IFD", n, offset)
+		}
+		visited[offset] = true
+
+		var count [2]byte
+		if offset < 8 || offset+2 > size {
+			return fmt.Errorf("invalid TIFF: IFD %d offset %d is outside the file", n, offset)
+		}
+		if _, err := f.ReadAt(count[:], offset); err != nil {
+			return fmt.Errorf("invalid TIFF: IFD %d can't be read: %v", n, err)
+		}
+
+		entries := int64(order.Uint16(count[:]))
+		ifd := make([]byte, entries*12+4)
+		if _, err := f.ReadAt(ifd, offset+2); err != nil {
+			return fmt.Errorf("invalid TIFF: IFD %d at offset %d is truncated", n, offset)
+		}
+
+		tags := make(map[uint16]bool)
+		for i := range entries {
+			e := ifd[i*12 : i*12+12]
+			tag, typ, cnt := order.Uint16(e), order.Uint16(e[2:]), int64(order.Uint32(e[4:]))
+			tags[tag] = true
+
+			// Unknown types are skipped, as required by the specification.
+			if int(typ) >= len(tiffTypeSizes) || typ == 0 {
+				continue
+			}
+			if l := cnt * tiffTypeSizes[typ]; l > 4 {
+				if v := int64(order.Uint32(e[8:])); v+l > size {
+					return fmt.Errorf("invalid TIFF: tag %d value in IFD %d is outside the file", tag, n)
+				}
+			}
+		}
+
+		if n == 0 && (!tags[tiffTagImageWidth] || !tags[tiffTagImageLength]) {
+			return errors.New("invalid TIFF: first IFD has no image width or length")
+		}
+
+		offset = int64(order.Uint32(ifd[entries*12:]))
+	}
+
+	return nil
+}
@@ -0,0 +1,178 @@
+package fvalidate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"slices"
+	"strings"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/fsutil"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/premis"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/toolinfo"
+)
+
+// toolPathPlaceholder is replaced by the validated path in the arguments of a
+// tool command.
+const toolPathPlaceholder = "{path}"
+
+// maxToolFailures is the maximum number of output lines of a tool reported as
+// the failures of an invalid file.
+const maxToolFailures = 20
+
+// maxToolOutputSize is the maximum number of bytes of STDOUT and STDERR kept
+// from a tool validating a path, as only the first output lines are reported.
+const maxToolOutputSize = 1 << 20
+
+// toolValidator runs an external validation tool configured by a ToolConfig.
+type toolValidator struct {
+	cfg   ToolConfig
+	scope TargetType
+}
+
+var _ Validator = (*toolValidator)(nil)
+
+// NewToolValidator returns a validator running the external tool configured
+// by cfg, or an error if cfg is not valid.
+func NewToolValidator(cfg ToolConfig) (*toolValidator, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid %q tool configuration: %v", cfg.Name, err)
+	}
+
+	scope, _ := parseScope(cfg.Scope)
+
+	return &toolValidator{cfg: cfg, scope: scope}, nil
+}
+
+func (v *toolValidator) FormatIDs() []string {
+	return v.cfg.PUIDs
+}
+
+func (v *toolValidator) Name() string {
+	return v.cfg.Name
+}
+
+func (v *toolValidator) PREMISAgent(ctx context.Context) premis.Agent {
+	return v.agentFor(v.Version(ctx))
+}
+
+func (v *toolValidator) agentFor(version string, _ error) premis.Agent {
+	// An unknown version is reported as such in the agent name.
+	return toolinfo.Tool{Name: v.Name(), Version: version, URL: v.cfg.AgentURL}.PREMISAgent()
+}
+
+func (v *toolValidator) Scope() TargetType {
+	return v.scope
+}
+
+// Validate runs the tool on path and maps its exit code to the validation
+// result. The output lines of the tool are the failures of an invalid path.
+func (v *toolValidator) Validate(ctx context.Context, path string) ([]FileResult, error) {
+	if !fsutil.FileExists(path) {
+		return nil, fmt.Errorf("validate: file not found: %s", path)
+	}
+
+	cmd := v.cfg.Command
+	args := make([]string, 0, len(cmd))
+	var found bool
+	for _, arg := range cmd[1:] {
+		if strings.Contains(arg, toolPathPlaceholder) {
+			found = true
+			arg = strings.ReplaceAll(arg, toolPathPlaceholder, path)
+		}
+		args = append(args, arg)
+	}
+	if !found {
+		args = append(args, path)
+	}
+
+	ctx, cancel := withTimeout(ctx, v.cfg.Timeout)
+	defer cancel()
+
+	stdout, stderr := newOutputBuffer(maxToolOutputSize), newOutputBuffer(maxToolOutputSize)
+	c := command(ctx, cmd[0], args...)
+	c.Stdout = stdout
+	c.Stderr = stderr
+
+	var exitCode int
+	if err := c.Run(); err != nil {
+		if err := contextError(ctx, v.Name(), v.cfg.Timeout); err != nil {
+			return nil, err
+		}
+
+		var e *exec.ExitError
+		if !errors.As(err, &e) {
+			return nil, err
+		}
+		exitCode = e.ExitCode()
+	}
+
+	switch {
+	case slices.Contains(v.cfg.validExitCodes(), exitCode):
+		return nil, nil
+	case slices.Contains(v.cfg.invalidExitCodes(), exitCode):
+		return []FileResult{{Path: path, Failures: v.failures(exitCode, stdout, stderr)}}, nil
+	default:
+		return nil, NewSystemError(
+			v.Name(),
+			exitCode,
+			errors.New(withNote(stderr.String(), stderr.truncatedNote(v.Name()))),
+			fmt.Sprintf("File format validation failed with a %s application error", v.Name()),
+		)
+	}
+}
+
+// failures returns the non-empty output lines of the tool, STDOUT first, or a
+// generic failure when the tool didn't explain why the path is invalid. A note
+// is added when the output was truncated.
+func (v *toolValidator) failures(exitCode int, outputs ...*outputBuffer) []string {
+	var lines []string
+	var note string
+	for _, output := range outputs {
+		if n := output.truncatedNote(v.Name()); n != "" {
+			note = n
+		}
+		for line := range strings.Lines(output.String()) {
+			if line = strings.TrimSpace(line); line != "" {
+				lines = append(lines, line)
+			}
+		}
+	}
+
+	if len(lines) == 0 {
+		return []string{fmt.Sprintf("Reported as invalid by %s (exit code %d)", v.Name(), exitCode)}
+	}
+
+	if len(lines) > maxToolFailures {
+		n := len(lines) - maxToolFailures
+		lines = append(lines[:maxToolFailures], fmt.Sprintf("%d more lines of %s output not shown", n, v.Name()))
+	}
+	if note != "" {
+		lines = append(lines, note)
+	}
+
+	return lines
+}
+
+func (v *toolValidator) Version(ctx context.Context) (string, error) {
+	if len(v.cfg.VersionCommand) == 0 {
+		return "", nil
+	}
+
+	ctx, cancel := withTimeout(ctx, v.cfg.Timeout)
+	defer cancel()
+
+	cmd := v.cfg.VersionCommand
+	out, err := output(command(ctx, cmd[0], cmd[1:]...), v.Name())
+	if err != nil {
+		if err := contextError(ctx, v.Name(), v.cfg.Timeout); err != nil {
+			return "", err
+		}
+		return "", err
+	}
+
+	version, _, _ := strings.Cut(strings.TrimSpace(out.String()), "\n")
+
+	return strings.TrimSpace(version), nil
+}
@@ -0,0 +1,323 @@
+package fvalidate_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/fvalidate"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/premis"
+)
+
+// shCommand returns a tool command running script with sh, with the validated
+// path as its $1 argument.
+func shCommand(script string) []string {
+	return []string{"/bin/sh", "-c", script, "sh", "{path}"}
+}
+
+func toolConfig(cfg fvalidate.ToolConfig) fvalidate.ToolConfig {
+	if cfg.Name == "" {
+		cfg.Name = "mediaconch"
+	}
+	if cfg.Command == nil {
+		cfg.Command = shCommand("exit 0")
+	}
+	if cfg.PUIDs == nil {
+		cfg.PUIDs = []string{"fmt/569"}
+	}
+	if cfg.AgentURL == "" {
+		cfg.AgentURL = "https://mediaarea.net/MediaConch"
+	}
+
+	return cfg
+}
+
+func TestNewToolValidator(t *testing.T) {
+	t.Parallel()
+
+	type test struct {
+		name      string
+		cfg       fvalidate.ToolConfig
+		wantScope fvalidate.TargetType
+		wantErr   string
+	}
+	for _, tt := range []test{
+		{
+			name:      "Returns a file validator by default",
+			cfg:       toolConfig(fvalidate.ToolConfig{}),
+			wantScope: fvalidate.TargetTypeFile,
+		},
+		{
+			name:      "Returns a directory validator",
+			cfg:       toolConfig(fvalidate.ToolConfig{Scope: "dir"}),
+			wantScope: fvalidate.TargetTypeDir,
+		},
+		{
+			name: "Errors when the configuration is missing required values",
+			cfg:  fvalidate.ToolConfig{},
+			wantErr: `invalid "" tool configuration: Name: missing required value
+Command: missing required value
+PUIDs: missing required value
+AgentURL: missing required value`,
+		},
+		{
+			name: "Errors when the scope or exit codes are invalid",
+			cfg: toolConfig(fvalidate.ToolConfig{
+				Scope:            "sip",
+				ValidExitCodes:   []int{0, 3},
+				InvalidExitCodes: []int{1, 3},
+			}),
+			wantErr: `invalid "mediaconch" tool configuration: Scope: invalid value "sip", must be one of (file, dir)
+InvalidExitCodes: 3 is also a valid exit code`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			v, err := fvalidate.NewToolValidator(tt.cfg)
+			if tt.wantErr != "" {
+				assert.Error(t, err, tt.wantErr)
+				return
+			}
+			assert.NilError(t, err)
+			assert.Equal(t, v.Name(), tt.cfg.Name)
+			assert.DeepEqual(t, v.FormatIDs(), tt.cfg.PUIDs)
+			assert.Equal(t, v.Scope(), tt.wantScope)
+		})
+	}
+}
+
+func TestToolValidate(t *testing.T) {
+	t.Parallel()
+
+	type test struct {
+		name    string
+		cfg     fvalidate.ToolConfig
+		want    func(path string) []fvalidate.FileResult
+		wantErr string
+	}
+	for _, tt := range []test{
+		{
+			name: "Returns nothing when the tool exits with a valid exit code",
+			cfg: toolConfig(fvalidate.ToolConfig{
+				Command:        shCommand(`test -f "$1" && exit 2`),
+				ValidExitCodes: []int{2},
+			}),
+		},
+		{
+			name: "Returns the tool output as the failures of an invalid file",
+			cfg: toolConfig(fvalidate.ToolConfig{
+				Command: shCommand(`echo "$(basename "$1"): invalid header"; echo; echo "missing track" >&2; exit 1`),
+			}),
+			want: func(path string) []fvalidate.FileResult {
+				return []fvalidate.FileResult{{
+					Path:     path,
+					Failures: []string{"file.mkv: invalid header", "missing track"},
+				}}
+			},
+		},
+		{
+			name: "Adds the path as the last argument when there is no placeholder",
+			cfg: toolConfig(fvalidate.ToolConfig{
+				Command:          []string{"/bin/sh", "-c", `echo "checked $(basename "$1")"; exit 4`, "sh"},
+				InvalidExitCodes: []int{4},
+			}),
+			want: func(path string) []fvalidate.FileResult {
+				return []fvalidate.FileResult{{Path: path, Failures: []string{"checked file.mkv"}}}
+			},
+		},
+		{
+			name: "Returns a generic failure when the tool has no output",
+			cfg:  toolConfig(fvalidate.ToolConfig{Command: shCommand("exit 1")}),
+			want: func(path string) []fvalidate.FileResult {
+				return []fvalidate.FileResult{{
+					Path:     path,
+					Failures: []string{"Reported as invalid by mediaconch (exit code 1)"},
+				}}
+			},
+		},
+		{
+			name: "Truncates long tool output",
+			cfg: toolConfig(fvalidate.ToolConfig{
+				Command: shCommand(`seq 1 25; exit 1`),
+			}),
+			want: func(path string) []fvalidate.FileResult {
+				var failures []string
+				for i := range 20 {
+					failures = append(failures, fmt.Sprint(i+1))
+				}
+				failures = append(failures, "5 more lines of mediaconch output not shown")
+
+				return []fvalidate.FileResult{{Path: path, Failures: failures}}
+			},
+		},
+		{
+			name: "Notes when the tool output is truncated",
+			cfg: toolConfig(fvalidate.ToolConfig{
+				// 300,000 lines of 8 bytes, truncated after 1 MiB (131,072 lines).
+				Command: shCommand(`yes invalid | head -n 300000; exit 1`),
+			}),
+			want: func(path string) []fvalidate.FileResult {
+				var failures []string
+				for range 20 {
+					failures = append(failures, "invalid")
+				}
+				failures = append(failures,
+					"131052 more lines of mediaconch output not shown",
+					"mediaconch output truncated after 1048576 bytes",
+				)
+
+				return []fvalidate.FileResult{{Path: path, Failures: failures}}
+			},
+		},
+		{
+			name:    "Returns a system error for other exit codes",
+			cfg:     toolConfig(fvalidate.ToolConfig{Command: shCommand("echo 'out of memory' >&2; exit 5")}),
+			wantErr: "system error: exit code 5: out of memory",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			dir := fs.NewDir(t, "", fs.WithFile("file.mkv", "video"))
+			path := dir.Join("file.mkv")
+
+			v, err := fvalidate.NewToolValidator(tt.cfg)
+			assert.NilError(t, err)
+
+			got, err := v.Validate(t.Context(), path)
+			if tt.wantErr != "" {
+				assert.Error(t, err, tt.wantErr)
+
+				var se *fvalidate.SystemError
+				assert.Assert(t, errors.As(err, &se))
+				assert.Equal(t, se.Message(), "File format validation failed with a mediaconch application error")
+				return
+			}
+			assert.NilError(t, err)
+
+			var want []fvalidate.FileResult
+			if tt.want != nil {
+				want = tt.want(path)
+			}
+			assert.DeepEqual(t, got, want)
+		})
+	}
+}
+
+func TestToolValidateTruncatedError(t *testing.T) {
+	t.Parallel()
+
+	dir := fs.NewDir(t, "", fs.WithFile("file.mkv", "video"))
+	v, err := fvalidate.NewToolValidator(toolConfig(fvalidate.ToolConfig{
+		Command: shCommand(`yes "out of memory" | head -n 100000 >&2; exit 5`),
+	}))
+	assert.NilError(t, err)
+
+	_, err = v.Validate(t.Context(), dir.Join("file.mkv"))
+	assert.ErrorContains(t, err, "system error: exit code 5: out of memory\nout of memory\n")
+	assert.Assert(t, strings.HasSuffix(err.Error(), "\nmediaconch output truncated after 1048576 bytes"))
+	assert.Assert(t, len(err.Error()) < 1100000)
+}
+
+func TestToolValidateTimeout(t *testing.T) {
+	t.Parallel()
+
+	dir := fs.NewDir(t, "", fs.WithFile("file.mkv", "video"))
+
+	// The tool starts a child process that keeps the output open, it's only
+	// stopped quickly if the whole process group is killed.
+	v, err := fvalidate.NewToolValidator(toolConfig(fvalidate.ToolConfig{
+		Command: shCommand("sleep 30 & wait"),
+		Timeout: 100 * time.Millisecond,
+	}))
+	assert.NilError(t, err)
+
+	start := time.Now()
+	_, err = v.Validate(t.Context(), dir.Join("file.mkv"))
+	assert.Assert(t, time.Since(start) < 3*time.Second)
+	assert.Error(t, err, "system error: validator timed out after 100ms")
+	assert.Assert(t, errors.Is(err, fvalidate.ErrTimeout))
+
+	var se *fvalidate.SystemError
+	assert.Assert(t, errors.As(err, &se))
+	assert.Equal(t, se.Message(), "File format validation timed out, mediaconch didn't finish within 100ms")
+}
+
+func TestToolValidateCancelled(t *testing.T) {
+	t.Parallel()
+
+	dir := fs.NewDir(t, "", fs.WithFile("file.mkv", "video"))
+	v, err := fvalidate.NewToolValidator(toolConfig(fvalidate.ToolConfig{
+		Command: shCommand("sleep 30"),
+		Timeout: time.Minute,
+	}))
+	assert.NilError(t, err)
+
+	ctx, cancel := context.WithTimeout(t.Context(), 100*time.Millisecond)
+	defer cancel()
+
+	_, err = v.Validate(ctx, dir.Join("file.mkv"))
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Assert(t, !errors.Is(err, fvalidate.ErrTimeout))
+}
+
+func TestToolValidateMissingFile(t *testing.T) {
+	t.Parallel()
+
+	v, err := fvalidate.NewToolValidator(toolConfig(fvalidate.ToolConfig{}))
+	assert.NilError(t, err)
+
+	_, err = v.Validate(t.Context(), "/missing/file.mkv")
+	assert.Error(t, err, "validate: file not found: /missing/file.mkv")
+}
+
+func TestToolPREMISAgent(t *testing.T) {
+	t.Parallel()
+
+	type test struct {
+		name string
+		cmd  []string
+		want string
+	}
+	for _, tt := range []test{
+		{
+			name: "Uses the version output as the agent name",
+			cmd:  []string{"/bin/sh", "-c", "echo 'mediaconch 24.06'; echo 'Copyright'"},
+			want: "mediaconch 24.06",
+		},
+		{
+			name: "Adds the tool name to the version",
+			cmd:  []string{"/bin/sh", "-c", "echo 24.06"},
+			want: "mediaconch 24.06",
+		},
+		{
+			name: "Uses an unknown version without a version command",
+			want: "mediaconch (version unknown)",
+		},
+		{
+			name: "Uses an unknown version when the version command fails",
+			cmd:  []string{"/bin/sh", "-c", "exit 1"},
+			want: "mediaconch (version unknown)",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			v, err := fvalidate.NewToolValidator(toolConfig(fvalidate.ToolConfig{VersionCommand: tt.cmd}))
+			assert.NilError(t, err)
+			assert.DeepEqual(t, v.PREMISAgent(t.Context()), premis.Agent{
+				Type:    "software",
+				Name:    tt.want,
+				IdType:  "url",
+				IdValue: "https://mediaarea.net/MediaConch",
+			})
+		})
+	}
+}
@@ -0,0 +1,90 @@
+package fvalidate
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// veraPDFReport is the veraPDF machine-readable report (MRR), only the
+// elements needed to find the non-compliant files are decoded.
+type veraPDFReport struct {
+	Jobs []veraPDFJob `xml:"jobs>job"`
+}
+
+type veraPDFJob struct {
+	Name             string                   `xml:"item>name"`
+	ValidationReport *veraPDFValidationReport `xml:"validationReport"`
+	TaskException    *veraPDFTaskException    `xml:"taskException"`
+}
+
+type veraPDFValidationReport struct {
+	IsCompliant bool          `xml:"isCompliant,attr"`
+	Rules       []veraPDFRule `xml:"details>rule"`
+}
+
+type veraPDFRule struct {
+	Specification string `xml:"specification,attr"`
+	Clause        string `xml:"clause,attr"`
+	TestNumber    string `xml:"testNumber,attr"`
+	Status        string `xml:"status,attr"`
+	Description   string `xml:"description"`
+}
+
+type veraPDFTaskException struct {
+	Type    string `xml:"type,attr"`
+	Message string `xml:"exceptionMessage"`
+}
+
+// String returns the rule as "<specification> <clause>-<test number>:
+// <description>", e.g. "ISO 19005-1:2005 6.1.7-1: The file header shall
+// begin at byte zero".
+func (r veraPDFRule) String() string {
+	return fmt.Sprintf(
+		"%s %s-%s: %s",
+		r.Specification,
+		r.Clause,
+		r.TestNumber,
+		strings.Join(strings.Fields(r.Description), " "),
+	)
+}
+
+// parseVeraPDFReport reads a veraPDF machine-readable report from r and
+// returns the results of the files that are not compliant, with the rules
+// they fail.
+func parseVeraPDFReport(r io.Reader) ([]FileResult, error) {
+	var rep veraPDFReport
+	if err := xml.NewDecoder(r).Decode(&rep); err != nil {
+		return nil, fmt.Errorf("parse veraPDF report: %v", err)
+	}
+
+	var res []FileResult
+	for _, job := range rep.Jobs {
+		var failures []string
+		switch {
+		case job.TaskException != nil:
+			// The file couldn't be validated, e.g. it can't be parsed.
+			msg := strings.TrimSpace(job.TaskException.Message)
+			if msg == "" {
+				msg = fmt.Sprintf("veraPDF %s task failed", strings.ToLower(job.TaskException.Type))
+			}
+			failures = append(failures, msg)
+		case job.ValidationReport != nil && !job.ValidationReport.IsCompliant:
+			for _, rule := range job.ValidationReport.Rules {
+				if rule.Status == "failed" {
+					failures = append(failures, rule.String())
+				}
+			}
+			if len(failures) == 0 {
+				failures = append(failures, "PDF file is not compliant with Validation Profile requirements.")
+			}
+		}
+
+		if len(failures) > 0 {
+			res = append(res, FileResult{Path: job.Name, Failures: failures})
+		}
+	}
+
+	return res, nil
+}
@@ -1,10 +1,12 @@
 package fvalidate
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"os/exec"
 	"strings"
+	"time"
 
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/fsutil"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/premis"
@@ -27,14 +29,17 @@ var pdfaPUIDs = []string{
 }
 
 type veraPDFValidator struct {
-	cmd   string
-	scope TargetType
+	cmd     string
+	timeout time.Duration
+	scope   TargetType
 }
 
 var _ Validator = (*veraPDFValidator)(nil)
 
-func NewVeraPDFValidator(cmd string) *veraPDFValidator {
-	return &veraPDFValidator{cmd: cmd, scope: TargetTypeDir}
+// NewVeraPDFValidator returns a validator running the veraPDF cmd, which is
+// killed if it runs longer than timeout (if positive).
+func NewVeraPDFValidator(cmd string, timeout time.Duration) *veraPDFValidator {
+	return &veraPDFValidator{cmd: cmd, timeout: timeout, scope: TargetTypeDir}
 }
 
 func (v *veraPDFValidator) FormatIDs() []string {
@@ -45,8 +50,11 @@ func (v *veraPDFValidator) Name() string {
 	return "veraPDF"
 }
 
-func (v *veraPDFValidator) PREMISAgent() premis.Agent {
-	name, err := v.Version()
+func (v *veraPDFValidator) PREMISAgent(ctx context.Context) premis.Agent {
+	return v.agentFor(v.Version(ctx))
+}
+
+func (v *veraPDFValidator) agentFor(name string, err error) premis.Agent {
 	if err != nil || name == "" {
 		name = fmt.Sprintf("%s (version unknown)", v.Name())
 	}
@@ -59,38 +67,53 @@ func (v *veraPDFValidator) PREMISAgent() premis.Agent {
 	}
 }
 
-func (v *veraPDFValidator) Validate(path string) (string, error) {
+func (v *veraPDFValidator) Validate(ctx context.Context, path string) ([]FileResult, error) {
 	// If the veraPDF cmd path is not set then skip validation.
 	if v.cmd == "" {
-		return "", nil
+		return nil, nil
 	}
 
 	if !fsutil.FileExists(path) {
-		return "", fmt.Errorf("validate: file not found: %s", path)
+		return nil, fmt.Errorf("validate: file not found: %s", path)
 	}
 
-	_, err := v.run("--recurse", path)
+	ctx, cancel := withTimeout(ctx, v.timeout)
+	defer cancel()
+
+	output, err := v.run(ctx, "--format", "mrr", "--recurse", path)
 	if err == nil { // error IS nil.
-		return "", nil
+		return nil, nil
+	}
+	if err := contextError(ctx, v.Name(), v.timeout); err != nil {
+		return nil, err
 	}
 
 	e, ok := err.(*exec.ExitError)
 	if !ok {
-		return "", err
+		return nil, err
 	}
 
 	switch e.ExitCode() {
 	case 1:
 		// Exit code 1 indicates a validation error, and there is no
-		// STDERR. In this case the full validation report is written to
-		// STDOUT, but we are ignoring it right now because it is very
-		// long.
-		return "One or more PDF/A files are invalid", nil
+		// STDERR. In this case the machine-readable report is written to
+		// STDOUT, with the compliance result of each file.
+		res, err := parseVeraPDFReport(strings.NewReader(output.String()))
+		if err != nil {
+			return nil, NewSystemError(
+				v.Name(),
+				e.ExitCode(),
+				errorWithNote(err, output.truncatedNote(v.Name())),
+				"PDF/A validation failed with an application error",
+			)
+		}
+
+		return res, nil
 
 	default:
 		// Other exit codes (e.g. file not found) should write an error
 		// message to STDERR.
-		return "", NewSystemError(
+		return nil, NewSystemError(
 			v.Name(),
 			e.ExitCode(),
 			errors.New(string(e.Stderr)),
@@ -103,29 +126,30 @@ func (v *veraPDFValidator) Scope() TargetType {
 	return v.scope
 }
 
-func (v *veraPDFValidator) Version() (string, error) {
+func (v *veraPDFValidator) Version(ctx context.Context) (string, error) {
 	// If the veraPDF cmd path is not set then skip returning the version.
 	if v.cmd == "" {
 		return "", nil
 	}
 
-	output, err := v.run("--version")
+	ctx, cancel := withTimeout(ctx, v.timeout)
+	defer cancel()
+
+	output, err := v.run(ctx, "--version")
 	if err != nil {
+		if err := contextError(ctx, v.Name(), v.timeout); err != nil {
+			return "", err
+		}
 		return "", err
 	}
 
-	lines := strings.Split(output, "\n")
+	lines := strings.Split(output.String(), "\n")
 
 	return lines[0], nil
 }
 
-func (v *veraPDFValidator) run(args ...string) (string, error) {
-	result := exec.Command(v.cmd, args...) // #nosec: G204 -- trusted path.
-
-	output, err := result.Output()
-	if err != nil {
-		return "", err
-	}
-
-	return string(output), nil
+func (v *veraPDFValidator) run(ctx context.Context, args ...string) (*outputBuffer, error) {
+	// The output is returned with the error, veraPDF writes the validation
+	// report to STDOUT when it exits with a validation error.
+	return output(command(ctx, v.cmd, args...), v.Name())
 }
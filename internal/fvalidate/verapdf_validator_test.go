@@ -13,7 +13,7 @@ import (
 func TestFormatIDs(t *testing.T) {
 	t.Parallel()
 
-	v := fvalidate.NewVeraPDFValidator("")
+	v := fvalidate.NewVeraPDFValidator("", 0)
 	got := v.FormatIDs()
 
 	assert.DeepEqual(t, got, []string{
@@ -34,20 +34,64 @@ func TestFormatIDs(t *testing.T) {
 func TestName(t *testing.T) {
 	t.Parallel()
 
-	v := fvalidate.NewVeraPDFValidator("")
+	v := fvalidate.NewVeraPDFValidator("", 0)
 	got := v.Name()
 
 	assert.Equal(t, got, "veraPDF")
 }
 
+const veraPDFReport = `<?xml version="1.0" encoding="utf-8"?>
+<report>
+  <buildInformation>
+    <releaseDetails id="core" version="1.24.1" buildDate="2023-10-20T13:26:00Z"></releaseDetails>
+  </buildInformation>
+  <jobs>
+    <job>
+      <item size="1024">
+        <name>/sip/content/d_0000001/valid.pdf</name>
+      </item>
+      <validationReport jobEndStatus="normal" profileName="PDF/A-1B validation profile" statement="PDF file is compliant with Validation Profile requirements." isCompliant="true">
+        <details passedRules="128" failedRules="0" passedChecks="1024" failedChecks="0"></details>
+      </validationReport>
+    </job>
+    <job>
+      <item size="2048">
+        <name>/sip/content/d_0000001/invalid.pdf</name>
+      </item>
+      <validationReport jobEndStatus="normal" profileName="PDF/A-1B validation profile" statement="PDF file is not compliant with Validation Profile requirements." isCompliant="false">
+        <details passedRules="126" failedRules="2" passedChecks="1020" failedChecks="4">
+          <rule specification="ISO 19005-1:2005" clause="6.1.2" testNumber="1" status="failed" failedChecks="1">
+            <description>The % character of the file header shall occur at byte offset 0 of the file.</description>
+            <object>CosDocument</object>
+          </rule>
+          <rule specification="ISO 19005-1:2005" clause="6.1.3" testNumber="1" status="failed" failedChecks="3">
+            <description>
+              The file trailer dictionary shall contain the ID keyword.
+            </description>
+            <object>CosTrailer</object>
+          </rule>
+        </details>
+      </validationReport>
+    </job>
+    <job>
+      <item size="10">
+        <name>/sip/content/d_0000001/broken.pdf</name>
+      </item>
+      <taskException type="PARSE" isExecuted="true" isSuccess="false">
+        <exceptionMessage>Couldn't parse stream</exceptionMessage>
+      </taskException>
+    </job>
+  </jobs>
+</report>`
+
 func TestValidate(t *testing.T) {
 	t.Parallel()
 
 	type test struct {
 		name    string
-		cmd     string
+		cmd     func(t *testing.T) string
 		path    func(td string) string
-		want    func(td string) string
+		want    []fvalidate.FileResult
 		wantErr func(td string) string
 	}
 	for _, tt := range []test{
@@ -56,7 +100,7 @@ func TestValidate(t *testing.T) {
 		},
 		{
 			name: "Errors when path doesn't exist",
-			cmd:  "echo",
+			cmd:  func(t *testing.T) string { return "echo" },
 			path: func(td string) string { return td + "/foo" },
 			wantErr: func(td string) string {
 				return fmt.Sprintf("validate: file not found: %s/foo", td)
@@ -64,14 +108,45 @@ func TestValidate(t *testing.T) {
 		},
 		{
 			name: "Returns nothing when no error",
-			cmd:  "echo",
+			cmd:  func(t *testing.T) string { return "echo" },
+			path: func(td string) string { return td },
+		},
+		{
+			name: "Returns the non-compliant files and their failed rules",
+			cmd:  func(t *testing.T) string { return fakeCommand(t, veraPDFReport, 1) },
+			path: func(td string) string { return td },
+			want: []fvalidate.FileResult{
+				{
+					Path: "/sip/content/d_0000001/invalid.pdf",
+					Failures: []string{
+						"ISO 19005-1:2005 6.1.2-1: The % character of the file header shall occur at byte offset 0 of the file.",
+						"ISO 19005-1:2005 6.1.3-1: The file trailer dictionary shall contain the ID keyword.",
+					},
+				},
+				{
+					Path:     "/sip/content/d_0000001/broken.pdf",
+					Failures: []string{"Couldn't parse stream"},
+				},
+			},
+		},
+		{
+			name: "Errors when the report can't be parsed",
+			cmd:  func(t *testing.T) string { return fakeCommand(t, "not a report", 1) },
 			path: func(td string) string { return td },
+			wantErr: func(td string) string {
+				return "system error: exit code 1: parse veraPDF report: EOF"
+			},
 		},
 	} {
 		t.Run(tt.name, func(t *testing.T) {
 			t.Parallel()
 
-			v := fvalidate.NewVeraPDFValidator(tt.cmd)
+			cmd := ""
+			if tt.cmd != nil {
+				cmd = tt.cmd(t)
+			}
+
+			v := fvalidate.NewVeraPDFValidator(cmd, 0)
 			td := t.TempDir()
 
 			path := ""
@@ -79,19 +154,14 @@ func TestValidate(t *testing.T) {
 				path = tt.path(td)
 			}
 
-			got, err := v.Validate(path)
+			got, err := v.Validate(t.Context(), path)
 			if tt.wantErr != nil {
 				assert.Error(t, err, tt.wantErr(td))
 				return
 			}
 
 			assert.NilError(t, err)
-
-			if tt.want != nil {
-				assert.Equal(t, got, tt.want(td))
-			} else {
-				assert.Equal(t, got, "")
-			}
+			assert.DeepEqual(t, got, tt.want)
 		})
 	}
 }
@@ -99,16 +169,16 @@ func TestValidate(t *testing.T) {
 func TestScope(t *testing.T) {
 	t.Parallel()
 
-	v := fvalidate.NewVeraPDFValidator("")
+	v := fvalidate.NewVeraPDFValidator("", 0)
 	assert.Equal(t, v.Scope(), fvalidate.TargetTypeDir)
 }
 
 func TestVeraPDFPREMISAgent(t *testing.T) {
 	t.Parallel()
 
-	v := fvalidate.NewVeraPDFValidator("")
+	v := fvalidate.NewVeraPDFValidator("", 0)
 
-	got := v.PREMISAgent()
+	got := v.PREMISAgent(t.Context())
 	assert.DeepEqual(t, got, premis.Agent{
 		Type:    "software",
 		Name:    "veraPDF (version unknown)",
@@ -120,8 +190,8 @@ func TestVeraPDFPREMISAgent(t *testing.T) {
 func TestVersion(t *testing.T) {
 	t.Parallel()
 
-	v := fvalidate.NewVeraPDFValidator("")
-	got, err := v.Version()
+	v := fvalidate.NewVeraPDFValidator("", 0)
+	got, err := v.Version(t.Context())
 
 	assert.NilError(t, err)
 	assert.Equal(t, got, "")
@@ -0,0 +1,346 @@
+// Package inventory records the files and directories of a SIP, so the SIP
+// tree is walked and its files are read only once during preprocessing.
+//
+// The inventory is created by the create-inventory activity and persisted as
+// a JSON file next to the SIP directory, in the working directory holding it,
+// so it's never added to the SIP. The activities that run afterwards read it
+// instead of walking the SIP again. The checksums calculated when the SIP
+// manifest is verified are persisted in a second file, and added to the
+// inventory when it's loaded.
+package inventory
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/fformat"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/sip"
+)
+
+const (
+	// fileSuffix is the suffix added to the SIP name to name its inventory
+	// file.
+	fileSuffix = ".inventory.json"
+
+	// checksumsSuffix is the suffix added to the SIP name to name its
+	// checksums file.
+	checksumsSuffix = ".checksums.json"
+)
+
+// Checksums are file checksums keyed by file path, relative to the SIP root
+// directory, and algorithm name.
+type Checksums map[string]map[string]string
+
+// Entry is a file or directory found in the SIP.
+type Entry struct {
+	// Path of the file or directory relative to the SIP root directory, "."
+	// for the root directory itself.
+	Path string `json:"path"`
+
+	// Dir is true for directories.
+	Dir bool `json:"dir,omitempty"`
+
+	// Size of the file in bytes.
+	Size int64 `json:"size,omitempty"`
+
+	// ModTime is the file modification time.
+	ModTime time.Time `json:"modTime"`
+
+	// Checksums of the file contents by algorithm name, as used in the SIP
+	// manifest (e.g. "MD5").
+	Checksums map[string]string `json:"checksums,omitempty"`
+
+	// Format is the file format identification, nil if the file has not
+	// been identified or its identification failed.
+	Format *fformat.FileFormat `json:"format,omitempty"`
+}
+
+// Inventory lists the SIP files and directories in lexical order, as walked
+// by filepath.WalkDir.
+type Inventory struct {
+	// Identified is true when the format of the files in the SIP content
+	// directory has been identified.
+	Identified bool `json:"identified"`
+
+	Entries []Entry `json:"entries"`
+
+	// index maps entry paths to their Entries index.
+	index map[string]int
+}
+
+// Path returns the path of the inventory file of the SIP at root, a hidden
+// file next to the SIP directory.
+func Path(root string) string {
+	return sidecarPath(root, fileSuffix)
+}
+
+// ChecksumsPath returns the path of the checksums file of the SIP at root, a
+// hidden file next to the SIP directory.
+func ChecksumsPath(root string) string {
+	return sidecarPath(root, checksumsSuffix)
+}
+
+// Paths returns the paths of the files written for the SIP at root.
+func Paths(root string) []string {
+	return []string{Path(root), ChecksumsPath(root)}
+}
+
+func sidecarPath(root, suffix string) string {
+	root = filepath.Clean(root)
+
+	return filepath.Join(filepath.Dir(root), "."+filepath.Base(root)+suffix)
+}
+
+// Scan walks the SIP directory tree at root and returns an inventory with the
+// path, size and modification time of each entry.
+func Scan(root string) (*Inventory, error) {
+	inv := &Inventory{}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+
+		e := Entry{Path: rel, Dir: d.IsDir(), ModTime: fi.ModTime()}
+		if !e.Dir {
+			e.Size = fi.Size()
+		}
+		inv.Entries = append(inv.Entries, e)
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("inventory: scan: %v", err)
+	}
+	inv.reindex()
+
+	return inv, nil
+}
+
+// Read reads the inventory file of the SIP at root.
+func Read(root string) (*Inventory, error) {
+	blob, err := os.ReadFile(Path(root)) // #nosec G304 -- trusted file path.
+	if err != nil {
+		return nil, fmt.Errorf("inventory: read: %w", err)
+	}
+
+	var inv Inventory
+	if err := json.Unmarshal(blob, &inv); err != nil {
+		return nil, fmt.Errorf("inventory: decode: %v", err)
+	}
+	inv.reindex()
+
+	return &inv, nil
+}
+
+// Load reads the inventory file of the SIP at root, or scans the SIP if the
+// inventory has not been created. A scanned inventory has no file formats.
+// The checksums found in the SIP checksums file are added to the inventory.
+func Load(root string) (*Inventory, error) {
+	inv, err := Read(root)
+	if errors.Is(err, fs.ErrNotExist) {
+		inv, err = Scan(root)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sums, err := ReadChecksums(root)
+	if err != nil {
+		return nil, err
+	}
+	for path, s := range sums {
+		i, ok := inv.index[path]
+		if !ok {
+			continue
+		}
+		if inv.Entries[i].Checksums == nil {
+			inv.Entries[i].Checksums = make(map[string]string, len(s))
+		}
+		maps.Copy(inv.Entries[i].Checksums, s)
+	}
+
+	return inv, nil
+}
+
+// Write writes inv to the inventory file of the SIP at root. The file is
+// replaced atomically, so it's never read partially written.
+func Write(root string, inv *Inventory) error {
+	return writeJSON(Path(root), inv)
+}
+
+// ReadChecksums reads the checksums file of the SIP at root. It returns no
+// checksums if the file doesn't exist.
+func ReadChecksums(root string) (Checksums, error) {
+	blob, err := os.ReadFile(ChecksumsPath(root)) // #nosec G304 -- trusted file path.
+	if errors.Is(err, fs.ErrNotExist) {
+		return Checksums{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("inventory: read checksums: %v", err)
+	}
+
+	sums := Checksums{}
+	if err := json.Unmarshal(blob, &sums); err != nil {
+		return nil, fmt.Errorf("inventory: decode checksums: %v", err)
+	}
+
+	return sums, nil
+}
+
+// WriteChecksums writes sums to the checksums file of the SIP at root,
+// replacing it atomically.
+func WriteChecksums(root string, sums Checksums) error {
+	return writeJSON(ChecksumsPath(root), sums)
+}
+
+// Remove removes the inventory and checksums files of the SIP at root, if
+// any.
+func Remove(root string) error {
+	for _, path := range Paths(root) {
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("inventory: remove: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// writeJSON encodes v to a temporary file and renames it to path.
+func writeJSON(path string, v any) error {
+	blob, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("inventory: encode: %v", err)
+	}
+
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, blob, 0o600); err != nil {
+		return fmt.Errorf("inventory: write: %v", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return fmt.Errorf("inventory: write: %v", err)
+	}
+
+	return nil
+}
+
+// Resume copies the file formats identified in prev, a partial inventory
+// saved by a previous attempt, to the inv entries that haven't changed since.
+// It returns the number of formats copied.
+func (inv *Inventory) Resume(prev *Inventory) int {
+	var n int
+	for i, e := range inv.Entries {
+		p, ok := prev.Entry(e.Path)
+		if !ok || e.Dir || p.Format == nil || p.Size != e.Size || !p.ModTime.Equal(e.ModTime) {
+			continue
+		}
+		inv.Entries[i].Format = p.Format
+		n++
+	}
+
+	return n
+}
+
+// Files returns the paths of the files within dir, a path relative to the SIP
+// root directory. The returned paths are relative to dir.
+func (inv *Inventory) Files(dir string) []string {
+	var paths []string
+	for _, e := range inv.Entries {
+		if e.Dir {
+			continue
+		}
+		if rel, ok := e.Within(dir); ok {
+			paths = append(paths, rel)
+		}
+	}
+
+	return paths
+}
+
+// Entry returns the entry at path, relative to the SIP root directory, and
+// whether it is in the inventory.
+func (inv *Inventory) Entry(path string) (Entry, bool) {
+	i, ok := inv.index[path]
+	if !ok {
+		return Entry{}, false
+	}
+
+	return inv.Entries[i], true
+}
+
+// Checksum returns the checksum of the file at path, relative to the SIP root
+// directory, using the alg algorithm. It returns false if the checksum is not
+// in the inventory.
+func (inv *Inventory) Checksum(path, alg string) (string, bool) {
+	i, ok := inv.index[path]
+	if !ok {
+		return "", false
+	}
+	sum, ok := inv.Entries[i].Checksums[alg]
+
+	return sum, ok
+}
+
+// Formats returns the formats of the files in the SIP content directory, keyed
+// by absolute path like fformat.IdentifyFormats. The files are identified with
+// identifier if their formats are not in the inventory.
+func (inv *Inventory) Formats(
+	ctx context.Context,
+	identifier fformat.Identifier,
+	s sip.SIP,
+) (fformat.FileFormats, error) {
+	if !inv.Identified {
+		return fformat.IdentifyFormats(ctx, identifier, s)
+	}
+
+	content, err := filepath.Rel(s.Path, s.ContentPath)
+	if err != nil {
+		return nil, err
+	}
+
+	formats := make(fformat.FileFormats)
+	for _, e := range inv.Entries {
+		if e.Dir || e.Format == nil {
+			continue
+		}
+		if _, ok := e.Within(content); ok {
+			formats[filepath.Join(s.Path, e.Path)] = e.Format
+		}
+	}
+
+	return formats, nil
+}
+
+func (inv *Inventory) reindex() {
+	inv.index = make(map[string]int, len(inv.Entries))
+	for i, e := range inv.Entries {
+		inv.index[e.Path] = i
+	}
+}
+
+// Within returns the entry path relative to dir, and whether the entry is
+// within dir. dir is relative to the SIP root directory.
+func (e Entry) Within(dir string) (string, bool) {
+	if dir == "." {
+		return e.Path, e.Path != "."
+	}
+
+	return strings.CutPrefix(e.Path, dir+string(filepath.Separator))
+}
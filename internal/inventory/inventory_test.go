@@ -0,0 +1,278 @@
+package inventory_test
+
+import (
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/mock/gomock"
+	"gotest.tools/v3/assert"
+	tfs "gotest.tools/v3/fs"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/enums"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/fformat"
+	fake_fformat "github.com/artefactual-sdps/preprocessing-sfa/internal/fformat/fake"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/inventory"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/sip"
+)
+
+// testDir returns the path of a SIP directory, in a working directory where
+// its inventory files are written.
+func testDir(t *testing.T) string {
+	t.Helper()
+
+	dir := tfs.NewDir(t, "",
+		tfs.WithDir("SIP_20201201_Vecteur",
+			tfs.WithDir("content",
+				tfs.WithDir("d_0000001",
+					tfs.WithFile("00000001.jp2", "12345"),
+				),
+			),
+			tfs.WithDir("header",
+				tfs.WithFile("metadata.xml", "<xml/>"),
+			),
+		),
+		tfs.WithFile(".SIP_20201201_Vecteur.inventory.json", "{}"),
+	)
+
+	return dir.Join("SIP_20201201_Vecteur")
+}
+
+type entry struct {
+	Path string
+	Dir  bool
+	Size int64
+}
+
+func entries(inv *inventory.Inventory) []entry {
+	var got []entry
+	for _, e := range inv.Entries {
+		got = append(got, entry{Path: e.Path, Dir: e.Dir, Size: e.Size})
+	}
+	return got
+}
+
+func TestScan(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Lists the SIP entries in walk order", func(t *testing.T) {
+		t.Parallel()
+
+		inv, err := inventory.Scan(testDir(t))
+		assert.NilError(t, err)
+		assert.DeepEqual(t, entries(inv), []entry{
+			{Path: ".", Dir: true},
+			{Path: "content", Dir: true},
+			{Path: "content/d_0000001", Dir: true},
+			{Path: "content/d_0000001/00000001.jp2", Size: 5},
+			{Path: "header", Dir: true},
+			{Path: "header/metadata.xml", Size: 6},
+		})
+		assert.Equal(t, inv.Identified, false)
+	})
+
+	t.Run("Errors when the SIP doesn't exist", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := inventory.Scan(filepath.Join(t.TempDir(), "missing"))
+		assert.ErrorContains(t, err, "inventory: scan: lstat")
+	})
+}
+
+func TestWriteRead(t *testing.T) {
+	t.Parallel()
+
+	dir := testDir(t)
+	inv, err := inventory.Scan(dir)
+	assert.NilError(t, err)
+	inv.Identified = true
+	inv.Entries[3].Checksums = map[string]string{"MD5": "827ccb0eea8a706c4c34a16891f84e7b"}
+	inv.Entries[3].Format = &fformat.FileFormat{Namespace: "PRONOM", ID: "x-fmt/392"}
+
+	assert.NilError(t, inventory.Write(dir, inv))
+
+	got, err := inventory.Read(dir)
+	assert.NilError(t, err)
+	assert.Equal(t, got.Identified, true)
+	assert.DeepEqual(t, entries(got), entries(inv))
+	assert.DeepEqual(t, got.Entries[3].Format, inv.Entries[3].Format)
+
+	sum, ok := got.Checksum("content/d_0000001/00000001.jp2", "MD5")
+	assert.Assert(t, ok)
+	assert.Equal(t, sum, "827ccb0eea8a706c4c34a16891f84e7b")
+
+	_, ok = got.Checksum("content/d_0000001/00000001.jp2", "SHA-256")
+	assert.Assert(t, !ok)
+
+	_, ok = got.Checksum("header/metadata.xml", "MD5")
+	assert.Assert(t, !ok)
+
+	e, ok := got.Entry("content/d_0000001/00000001.jp2")
+	assert.Assert(t, ok)
+	assert.Equal(t, e.Size, int64(5))
+
+	_, ok = got.Entry("content/d_0000001/missing.jp2")
+	assert.Assert(t, !ok)
+
+	// The inventory file is written next to the SIP directory.
+	assert.Equal(t, inventory.Path(dir), filepath.Join(filepath.Dir(dir), ".SIP_20201201_Vecteur.inventory.json"))
+	rescan, err := inventory.Scan(dir)
+	assert.NilError(t, err)
+	assert.DeepEqual(t, entries(rescan), entries(inv))
+}
+
+func TestLoad(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Scans the SIP without an inventory", func(t *testing.T) {
+		t.Parallel()
+
+		dir := testDir(t)
+		assert.NilError(t, inventory.Remove(dir))
+
+		inv, err := inventory.Load(dir)
+		assert.NilError(t, err)
+		assert.Equal(t, len(inv.Entries), 6)
+		assert.Equal(t, inv.Identified, false)
+	})
+
+	t.Run("Adds the checksums of the checksums file", func(t *testing.T) {
+		t.Parallel()
+
+		dir := testDir(t)
+		assert.NilError(t, inventory.Remove(dir))
+		assert.NilError(t, inventory.WriteChecksums(dir, inventory.Checksums{
+			"content/d_0000001/00000001.jp2": {"MD5": "827ccb0eea8a706c4c34a16891f84e7b"},
+			"content/d_0000001/missing.jp2":  {"MD5": "d41d8cd98f00b204e9800998ecf8427e"},
+		}))
+
+		inv, err := inventory.Load(dir)
+		assert.NilError(t, err)
+
+		sum, ok := inv.Checksum("content/d_0000001/00000001.jp2", "MD5")
+		assert.Assert(t, ok)
+		assert.Equal(t, sum, "827ccb0eea8a706c4c34a16891f84e7b")
+
+		_, ok = inv.Entry("content/d_0000001/missing.jp2")
+		assert.Assert(t, !ok)
+	})
+
+	t.Run("Errors when the inventory is invalid", func(t *testing.T) {
+		t.Parallel()
+
+		dir := testDir(t)
+		tfs.Apply(t, tfs.DirFromPath(t, filepath.Dir(dir)), tfs.WithFile(".SIP_20201201_Vecteur.inventory.json", "{"))
+
+		_, err := inventory.Load(dir)
+		assert.ErrorContains(t, err, "inventory: decode:")
+	})
+
+	t.Run("Errors when the checksums file is invalid", func(t *testing.T) {
+		t.Parallel()
+
+		dir := testDir(t)
+		tfs.Apply(t, tfs.DirFromPath(t, filepath.Dir(dir)), tfs.WithFile(".SIP_20201201_Vecteur.checksums.json", "{"))
+
+		_, err := inventory.Load(dir)
+		assert.ErrorContains(t, err, "inventory: decode checksums:")
+	})
+}
+
+func TestRemove(t *testing.T) {
+	t.Parallel()
+
+	dir := testDir(t)
+	assert.NilError(t, inventory.WriteChecksums(dir, inventory.Checksums{}))
+	assert.NilError(t, inventory.Remove(dir))
+	assert.NilError(t, inventory.Remove(dir))
+
+	_, err := inventory.Read(dir)
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	_, err = os.Stat(inventory.ChecksumsPath(dir))
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestResume(t *testing.T) {
+	t.Parallel()
+
+	dir := testDir(t)
+	prev, err := inventory.Scan(dir)
+	assert.NilError(t, err)
+	jp2 := &fformat.FileFormat{Namespace: "PRONOM", ID: "x-fmt/392"}
+	prev.Entries[3].Format = jp2
+	prev.Entries[5].Format = &fformat.FileFormat{Namespace: "PRONOM", ID: "fmt/101"}
+	prev.Entries[5].Size = 1
+
+	inv, err := inventory.Scan(dir)
+	assert.NilError(t, err)
+
+	// The format of the file modified since is not copied.
+	assert.Equal(t, inv.Resume(prev), 1)
+	assert.DeepEqual(t, inv.Entries[3].Format, jp2)
+	assert.Assert(t, inv.Entries[5].Format == nil)
+}
+
+func TestFiles(t *testing.T) {
+	t.Parallel()
+
+	inv, err := inventory.Scan(testDir(t))
+	assert.NilError(t, err)
+
+	assert.DeepEqual(t, inv.Files("."), []string{
+		"content/d_0000001/00000001.jp2",
+		"header/metadata.xml",
+	})
+	assert.DeepEqual(t, inv.Files("content"), []string{"d_0000001/00000001.jp2"})
+	assert.Assert(t, inv.Files("cont") == nil)
+}
+
+func TestFormats(t *testing.T) {
+	t.Parallel()
+
+	jp2 := &fformat.FileFormat{Namespace: "PRONOM", ID: "x-fmt/392"}
+
+	t.Run("Returns the inventory formats", func(t *testing.T) {
+		t.Parallel()
+
+		dir := testDir(t)
+		s := sip.SIP{
+			Type:        enums.SIPTypeDigitizedSIP,
+			Path:        dir,
+			ContentPath: filepath.Join(dir, "content"),
+		}
+		inv, err := inventory.Scan(dir)
+		assert.NilError(t, err)
+		inv.Identified = true
+		inv.Entries[3].Format = jp2
+
+		got, err := inv.Formats(context.Background(), nil, s)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, got, fformat.FileFormats{
+			filepath.Join(dir, "content", "d_0000001", "00000001.jp2"): jp2,
+		})
+	})
+
+	t.Run("Identifies the formats when not in the inventory", func(t *testing.T) {
+		t.Parallel()
+
+		dir := testDir(t)
+		s := sip.SIP{
+			Type:        enums.SIPTypeDigitizedSIP,
+			Path:        dir,
+			ContentPath: filepath.Join(dir, "content"),
+		}
+		inv, err := inventory.Scan(dir)
+		assert.NilError(t, err)
+
+		idr := fake_fformat.NewMockIdentifier(gomock.NewController(t))
+		idr.EXPECT().Identify(filepath.Join(dir, "content", "d_0000001", "00000001.jp2")).Return(jp2, nil)
+
+		got, err := inv.Formats(context.Background(), idr, s)
+		assert.NilError(t, err)
+		assert.DeepEqual(t, got, fformat.FileFormats{
+			filepath.Join(dir, "content", "d_0000001", "00000001.jp2"): jp2,
+		})
+	})
+}
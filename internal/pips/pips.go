@@ -1,6 +1,9 @@
 package pips
 
 import (
+	"errors"
+	"fmt"
+	"os"
 	"path/filepath"
 	"strings"
 
@@ -54,3 +57,32 @@ func (p PIP) ConvertSIPPath(path string) string {
 
 	return ""
 }
+
+// Verify checks that the PIP directory has the layout expected for its type,
+// returning an error listing the missing paths.
+func (p PIP) Verify() error {
+	var errs error
+
+	check := func(path string, dir bool) {
+		fi, err := os.Stat(filepath.Join(p.Path, path))
+		if err != nil {
+			errs = errors.Join(errs, fmt.Errorf("missing %s", path))
+		} else if fi.IsDir() != dir {
+			errs = errors.Join(errs, fmt.Errorf("unexpected file type: %s", path))
+		}
+	}
+
+	check("metadata", true)
+	check(filepath.Join("objects", p.Name(), "content"), true)
+	check(filepath.Join("objects", p.Name(), "header", "metadata.xml"), false)
+
+	if p.Type == enums.SIPTypeDigitizedSIP || p.Type == enums.SIPTypeDigitizedAIP {
+		check(filepath.Join("metadata", "Prozess_Digitalisierung_PREMIS.xml"), false)
+	}
+	if p.Type == enums.SIPTypeDigitizedAIP || p.Type == enums.SIPTypeBornDigitalAIP {
+		check(filepath.Join("metadata", "UpdatedAreldaMetadata.xml"), false)
+		check(filepath.Join("metadata", p.Name()+"-premis.xml"), false)
+	}
+
+	return errs
+}
@@ -4,6 +4,7 @@ import (
 	"testing"
 
 	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
 
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/enums"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/pips"
@@ -104,3 +105,89 @@ func TestConvertSIPPath(t *testing.T) {
 	)
 	assert.Equal(t, p.ConvertSIPPath("header/xsd/arelda.xsd"), "")
 }
+
+func TestVerify(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name    string
+		sipType enums.SIPType
+		layout  []fs.PathOp
+		wantErr string
+	}{
+		{
+			name:    "Verifies a born digital SIP PIP",
+			sipType: enums.SIPTypeBornDigitalSIP,
+			layout: []fs.PathOp{
+				fs.WithDir("metadata"),
+				fs.WithDir("objects",
+					fs.WithDir("PIP",
+						fs.WithDir("content"),
+						fs.WithDir("header", fs.WithFile("metadata.xml", "")),
+					),
+				),
+			},
+		},
+		{
+			name:    "Verifies a digitized AIP PIP",
+			sipType: enums.SIPTypeDigitizedAIP,
+			layout: []fs.PathOp{
+				fs.WithDir("metadata",
+					fs.WithFile("Prozess_Digitalisierung_PREMIS.xml", ""),
+					fs.WithFile("UpdatedAreldaMetadata.xml", ""),
+					fs.WithFile("PIP-premis.xml", ""),
+				),
+				fs.WithDir("objects",
+					fs.WithDir("PIP",
+						fs.WithDir("content"),
+						fs.WithDir("header", fs.WithFile("metadata.xml", "")),
+					),
+				),
+			},
+		},
+		{
+			name:    "Errors when the digitized AIP metadata files are missing",
+			sipType: enums.SIPTypeDigitizedAIP,
+			layout: []fs.PathOp{
+				fs.WithDir("metadata"),
+				fs.WithDir("objects",
+					fs.WithDir("PIP",
+						fs.WithDir("content"),
+						fs.WithDir("header", fs.WithFile("metadata.xml", "")),
+					),
+				),
+			},
+			wantErr: `missing metadata/Prozess_Digitalisierung_PREMIS.xml
+missing metadata/UpdatedAreldaMetadata.xml
+missing metadata/PIP-premis.xml`,
+		},
+		{
+			name:    "Errors when the objects directory has an unexpected layout",
+			sipType: enums.SIPTypeDigitizedSIP,
+			layout: []fs.PathOp{
+				fs.WithDir("metadata",
+					fs.WithFile("Prozess_Digitalisierung_PREMIS.xml", ""),
+				),
+				fs.WithDir("objects",
+					fs.WithDir("PIP",
+						fs.WithFile("content", ""),
+					),
+				),
+			},
+			wantErr: `unexpected file type: objects/PIP/content
+missing objects/PIP/header/metadata.xml`,
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			path := fs.NewDir(t, "", fs.WithDir("PIP", tt.layout...)).Join("PIP")
+			err := pips.New(path, tt.sipType).Verify()
+			if tt.wantErr != "" {
+				assert.Error(t, err, tt.wantErr)
+				return
+			}
+			assert.NilError(t, err)
+		})
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io/fs"
 	"path/filepath"
+	"strconv"
 	"time"
 
 	"github.com/beevik/etree"
@@ -29,14 +30,38 @@ type Object struct {
 	IdType           string
 	IdValue          string
 	OriginalName     string
+	Fixity           []Fixity
+	Size             *int64
+	Format           *Format
 	EventIdentifiers []ObjectEventIdentifier
 }
 
+// Fixity is a message digest of an object.
+type Fixity struct {
+	Algorithm  string // Algorithm of the digest (e.g. "MD5").
+	Digest     string // Digest is the hexadecimal encoded message digest.
+	Originator string // Originator of the digest (e.g. "SIP manifest").
+}
+
+// Format describes the file format of an object. An object without a Format
+// is added with an empty format name.
+type Format struct {
+	Name         string // Name of the format (e.g. "JPEG 2000 JP2").
+	Version      string // Version of the format (e.g. "1.7").
+	RegistryName string // RegistryName of the format registry (e.g. "PRONOM").
+	RegistryKey  string // RegistryKey is the format registry ID (e.g. "x-fmt/392").
+	Basis        string // Basis of the format identification.
+}
+
 type EventSummary struct {
 	Type          string
 	Detail        string
 	Outcome       string
 	OutcomeDetail string
+
+	// Failures are added as outcome details after OutcomeDetail, one per
+	// failure.
+	Failures []string
 }
 
 type Event struct {
@@ -203,17 +228,68 @@ func addObjectElementIfNeeded(PREMISEl *etree.Element, object Object) {
 	// Add object characteristics element.
 	objectCharEl := objectEl.CreateElement("premis:objectCharacteristics")
 
-	formatEl := objectCharEl.CreateElement("premis:format")
+	for _, fixity := range object.Fixity {
+		fixityEl := objectCharEl.CreateElement("premis:fixity")
 
-	formatDesEl := formatEl.CreateElement("premis:formatDesignation")
+		algorithmEl := fixityEl.CreateElement("premis:messageDigestAlgorithm")
+		algorithmEl.CreateText(fixity.Algorithm)
 
-	formatDesEl.CreateElement("premis:formatName")
+		digestEl := fixityEl.CreateElement("premis:messageDigest")
+		digestEl.CreateText(fixity.Digest)
+
+		if fixity.Originator != "" {
+			originatorEl := fixityEl.CreateElement("premis:messageDigestOriginator")
+			originatorEl.CreateText(fixity.Originator)
+		}
+	}
+
+	if object.Size != nil {
+		sizeEl := objectCharEl.CreateElement("premis:size")
+		sizeEl.CreateText(strconv.FormatInt(*object.Size, 10))
+	}
+
+	formatEl := objectCharEl.CreateElement("premis:format")
+
+	addFormatElements(formatEl, object.Format)
 
 	// Add original name element.
 	originalNameEl := objectEl.CreateElement("premis:originalName")
 	originalNameEl.CreateText(object.OriginalName)
 }
 
+func addFormatElements(formatEl *etree.Element, format *Format) {
+	formatDesEl := formatEl.CreateElement("premis:formatDesignation")
+	formatNameEl := formatDesEl.CreateElement("premis:formatName")
+
+	if format == nil {
+		return
+	}
+
+	formatNameEl.CreateText(format.Name)
+	if format.Version != "" {
+		formatVersionEl := formatDesEl.CreateElement("premis:formatVersion")
+		formatVersionEl.CreateText(format.Version)
+	}
+
+	if format.RegistryKey != "" {
+		formatRegistryEl := formatEl.CreateElement("premis:formatRegistry")
+
+		formatRegistryNameEl := formatRegistryEl.CreateElement("premis:formatRegistryName")
+		formatRegistryNameEl.CreateText(format.RegistryName)
+
+		formatRegistryKeyEl := formatRegistryEl.CreateElement("premis:formatRegistryKey")
+		formatRegistryKeyEl.CreateText(format.RegistryKey)
+
+		formatRegistryRoleEl := formatRegistryEl.CreateElement("premis:formatRegistryRole")
+		formatRegistryRoleEl.CreateText("specification")
+	}
+
+	if format.Basis != "" {
+		formatNoteEl := formatEl.CreateElement("premis:formatNote")
+		formatNoteEl.CreateText(fmt.Sprintf("Identification basis: %s", format.Basis))
+	}
+}
+
 func AddEventElement(PREMISEl *etree.Element, event Event) {
 	eventEl := etree.NewElement("premis:event")
 
@@ -249,6 +325,12 @@ func AddEventElement(PREMISEl *etree.Element, event Event) {
 		outcomeDetailNoteEl.CreateText(event.Summary.OutcomeDetail)
 	}
 
+	for _, failure := range event.Summary.Failures {
+		outcomeDetailEl := outcomeInfoEl.CreateElement("premis:eventOutcomeDetail")
+		outcomeDetailNoteEl := outcomeDetailEl.CreateElement("premis:eventOutcomeDetailNote")
+		outcomeDetailNoteEl.CreateText(failure)
+	}
+
 	// The order of PREMIS entities is important (i.e. first objects, then
 	// events, then agents) so we need to find the correct place to insert this
 	// event element.
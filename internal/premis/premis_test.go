@@ -32,6 +32,38 @@ const premisObjectAddContent = `<?xml version="1.0" encoding="UTF-8"?>
 </premis:premis>
 `
 
+const premisObjectWithCharacteristicsAddContent = `<?xml version="1.0" encoding="UTF-8"?>
+<premis:premis xmlns:premis="http://www.loc.gov/premis/v3" xmlns:xlink="http://www.w3.org/1999/xlink" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:schemaLocation="http://www.loc.gov/premis/v3 https://www.loc.gov/standards/premis/premis.xsd" version="3.0">
+  <premis:object xsi:type="premis:file">
+    <premis:objectIdentifier>
+      <premis:objectIdentifierType>uuid</premis:objectIdentifierType>
+      <premis:objectIdentifierValue>c74a85b7-919b-409e-8209-9c7ebe0e7945</premis:objectIdentifierValue>
+    </premis:objectIdentifier>
+    <premis:objectCharacteristics>
+      <premis:fixity>
+        <premis:messageDigestAlgorithm>MD5</premis:messageDigestAlgorithm>
+        <premis:messageDigest>827ccb0eea8a706c4c34a16891f84e7b</premis:messageDigest>
+        <premis:messageDigestOriginator>SIP manifest</premis:messageDigestOriginator>
+      </premis:fixity>
+      <premis:size>5</premis:size>
+      <premis:format>
+        <premis:formatDesignation>
+          <premis:formatName>JPEG File Interchange Format</premis:formatName>
+          <premis:formatVersion>1.01</premis:formatVersion>
+        </premis:formatDesignation>
+        <premis:formatRegistry>
+          <premis:formatRegistryName>PRONOM</premis:formatRegistryName>
+          <premis:formatRegistryKey>fmt/43</premis:formatRegistryKey>
+          <premis:formatRegistryRole>specification</premis:formatRegistryRole>
+        </premis:formatRegistry>
+        <premis:formatNote>Identification basis: extension match jpg; byte match at [[0 14] [24 2]]</premis:formatNote>
+      </premis:format>
+    </premis:objectCharacteristics>
+    <premis:originalName>data/objects/test_transfer/content/cat.jpg</premis:originalName>
+  </premis:object>
+</premis:premis>
+`
+
 const premisObjectAndEventAddContent = `<?xml version="1.0" encoding="UTF-8"?>
 <premis:premis xmlns:premis="http://www.loc.gov/premis/v3" xmlns:xlink="http://www.w3.org/1999/xlink" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance" xsi:schemaLocation="http://www.loc.gov/premis/v3 https://www.loc.gov/standards/premis/premis.xsd" version="3.0">
   <premis:object xsi:type="premis:file">
@@ -188,6 +220,40 @@ func TestAppendPREMISObjectXML(t *testing.T) {
 	assert.Equal(t, xml, premisObjectAddContent)
 }
 
+func TestAppendPREMISObjectXMLWithCharacteristics(t *testing.T) {
+	t.Parallel()
+
+	size := int64(5)
+
+	doc, err := premis.NewDoc()
+	assert.NilError(t, err)
+
+	err = premis.AppendObjectXML(doc, premis.Object{
+		IdType:       "uuid",
+		IdValue:      "c74a85b7-919b-409e-8209-9c7ebe0e7945",
+		OriginalName: "data/objects/test_transfer/content/cat.jpg",
+		Fixity: []premis.Fixity{{
+			Algorithm:  "MD5",
+			Digest:     "827ccb0eea8a706c4c34a16891f84e7b",
+			Originator: "SIP manifest",
+		}},
+		Size: &size,
+		Format: &premis.Format{
+			Name:         "JPEG File Interchange Format",
+			Version:      "1.01",
+			RegistryName: "PRONOM",
+			RegistryKey:  "fmt/43",
+			Basis:        "extension match jpg; byte match at [[0 14] [24 2]]",
+		},
+	})
+	assert.NilError(t, err)
+
+	doc.Indent(2)
+	xml, err := doc.WriteToString()
+	assert.NilError(t, err)
+	assert.Equal(t, xml, premisObjectWithCharacteristicsAddContent)
+}
+
 func TestAppendPREMISEventXML(t *testing.T) {
 	t.Parallel()
 
@@ -242,6 +308,39 @@ func TestAppendPREMISEventXML(t *testing.T) {
 	assert.Equal(t, xml, premisObjectAndEventAddContent)
 }
 
+func TestAddEventElementFailures(t *testing.T) {
+	t.Parallel()
+
+	doc, err := premis.NewDoc()
+	assert.NilError(t, err)
+
+	PREMISEl := doc.FindElement("/premis:premis")
+	premis.AddEventElement(PREMISEl, premis.Event{
+		IdType:  "UUID",
+		IdValue: "c74a85b7-919b-409e-8209-9c7ebe0e7945",
+		Summary: premis.EventSummary{
+			Type:          "validation",
+			Detail:        "name=\"Verify SIP checksums\"",
+			Outcome:       "invalid",
+			OutcomeDetail: "Content error: SIP checksums do not match file contents.",
+			Failures: []string{
+				"Checksum mismatch for \"content/d_0000001/00000001.jp2\"",
+				"Checksum mismatch for \"content/d_0000001/00000002.jp2\"",
+			},
+		},
+	})
+
+	var notes []string
+	for _, el := range doc.FindElements("//premis:eventOutcomeDetailNote") {
+		notes = append(notes, el.Text())
+	}
+	assert.DeepEqual(t, notes, []string{
+		"Content error: SIP checksums do not match file contents.",
+		"Checksum mismatch for \"content/d_0000001/00000001.jp2\"",
+		"Checksum mismatch for \"content/d_0000001/00000002.jp2\"",
+	})
+}
+
 func TestAppendPREMISAgentXML(t *testing.T) {
 	t.Parallel()
 
@@ -0,0 +1,202 @@
+// Package progress reports the progress of long running activities, so it can
+// be inspected with the workflow progress query.
+//
+// The counters are kept in the memory of the worker process running the
+// activities, not in the workflow state. They are only reliable when a single
+// worker process runs the workflows and activities: a query answered by
+// another worker, or after a worker restart or a workflow replay, returns
+// missing or stale counters.
+package progress
+
+import (
+	"context"
+	"encoding/json"
+	"maps"
+	"sync"
+
+	"github.com/artefactual-sdps/enduro/pkg/childwf"
+	temporalsdk_activity "go.temporal.io/sdk/activity"
+)
+
+// QueryType is the workflow query returning the workflow Status.
+const QueryType = "progress"
+
+// Progress counter names.
+const (
+	// FilesHashed counts the SIP files whose checksum has been verified.
+	FilesHashed = "filesHashed"
+
+	// FilesIdentified counts the SIP files whose format has been identified.
+	FilesIdentified = "filesIdentified"
+
+	// FilesInventoried counts the SIP files added to the SIP inventory.
+	FilesInventoried = "filesInventoried"
+
+	// FilesValidated counts the SIP file validations done by the file format
+	// validators.
+	FilesValidated = "filesValidated"
+
+	// APISAnalysis is the APIS import task analysis progress in percent.
+	APISAnalysis = "apisAnalysisPercent"
+)
+
+// Counter is a progress counter. Total is zero when it's not known in
+// advance.
+type Counter struct {
+	Done  int64 `json:"done"`
+	Total int64 `json:"total,omitempty"`
+}
+
+// Update is the progress reported by an activity in its heartbeat details.
+type Update struct {
+	Name    string  `json:"name"`
+	Counter Counter `json:"counter"`
+}
+
+// Heartbeat is the activity heartbeat details recorded by a Reporter.
+type Heartbeat struct {
+	Update
+
+	// Details are the optional details reported with the counter.
+	Details any `json:"details,omitempty"`
+}
+
+// Status is the workflow progress returned by the progress query.
+type Status struct {
+	// Step is the name of the step in progress, empty when the workflow is
+	// between steps or completed.
+	Step string `json:"step"`
+
+	// Tasks is the current workflow task list.
+	Tasks []*childwf.Task `json:"tasks"`
+
+	// Counters are the last progress counters reported by the activities
+	// running in the worker answering the query, by counter name. They are a
+	// hint only, see the package documentation.
+	Counters map[string]Counter `json:"counters,omitempty"`
+}
+
+// Store keeps the last progress counters reported by the activities running
+// in the worker process, by workflow run ID, so the workflow progress query
+// can return them without recording them in the workflow history. A nil Store
+// keeps no counters.
+type Store struct {
+	mu       sync.Mutex
+	counters map[string]map[string]Counter
+}
+
+func NewStore() *Store {
+	return &Store{counters: map[string]map[string]Counter{}}
+}
+
+// Set records the named counter of the runID workflow execution.
+func (s *Store) Set(runID, name string, c Counter) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.counters[runID] == nil {
+		s.counters[runID] = map[string]Counter{}
+	}
+	s.counters[runID][name] = c
+}
+
+// Counters returns a copy of the counters of the runID workflow execution.
+func (s *Store) Counters(runID string) map[string]Counter {
+	if s == nil {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return maps.Clone(s.counters[runID])
+}
+
+// Delete removes the counters of the runID workflow execution.
+func (s *Store) Delete(runID string) {
+	if s == nil {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.counters, runID)
+}
+
+type storeKey struct{}
+
+// WithStore returns a copy of ctx with s, it's meant to be used as the worker
+// background activity context so activities can record their progress in s.
+func WithStore(ctx context.Context, s *Store) context.Context {
+	return context.WithValue(ctx, storeKey{}, s)
+}
+
+// Reporter reports the progress of a single counter for an activity
+// execution.
+type Reporter struct {
+	ctx   context.Context
+	name  string
+	store *Store
+}
+
+// NewReporter returns a Reporter for the named counter. Reporting progress
+// outside of an activity, e.g. from the sfa-validate command, is a no-op.
+func NewReporter(ctx context.Context, name string) *Reporter {
+	s, _ := ctx.Value(storeKey{}).(*Store)
+
+	return &Reporter{ctx: ctx, name: name, store: s}
+}
+
+// Report records the counter as the activity heartbeat details, and in the
+// worker Store if there is one. Heartbeats are throttled by the Temporal SDK,
+// and nothing is recorded in the workflow history.
+func (r *Reporter) Report(c Counter) {
+	r.ReportDetails(c, nil)
+}
+
+// ReportDetails is like Report, but it also records details in the activity
+// heartbeat, e.g. a checkpoint allowing a retried attempt to resume the work
+// done, see LastDetails. Details are not recorded in the Store.
+func (r *Reporter) ReportDetails(c Counter, details any) {
+	if r == nil || !temporalsdk_activity.IsActivity(r.ctx) {
+		return
+	}
+
+	u := Update{Name: r.name, Counter: c}
+	temporalsdk_activity.RecordHeartbeat(r.ctx, Heartbeat{Update: u, Details: details})
+
+	r.store.Set(temporalsdk_activity.GetInfo(r.ctx).WorkflowExecution.RunID, r.name, c)
+}
+
+// LastDetails decodes the heartbeat details recorded by a previous attempt of
+// the activity into details, and returns the counter reported. It returns
+// false if there are no heartbeat details for the named counter, or they
+// can't be decoded.
+func LastDetails(ctx context.Context, name string, details any) (Counter, bool) {
+	if !temporalsdk_activity.IsActivity(ctx) || !temporalsdk_activity.HasHeartbeatDetails(ctx) {
+		return Counter{}, false
+	}
+
+	var h struct {
+		Update
+		Details json.RawMessage `json:"details"`
+	}
+	if err := temporalsdk_activity.GetHeartbeatDetails(ctx, &h); err != nil {
+		return Counter{}, false
+	}
+	if h.Name != name {
+		return Counter{}, false
+	}
+	if len(h.Details) > 0 && details != nil {
+		if err := json.Unmarshal(h.Details, details); err != nil {
+			return Counter{}, false
+		}
+	}
+
+	return h.Counter, true
+}
@@ -0,0 +1,103 @@
+package progress_test
+
+import (
+	"context"
+	"testing"
+
+	temporalsdk_activity "go.temporal.io/sdk/activity"
+	temporalsdk_testsuite "go.temporal.io/sdk/testsuite"
+	temporalsdk_worker "go.temporal.io/sdk/worker"
+	"gotest.tools/v3/assert"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/progress"
+)
+
+const runID = "default-test-run-id"
+
+func TestReporter(t *testing.T) {
+	t.Parallel()
+
+	counters := []progress.Counter{
+		{Done: 0, Total: 3},
+		{Done: 1, Total: 3},
+		{Done: 2, Total: 3},
+		{Done: 3, Total: 3},
+	}
+
+	tests := []struct {
+		name  string
+		store *progress.Store
+		want  map[string]progress.Counter
+	}{
+		{
+			name:  "Records the last counter in the store",
+			store: progress.NewStore(),
+			want:  map[string]progress.Counter{progress.FilesHashed: counters[3]},
+		},
+		{
+			name: "Reports progress without store",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			ts := &temporalsdk_testsuite.WorkflowTestSuite{}
+			env := ts.NewTestActivityEnvironment()
+
+			bgCtx := context.Background()
+			if tt.store != nil {
+				bgCtx = progress.WithStore(bgCtx, tt.store)
+			}
+			env.SetWorkerOptions(temporalsdk_worker.Options{BackgroundActivityContext: bgCtx})
+
+			env.RegisterActivityWithOptions(
+				func(ctx context.Context) error {
+					r := progress.NewReporter(ctx, progress.FilesHashed)
+					for _, c := range counters {
+						r.Report(c)
+					}
+					return nil
+				},
+				temporalsdk_activity.RegisterOptions{Name: "hash-files"},
+			)
+
+			_, err := env.ExecuteActivity("hash-files")
+			assert.NilError(t, err)
+			assert.DeepEqual(t, tt.store.Counters(runID), tt.want)
+		})
+	}
+}
+
+func TestReporterOutsideActivity(t *testing.T) {
+	t.Parallel()
+
+	s := progress.NewStore()
+	r := progress.NewReporter(progress.WithStore(context.Background(), s), progress.FilesHashed)
+	r.Report(progress.Counter{Done: 1, Total: 1})
+
+	assert.Equal(t, len(s.Counters(runID)), 0)
+}
+
+func TestStore(t *testing.T) {
+	t.Parallel()
+
+	s := progress.NewStore()
+	s.Set("run-1", progress.FilesHashed, progress.Counter{Done: 1, Total: 2})
+	s.Set("run-1", progress.FilesIdentified, progress.Counter{Done: 1})
+	s.Set("run-2", progress.FilesHashed, progress.Counter{Done: 2, Total: 2})
+
+	got := s.Counters("run-1")
+	assert.DeepEqual(t, got, map[string]progress.Counter{
+		progress.FilesHashed:     {Done: 1, Total: 2},
+		progress.FilesIdentified: {Done: 1},
+	})
+
+	// Counters returns a copy.
+	got[progress.FilesHashed] = progress.Counter{}
+	assert.DeepEqual(t, s.Counters("run-1")[progress.FilesHashed], progress.Counter{Done: 1, Total: 2})
+
+	s.Delete("run-1")
+	assert.Assert(t, s.Counters("run-1") == nil)
+	assert.DeepEqual(t, s.Counters("run-2"), map[string]progress.Counter{progress.FilesHashed: {Done: 2, Total: 2}})
+}
@@ -0,0 +1,81 @@
+package report
+
+// Severity is the severity of a validation failure.
+type Severity string
+
+const (
+	// SeverityError is a failure that stops the SIP from being ingested.
+	SeverityError Severity = "error"
+
+	// SeverityWarning is a failure that is reported but doesn't stop the
+	// SIP from being ingested.
+	SeverityWarning Severity = "warning"
+)
+
+// Code is a stable identifier of a kind of validation failure, meant to be
+// used by tooling instead of parsing the failure message.
+type Code string
+
+const (
+	CodeBagInvalid Code = "bag.invalid"
+
+	CodeSIPMissingTopLevelDirectory Code = "sip.missing-top-level-directory"
+	CodeSIPUnidentified             Code = "sip.unidentified"
+	CodeSIPInvalidName              Code = "sip.invalid-name"
+
+	CodeStructureEmptySIP                Code = "structure.empty-sip"
+	CodeStructureEmptyDirectory          Code = "structure.empty-directory"
+	CodeStructureInvalidName             Code = "structure.invalid-name"
+	CodeStructureMissingContentDirectory Code = "structure.missing-content-directory"
+	CodeStructureMissingXSDDirectory     Code = "structure.missing-xsd-directory"
+	CodeStructureMissingFile             Code = "structure.missing-file"
+	CodeStructureUnexpectedDirectory     Code = "structure.unexpected-directory"
+	CodeStructureUnexpectedFile          Code = "structure.unexpected-file"
+	CodeStructureMultipleDossiers        Code = "structure.multiple-dossiers"
+
+	CodeManifestUnsupportedSchemaVersion Code = "manifest.unsupported-schema-version"
+	CodeManifestMissingFile              Code = "manifest.missing-file"
+	CodeManifestUnexpectedFile           Code = "manifest.unexpected-file"
+	CodeManifestChecksumMismatch         Code = "manifest.checksum-mismatch"
+
+	CodeFileFormatUnidentified Code = "file-format.unidentified"
+	CodeFileFormatAmbiguous    Code = "file-format.ambiguous"
+	CodeFileFormatDisallowed   Code = "file-format.disallowed"
+	CodeFileFormatInvalid      Code = "file-format.invalid"
+
+	CodeMetadataInvalid Code = "metadata.invalid"
+
+	CodeDigitizationPREMISInvalid          Code = "digitization-premis.invalid"
+	CodeDigitizationPREMISMissingFile      Code = "digitization-premis.missing-file"
+	CodeDigitizationPREMISFormatMismatch   Code = "digitization-premis.format-mismatch"
+	CodeDigitizationPREMISSizeMismatch     Code = "digitization-premis.size-mismatch"
+	CodeDigitizationPREMISChecksumMismatch Code = "digitization-premis.checksum-mismatch"
+
+	CodeLogicalMetadataMissing Code = "logical-metadata.missing"
+	CodeLogicalMetadataInvalid Code = "logical-metadata.invalid"
+)
+
+// Entry is a single failure found by a validation check.
+type Entry struct {
+	// Check is the name of the check that found the failure.
+	Check string `json:"check"`
+
+	// Task is the name of the preprocessing task that ran the check.
+	Task string `json:"task,omitempty"`
+
+	Severity Severity `json:"severity"`
+
+	// Path of the affected file or directory, relative to the SIP root
+	// directory. It's empty when the failure affects the whole SIP.
+	Path string `json:"path,omitempty"`
+
+	Code Code `json:"code"`
+
+	// Message is the human readable failure description.
+	Message string `json:"message"`
+
+	// Tool and ToolVersion identify the external software that found the
+	// failure, if any.
+	Tool        string `json:"tool,omitempty"`
+	ToolVersion string `json:"toolVersion,omitempty"`
+}
@@ -0,0 +1,169 @@
+package report
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/artefactual-sdps/enduro/pkg/childwf"
+)
+
+const (
+	// Filename is the name of the validation report file.
+	Filename = "validation-report.json"
+
+	// CustomMetadataKey is the workflow result custom metadata key of the
+	// validation report summary.
+	CustomMetadataKey = "validationReport"
+
+	// MaxCustomMetadataEntries is the maximum number of entries included in
+	// the workflow result custom metadata, as it's stored in the workflow
+	// history. The full list is in the validation report file.
+	MaxCustomMetadataEntries = 100
+)
+
+// Report is a machine-readable record of the preprocessing tasks run against a
+// SIP, including their outcome and the notes and failures reported to the
+// user, and the failures found by each validation check.
+type Report struct {
+	SIPID   string  `json:"sipId"`
+	SIPName string  `json:"sipName"`
+	Outcome string  `json:"outcome"`
+	Tasks   []Task  `json:"tasks"`
+	Entries []Entry `json:"entries"`
+}
+
+// Task is a single preprocessing task in a Report.
+type Task struct {
+	Name        string    `json:"name"`
+	Outcome     string    `json:"outcome"`
+	Message     string    `json:"message"`
+	StartedAt   time.Time `json:"startedAt"`
+	CompletedAt time.Time `json:"completedAt"`
+}
+
+// New returns an empty report for the given SIP.
+func New(sipID, sipName string) *Report {
+	return &Report{
+		SIPID:   sipID,
+		SIPName: sipName,
+		Tasks:   []Task{},
+		Entries: []Entry{},
+	}
+}
+
+// Add appends entries to the report.
+func (r *Report) Add(entries ...Entry) {
+	r.Entries = append(r.Entries, entries...)
+}
+
+// AddFor appends entries found by the named preprocessing task to the report.
+func (r *Report) AddFor(task string, entries ...Entry) {
+	for _, e := range entries {
+		e.Task = task
+		r.Entries = append(r.Entries, e)
+	}
+}
+
+// WithResult returns a copy of the report with the outcome and the tasks of a
+// preprocessing workflow result.
+func (r Report) WithResult(res *childwf.PreprocessingResult) Report {
+	r.Outcome = outcome(res.Outcome)
+	r.Tasks = make([]Task, 0, len(res.Tasks))
+	for _, t := range res.Tasks {
+		r.Tasks = append(r.Tasks, Task{
+			Name:        t.Name,
+			Outcome:     taskOutcome(t.Outcome),
+			Message:     t.Message,
+			StartedAt:   t.StartedAt,
+			CompletedAt: t.CompletedAt,
+		})
+	}
+
+	return r
+}
+
+// Summary is the validation report summary returned in the workflow result
+// custom metadata.
+type Summary struct {
+	// Errors and Warnings are the number of entries by severity.
+	Errors   int `json:"errors"`
+	Warnings int `json:"warnings"`
+
+	// Entries are the first MaxCustomMetadataEntries report entries.
+	Entries []Entry `json:"entries"`
+
+	// Truncated is true if Entries doesn't list all the report entries.
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// CustomMetadata returns the report summary encoded for the workflow result
+// custom metadata.
+func (r Report) CustomMetadata() (json.RawMessage, error) {
+	sum := Summary{Entries: r.Entries[:min(len(r.Entries), MaxCustomMetadataEntries)]}
+	if sum.Entries == nil {
+		sum.Entries = []Entry{}
+	}
+	sum.Truncated = len(sum.Entries) < len(r.Entries)
+	for _, e := range r.Entries {
+		switch e.Severity {
+		case SeverityError:
+			sum.Errors++
+		case SeverityWarning:
+			sum.Warnings++
+		}
+	}
+
+	data, err := json.Marshal(sum)
+	if err != nil {
+		return nil, fmt.Errorf("marshal validation report: %v", err)
+	}
+
+	return data, nil
+}
+
+// Write writes the report as JSON to a Filename file in dir and returns the
+// file path.
+func Write(dir string, r Report) (string, error) {
+	b, err := json.MarshalIndent(r, "", "    ")
+	if err != nil {
+		return "", fmt.Errorf("marshal validation report: %v", err)
+	}
+
+	path := filepath.Join(dir, Filename)
+	if err := os.WriteFile(path, b, 0o644); err != nil { // #nosec G306 -- report must be readable by depositors.
+		return "", fmt.Errorf("write validation report: %v", err)
+	}
+
+	return path, nil
+}
+
+func outcome(o childwf.Outcome) string {
+	switch o {
+	case childwf.OutcomeSuccess:
+		return "success"
+	case childwf.OutcomeSystemError:
+		return "system error"
+	case childwf.OutcomeContentError:
+		return "content error"
+	case childwf.OutcomeCanceled:
+		return "canceled"
+	default:
+		return "unknown"
+	}
+}
+
+func taskOutcome(o childwf.TaskOutcome) string {
+	switch o {
+	case childwf.TaskOutcomeSuccess:
+		return "success"
+	case childwf.TaskOutcomeSystemFailure:
+		return "system failure"
+	case childwf.TaskOutcomeValidationFailure:
+		return "validation failure"
+	default:
+		return "unspecified"
+	}
+}
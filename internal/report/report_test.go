@@ -0,0 +1,174 @@
+package report_test
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/artefactual-sdps/enduro/pkg/childwf"
+	"gotest.tools/v3/assert"
+	"gotest.tools/v3/fs"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/report"
+)
+
+var entry = report.Entry{
+	Check:    "validate-structure",
+	Severity: report.SeverityError,
+	Path:     "content/d_0000001",
+	Code:     report.CodeStructureEmptyDirectory,
+	Message:  "An empty directory has been found - content/d_0000001",
+}
+
+func TestWithResult(t *testing.T) {
+	t.Parallel()
+
+	started := time.Date(2024, 6, 1, 10, 0, 0, 0, time.UTC)
+	completed := started.Add(time.Second)
+
+	rep := report.New("52fdfc07-2182-454f-963f-5f0f9a621d72", "SIP_20201201_Vecteur.zip")
+	rep.Add(entry)
+
+	got := rep.WithResult(
+		&childwf.PreprocessingResult{
+			Outcome: childwf.OutcomeContentError,
+			Tasks: []*childwf.Task{
+				{
+					Name:        "Extract SIP",
+					Message:     "SIP extracted",
+					Outcome:     childwf.TaskOutcomeSuccess,
+					StartedAt:   started,
+					CompletedAt: completed,
+				},
+				{
+					Name:        "Validate SIP structure",
+					Message:     "Content error: SIP structure validation has failed.",
+					Outcome:     childwf.TaskOutcomeValidationFailure,
+					StartedAt:   started,
+					CompletedAt: completed,
+				},
+			},
+		},
+	)
+
+	assert.DeepEqual(t, got, report.Report{
+		SIPID:   "52fdfc07-2182-454f-963f-5f0f9a621d72",
+		SIPName: "SIP_20201201_Vecteur.zip",
+		Outcome: "content error",
+		Tasks: []report.Task{
+			{
+				Name:        "Extract SIP",
+				Outcome:     "success",
+				Message:     "SIP extracted",
+				StartedAt:   started,
+				CompletedAt: completed,
+			},
+			{
+				Name:        "Validate SIP structure",
+				Outcome:     "validation failure",
+				Message:     "Content error: SIP structure validation has failed.",
+				StartedAt:   started,
+				CompletedAt: completed,
+			},
+		},
+		Entries: []report.Entry{entry},
+	})
+
+	// The original report is not modified.
+	assert.Equal(t, rep.Outcome, "")
+	assert.Equal(t, len(rep.Tasks), 0)
+}
+
+func TestAddFor(t *testing.T) {
+	t.Parallel()
+
+	rep := report.New("52fdfc07-2182-454f-963f-5f0f9a621d72", "SIP_20201201_Vecteur.zip")
+	rep.AddFor("Validate SIP structure", entry, entry)
+
+	want := entry
+	want.Task = "Validate SIP structure"
+	assert.DeepEqual(t, rep.Entries, []report.Entry{want, want})
+
+	// The added entries are not modified.
+	assert.Equal(t, entry.Task, "")
+}
+
+func TestCustomMetadata(t *testing.T) {
+	t.Parallel()
+
+	warning := entry
+	warning.Severity = report.SeverityWarning
+
+	many := make([]report.Entry, report.MaxCustomMetadataEntries+1)
+	for i := range many {
+		many[i] = entry
+	}
+
+	tests := []struct {
+		name    string
+		entries []report.Entry
+		want    string
+	}{
+		{
+			name: "Encodes an empty report",
+			want: `{"errors":0,"warnings":0,"entries":[]}`,
+		},
+		{
+			name:    "Encodes the report entries",
+			entries: []report.Entry{entry, warning},
+			want: `{"errors":1,"warnings":1,"entries":[` +
+				`{"check":"validate-structure","severity":"error","path":"content/d_0000001","code":"structure.empty-directory","message":"An empty directory has been found - content/d_0000001"},` +
+				`{"check":"validate-structure","severity":"warning","path":"content/d_0000001","code":"structure.empty-directory","message":"An empty directory has been found - content/d_0000001"}` +
+				`]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			rep := report.Report{Entries: tt.entries}
+			got, err := rep.CustomMetadata()
+			assert.NilError(t, err)
+			assert.Equal(t, string(got), tt.want)
+		})
+	}
+
+	t.Run("Caps the report entries", func(t *testing.T) {
+		t.Parallel()
+
+		data, err := report.Report{Entries: many}.CustomMetadata()
+		assert.NilError(t, err)
+
+		var got report.Summary
+		assert.NilError(t, json.Unmarshal(data, &got))
+		assert.Equal(t, got.Errors, report.MaxCustomMetadataEntries+1)
+		assert.Equal(t, len(got.Entries), report.MaxCustomMetadataEntries)
+		assert.Assert(t, got.Truncated)
+	})
+}
+
+func TestWrite(t *testing.T) {
+	t.Parallel()
+
+	rep := report.New("52fdfc07-2182-454f-963f-5f0f9a621d72", "SIP_20201201_Vecteur.zip")
+	rep.Add(entry)
+
+	dir := fs.NewDir(t, "")
+	path, err := report.Write(dir.Path(), *rep)
+	assert.NilError(t, err)
+	assert.Equal(t, path, dir.Join(report.Filename))
+
+	b, err := json.Marshal(rep)
+	assert.NilError(t, err)
+
+	var want, got any
+	assert.NilError(t, json.Unmarshal(b, &want))
+	data, err := os.ReadFile(path)
+	assert.NilError(t, err)
+	assert.NilError(t, json.Unmarshal(data, &got))
+	assert.DeepEqual(t, got, want)
+
+	_, err = report.Write(dir.Join("missing"), *rep)
+	assert.ErrorContains(t, err, "write validation report:")
+}
@@ -0,0 +1,88 @@
+package sip
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/enums"
+)
+
+// NamingRule is the naming convention of a SIP type.
+type NamingRule struct {
+	// Pattern is the regular expression a valid SIP name must match.
+	Pattern string
+
+	// Description is a human readable description of the convention, shown
+	// to the user when a SIP name doesn't match Pattern.
+	Description string
+
+	// Example is a valid SIP name.
+	Example string
+}
+
+// Validate checks that the rule has a valid pattern and a description.
+func (r NamingRule) Validate() error {
+	var errs error
+
+	if r.Pattern == "" {
+		errs = errors.Join(errs, errors.New("Pattern: missing required value"))
+	} else if _, err := regexp.Compile(r.Pattern); err != nil {
+		errs = errors.Join(errs, fmt.Errorf("Pattern: %v", err))
+	}
+	if r.Description == "" {
+		errs = errors.Join(errs, errors.New("Description: missing required value"))
+	}
+
+	return errs
+}
+
+// Match reports whether name matches the rule pattern.
+func (r NamingRule) Match(name string) (bool, error) {
+	re, err := regexp.Compile(r.Pattern)
+	if err != nil {
+		return false, fmt.Errorf("invalid naming rule pattern: %v", err)
+	}
+
+	return re.MatchString(name), nil
+}
+
+// NamingRules maps each SIP type to its naming convention.
+type NamingRules map[enums.SIPType]NamingRule
+
+const (
+	yyyymmdd              = `(\d{4})(\d{2})(\d{2})`
+	alphaNum              = `[a-zA-Z0-9]`
+	alphaNumAndUnderscore = `[a-zA-Z0-9_]`
+
+	// aipName matches names with an "AIP" part, the name parts are separated
+	// by underscores or hyphens (e.g. "Vecteur_Digitized_AIP" or
+	// "little-Test-AIP-Digitization").
+	aipName = `^(` + alphaNum + `+[_-])*AIP([_-]` + alphaNum + `+)*$`
+)
+
+// DefaultNamingRules returns the SFA naming conventions for all SIP types.
+func DefaultNamingRules() NamingRules {
+	return NamingRules{
+		enums.SIPTypeBornDigitalSIP: {
+			Pattern:     fmt.Sprintf("^SIP_%s_%s+(_%s+)?$", yyyymmdd, alphaNum, alphaNumAndUnderscore),
+			Description: "SIP_<YYYYMMDD>_<office>[_<reference>]",
+			Example:     "SIP_20201201_someoffice_someref",
+		},
+		enums.SIPTypeDigitizedSIP: {
+			Pattern:     fmt.Sprintf("^SIP_%s_[Vv]ecteur_%s+$", yyyymmdd, alphaNumAndUnderscore),
+			Description: "SIP_<YYYYMMDD>_Vecteur_<reference>",
+			Example:     "SIP_20201201_Vecteur_someref",
+		},
+		enums.SIPTypeBornDigitalAIP: {
+			Pattern:     aipName,
+			Description: "[<prefix>_]AIP[_<suffix>], with name parts separated by \"_\" or \"-\"",
+			Example:     "AIP_20201201_someoffice_someref",
+		},
+		enums.SIPTypeDigitizedAIP: {
+			Pattern:     aipName,
+			Description: "[<prefix>_]AIP[_<suffix>], with name parts separated by \"_\" or \"-\"",
+			Example:     "AIP_20201201_Vecteur_someref",
+		},
+	}
+}
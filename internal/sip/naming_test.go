@@ -0,0 +1,102 @@
+package sip_test
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/enums"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/sip"
+)
+
+func TestNamingRuleValidate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		rule    sip.NamingRule
+		wantErr string
+	}{
+		{
+			name: "Validates a rule",
+			rule: sip.NamingRule{Pattern: "^SIP_.+$", Description: "SIP_<name>"},
+		},
+		{
+			name:    "Errors when the pattern and description are missing",
+			rule:    sip.NamingRule{},
+			wantErr: "Pattern: missing required value\nDescription: missing required value",
+		},
+		{
+			name:    "Errors when the pattern isn't a valid regular expression",
+			rule:    sip.NamingRule{Pattern: "^SIP_(", Description: "SIP_<name>"},
+			wantErr: "Pattern: error parsing regexp: missing closing ): `^SIP_(`",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			err := tt.rule.Validate()
+			if tt.wantErr != "" {
+				assert.Error(t, err, tt.wantErr)
+				return
+			}
+			assert.NilError(t, err)
+		})
+	}
+}
+
+func TestDefaultNamingRules(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		sipType enums.SIPType
+		name    string
+		want    bool
+	}{
+		{sipType: enums.SIPTypeBornDigitalSIP, name: "SIP_20010106_someoffice_someref", want: true},
+		{sipType: enums.SIPTypeBornDigitalSIP, name: "SIP_20010106_someoffice_some_ref", want: true},
+		{sipType: enums.SIPTypeBornDigitalSIP, name: "SIP_20010106_someoffice", want: true},
+		{sipType: enums.SIPTypeBornDigitalSIP, name: "someref", want: false},
+		{sipType: enums.SIPTypeDigitizedSIP, name: "SIP_20010106_Vecteur_someref", want: true},
+		{sipType: enums.SIPTypeDigitizedSIP, name: "SIP_20010106_Vecteur_some_ref", want: true},
+		{sipType: enums.SIPTypeDigitizedSIP, name: "SIP_20010106_someoffice", want: false},
+		{sipType: enums.SIPTypeDigitizedSIP, name: "SIP_20010106_vecteur_someref", want: true},
+		{sipType: enums.SIPTypeDigitizedSIP, name: "SIP_20010106_|ecteur_someref", want: false},
+		{sipType: enums.SIPTypeBornDigitalAIP, name: "Born-Digital-AIP", want: true},
+		{sipType: enums.SIPTypeBornDigitalAIP, name: "Test_Born_Digital_AIP", want: true},
+		{sipType: enums.SIPTypeBornDigitalAIP, name: "AIP-1234", want: true},
+		{sipType: enums.SIPTypeBornDigitalAIP, name: "AIP_20010106_someoffice_someref", want: true},
+		{sipType: enums.SIPTypeBornDigitalAIP, name: "SIP_20010106_someoffice_someref", want: false},
+		{sipType: enums.SIPTypeBornDigitalAIP, name: "BornDigitalAIP", want: false},
+		{sipType: enums.SIPTypeBornDigitalAIP, name: "Born Digital AIP", want: false},
+		{sipType: enums.SIPTypeDigitizedAIP, name: "little-Test-AIP-Digitization", want: true},
+		{sipType: enums.SIPTypeDigitizedAIP, name: "Vecteur_Digitized_AIP", want: true},
+		{sipType: enums.SIPTypeDigitizedAIP, name: "digitized_AIP_012345", want: true},
+		{sipType: enums.SIPTypeDigitizedAIP, name: "AIP_20201201_vecteur", want: true},
+		{sipType: enums.SIPTypeDigitizedAIP, name: "SIP_20010106_Vecteur_someref", want: false},
+		{sipType: enums.SIPTypeDigitizedAIP, name: "Digitized-AIP-", want: false},
+		{sipType: enums.SIPTypeDigitizedAIP, name: "Digitized-aip", want: false},
+	}
+
+	rules := sip.DefaultNamingRules()
+	for _, sipType := range enums.SIPTypeNames() {
+		rule, ok := rules[enums.SIPType(sipType)]
+		assert.Assert(t, ok, "missing rule for %s", sipType)
+		assert.NilError(t, rule.Validate())
+
+		match, err := rule.Match(rule.Example)
+		assert.NilError(t, err)
+		assert.Assert(t, match, "example %q doesn't match the %s rule", rule.Example, sipType)
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.sipType)+"/"+tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			match, err := rules[tt.sipType].Match(tt.name)
+			assert.NilError(t, err)
+			assert.Equal(t, match, tt.want)
+		})
+	}
+}
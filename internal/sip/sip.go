@@ -4,7 +4,8 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
-	"regexp"
+
+	toolsfsutil "go.artefactual.dev/tools/fsutil"
 
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/enums"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/fsutil"
@@ -128,26 +129,21 @@ func (s SIP) IsSIP() bool {
 	return s.Type == enums.SIPTypeBornDigitalSIP || s.Type == enums.SIPTypeDigitizedSIP
 }
 
-func (s SIP) HasValidName() bool {
-	yyyymmdd := "(\\d{4})(\\d{2})(\\d{2})"
-	alphaNum := "[a-zA-Z0-9]"
-	alphaNumAndUnderscore := "[a-zA-Z0-9_]"
-
-	if s.Type == enums.SIPTypeBornDigitalSIP {
-		match, _ := regexp.MatchString(
-			fmt.Sprintf("^SIP_%s_%s+(_%s+)?$", yyyymmdd, alphaNum, alphaNumAndUnderscore),
-			s.Name(),
-		)
-		return match
-	}
+func (s SIP) IsDigitized() bool {
+	return s.Type == enums.SIPTypeDigitizedAIP || s.Type == enums.SIPTypeDigitizedSIP
+}
 
-	if s.Type == enums.SIPTypeDigitizedSIP {
-		match, _ := regexp.MatchString(
-			fmt.Sprintf("^SIP_%s_[V|v]ecteur_%s+$", yyyymmdd, alphaNumAndUnderscore),
-			s.Name(),
-		)
-		return match
+// TopLevelDirFailure returns a failure message if the extractPath directory,
+// extracted from the archiveName archive, isn't named after the archive minus
+// its file extension (e.g. "example.zip" -> "example"). It returns an empty
+// string when the top-level directory is found.
+func TopLevelDirFailure(extractPath, archiveName string) string {
+	if filepath.Base(extractPath) == toolsfsutil.BaseNoExt(archiveName) {
+		return ""
 	}
 
-	return true
+	return fmt.Sprintf(
+		"The extracted SIP is missing the top-level %q folder.",
+		toolsfsutil.BaseNoExt(archiveName),
+	)
 }
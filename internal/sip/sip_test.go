@@ -175,7 +175,6 @@ func TestNew(t *testing.T) {
 
 			assert.NilError(t, err)
 			assert.DeepEqual(t, s, tt.wantSIP)
-			assert.Equal(t, s.HasValidName(), true)
 		})
 	}
 }
@@ -190,6 +189,17 @@ func TestName(t *testing.T) {
 	assert.Equal(t, s.Name(), "SIP_20201201_Vecteur")
 }
 
+func TestTopLevelDirFailure(t *testing.T) {
+	t.Parallel()
+
+	assert.Equal(t, sip.TopLevelDirFailure("/tmp/SIP_20201201_Vecteur", "SIP_20201201_Vecteur.zip"), "")
+	assert.Equal(
+		t,
+		sip.TopLevelDirFailure("/tmp/content", "SIP_20201201_Vecteur.zip"),
+		`The extracted SIP is missing the top-level "SIP_20201201_Vecteur" folder.`,
+	)
+}
+
 func TestIsAIP(t *testing.T) {
 	t.Parallel()
 
@@ -222,6 +232,22 @@ func TestIsSIP(t *testing.T) {
 	assert.Assert(t, !s.IsSIP())
 }
 
+func TestIsDigitized(t *testing.T) {
+	t.Parallel()
+
+	s := sip.SIP{
+		Type: enums.SIPTypeDigitizedSIP,
+		Path: "/path/to/SIP_20201201_Vecteur",
+	}
+	assert.Assert(t, s.IsDigitized())
+
+	s = sip.SIP{
+		Type: enums.SIPTypeBornDigitalAIP,
+		Path: "/path/to/AIP_20201201",
+	}
+	assert.Assert(t, !s.IsDigitized())
+}
+
 func digitizedSIPTempDir(t *testing.T, sipName string) string {
 	return fs.NewDir(t, "",
 		fs.WithDir(sipName,
@@ -0,0 +1,160 @@
+// Package toolinfo identifies the software tools used to validate SIPs, so
+// task notes, report entries and PREMIS agents can name the exact tool version
+// that checked the SIP files.
+package toolinfo
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/richardlehane/siegfried/pkg/config"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/premis"
+)
+
+// versionTimeout is the maximum duration of a tool version command.
+const versionTimeout = 10 * time.Second
+
+// Tool is a software tool used by preprocessing.
+type Tool struct {
+	// Name of the tool.
+	Name string
+
+	// Version of the tool, empty if it couldn't be found.
+	Version string
+
+	// URL identifying the tool in PREMIS agents.
+	URL string
+}
+
+// String returns the tool name followed by its version, e.g. "xmllint
+// 2.13.8". The version is returned as is if it already includes the name.
+func (t Tool) String() string {
+	switch {
+	case t.Version == "":
+		return fmt.Sprintf("%s (version unknown)", t.Name)
+	case strings.Contains(strings.ToLower(t.Version), strings.ToLower(t.Name)):
+		return t.Version
+	default:
+		return fmt.Sprintf("%s %s", t.Name, t.Version)
+	}
+}
+
+// PREMISAgent returns a PREMIS agent representing the tool.
+func (t Tool) PREMISAgent() premis.Agent {
+	return premis.Agent{
+		Type:    "software",
+		Name:    t.String(),
+		IdType:  "url",
+		IdValue: t.URL,
+	}
+}
+
+// Tools are the tools used by the validation activities that don't report
+// the tool they use.
+type Tools struct {
+	// BagIt validates bags.
+	BagIt Tool
+
+	// XMLLint validates the metadata files against their XML schema.
+	XMLLint Tool
+
+	// Siegfried identifies the file formats.
+	Siegfried Tool
+}
+
+// Discover returns the tools used by the worker, with their versions. It's
+// meant to be called once at startup as it runs external commands, and a
+// version is left empty if it can't be found.
+func Discover(ctx context.Context) Tools {
+	return Tools{
+		BagIt: Tool{
+			Name:    "bagvalidate",
+			Version: moduleVersion("github.com/artefactual-sdps/temporal-activities"),
+			URL:     "https://github.com/artefactual-sdps/temporal-activities",
+		},
+		XMLLint: Tool{
+			Name:    "xmllint",
+			Version: xmllintVersion(ctx, "xmllint"),
+			URL:     "https://gitlab.gnome.org/GNOME/libxml2",
+		},
+		Siegfried: Tool{
+			Name:    "Siegfried",
+			Version: siegfriedVersion(),
+			URL:     "https://github.com/richardlehane/siegfried",
+		},
+	}
+}
+
+// moduleVersion returns the version of the path Go module linked in the
+// binary.
+func moduleVersion(path string) string {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+
+	for _, m := range bi.Deps {
+		if m.Path != path {
+			continue
+		}
+		if m.Replace != nil {
+			m = m.Replace
+		}
+		if m.Version == "(devel)" {
+			return ""
+		}
+		return m.Version
+	}
+
+	return ""
+}
+
+func siegfriedVersion() string {
+	v := config.Version()
+
+	return fmt.Sprintf("%d.%d.%d", v[0], v[1], v[2])
+}
+
+// libxmlVersionRe matches the libxml version number printed by xmllint, e.g.
+// "xmllint: using libxml version 21308".
+var libxmlVersionRe = regexp.MustCompile(`using libxml version (\d+)`)
+
+// xmllintVersion returns the libxml version of the xmllint cmd, e.g. "2.13.8",
+// or an empty string if it can't be found.
+func xmllintVersion(ctx context.Context, cmd string) string {
+	ctx, cancel := context.WithTimeout(ctx, versionTimeout)
+	defer cancel()
+
+	// xmllint writes its version to STDERR.
+	output, err := exec.CommandContext(ctx, cmd, "--version").CombinedOutput() // #nosec G204 -- trusted command.
+	if err != nil {
+		return ""
+	}
+
+	return parseXMLLintVersion(string(output))
+}
+
+// parseXMLLintVersion returns the version printed by "xmllint --version",
+// converting the libxml version number (e.g. "21308") to a dotted version
+// (e.g. "2.13.8").
+func parseXMLLintVersion(output string) string {
+	m := libxmlVersionRe.FindStringSubmatch(output)
+	if m == nil {
+		line, _, _ := strings.Cut(strings.TrimSpace(output), "\n")
+		return strings.TrimSpace(line)
+	}
+
+	n, err := strconv.Atoi(m[1])
+	if err != nil {
+		return m[1]
+	}
+
+	return fmt.Sprintf("%d.%d.%d", n/10000, n/100%100, n%100)
+}
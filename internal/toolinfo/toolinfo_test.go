@@ -0,0 +1,84 @@
+package toolinfo
+
+import (
+	"testing"
+
+	"gotest.tools/v3/assert"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/premis"
+)
+
+func TestToolString(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name string
+		tool Tool
+		want string
+	}{
+		{
+			name: "Adds the version to the name",
+			tool: Tool{Name: "xmllint", Version: "2.13.8"},
+			want: "xmllint 2.13.8",
+		},
+		{
+			name: "Returns a version including the name",
+			tool: Tool{Name: "veraPDF", Version: "veraPDF 1.24.1"},
+			want: "veraPDF 1.24.1",
+		},
+		{
+			name: "Reports an unknown version",
+			tool: Tool{Name: "bagvalidate"},
+			want: "bagvalidate (version unknown)",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.tool.String(), tt.want)
+		})
+	}
+}
+
+func TestToolPREMISAgent(t *testing.T) {
+	t.Parallel()
+
+	tool := Tool{Name: "xmllint", Version: "2.13.8", URL: "https://gitlab.gnome.org/GNOME/libxml2"}
+	assert.DeepEqual(t, tool.PREMISAgent(), premis.Agent{
+		Type:    "software",
+		Name:    "xmllint 2.13.8",
+		IdType:  "url",
+		IdValue: "https://gitlab.gnome.org/GNOME/libxml2",
+	})
+}
+
+func TestParseXMLLintVersion(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		name   string
+		output string
+		want   string
+	}{
+		{
+			name: "Converts the libxml version number",
+			output: `xmllint: using libxml version 21308
+   compiled with: Threads Tree Output Push Reader Patterns Writer SAXv1 HTTP`,
+			want: "2.13.8",
+		},
+		{
+			name:   "Returns the first line of an unexpected output",
+			output: "\nxmllint 2.14.0\nmore details\n",
+			want:   "xmllint 2.14.0",
+		},
+		{
+			name: "Returns nothing without output",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, parseXMLLintVersion(tt.output), tt.want)
+		})
+	}
+}
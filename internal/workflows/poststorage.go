@@ -17,17 +17,20 @@ import (
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/apis"
 	apisgen "github.com/artefactual-sdps/preprocessing-sfa/internal/apis/gen"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/config"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/progress"
 )
 
 type Poststorage struct {
 	cfg         config.PoststorageConfig
 	apisEnabled bool
+	progress    *progress.Store
 }
 
-func NewPoststorage(cfg config.PoststorageConfig, apisEnabled bool) *Poststorage {
+func NewPoststorage(cfg config.PoststorageConfig, apisEnabled bool, store *progress.Store) *Poststorage {
 	return &Poststorage{
 		cfg:         cfg,
 		apisEnabled: apisEnabled,
+		progress:    store,
 	}
 }
 
@@ -43,6 +46,12 @@ func (w *Poststorage) Execute(
 		logger.Debug("Poststorage workflow finished!", "result", r, "error", e)
 	}()
 
+	untrack, e := trackProgress(ctx, w.progress, func() []*childwf.Task { return r.Tasks })
+	if e != nil {
+		return nil, e
+	}
+	defer untrack()
+
 	if !w.apisEnabled {
 		return r, nil
 	}
@@ -216,7 +225,7 @@ func (w *Poststorage) SessionHandler(
 			TaskID:          apisMetadata.ImportTaskID,
 			METSPath:        metsPath,
 			ImportBehaviour: importBehaviour,
-			Username:        "sfa-enduro", // TODO: Use real username.
+			Username:        apisMetadata.User(),
 		},
 	).Get(ctx, &createImportRun)
 	if err != nil {
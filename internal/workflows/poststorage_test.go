@@ -94,7 +94,7 @@ func (s *TestSuite) setup(cfg *config.PoststorageConfig, apisEnabled bool) {
 		temporalsdk_activity.RegisterOptions{Name: apis.PollImportRunStatusActivityName},
 	)
 
-	s.workflow = workflows.NewPoststorage(*cfg, apisEnabled)
+	s.workflow = workflows.NewPoststorage(*cfg, apisEnabled, nil)
 }
 
 func TestPoststorage(t *testing.T) {
@@ -12,6 +12,7 @@ import (
 	"github.com/artefactual-sdps/temporal-activities/bagcreate"
 	"github.com/artefactual-sdps/temporal-activities/bagvalidate"
 	"github.com/artefactual-sdps/temporal-activities/ffvalidate"
+	"github.com/artefactual-sdps/temporal-activities/removepaths"
 	"github.com/artefactual-sdps/temporal-activities/xmlvalidate"
 	"go.artefactual.dev/tools/fsutil"
 	"go.artefactual.dev/tools/temporal"
@@ -22,27 +23,37 @@ import (
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/apis"
 	apisgen "github.com/artefactual-sdps/preprocessing-sfa/internal/apis/gen"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/config"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/inventory"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/localact"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/persistence"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/premis"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/progress"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/report"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/sip"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/toolinfo"
 )
 
 type Preprocessing struct {
 	psvc        persistence.Service
 	cfg         config.PreprocessingConfig
 	apisEnabled bool
+	tools       toolinfo.Tools
+	progress    *progress.Store
 }
 
 func NewPreprocessing(
 	psvc persistence.Service,
 	cfg config.PreprocessingConfig,
 	apisEnabled bool,
+	tools toolinfo.Tools,
+	store *progress.Store,
 ) *Preprocessing {
 	return &Preprocessing{
 		psvc:        psvc,
 		cfg:         cfg,
 		apisEnabled: apisEnabled,
+		tools:       tools,
+		progress:    store,
 	}
 }
 
@@ -67,8 +78,25 @@ func (w *Preprocessing) Execute(
 	}
 	result.RelativePath = params.RelativePath
 
+	untrack, e := trackProgress(ctx, w.progress, func() []*childwf.Task { return result.Tasks })
+	if e != nil {
+		return nil, e
+	}
+	defer untrack()
+
+	rep := report.New(params.SIPID.String(), params.SIPName)
+	defer attachReport(ctx, result, rep)
+
 	localPath := filepath.Join(w.cfg.SharedPath, filepath.Clean(params.RelativePath))
 
+	// Remove the SIP inventory files last, as recording a failed run reads
+	// them. The closure reads localPath when it runs.
+	defer func() { w.removeInventory(ctx, result, localPath) }()
+
+	// Record a failed run once the other deferred tasks are done. The closure
+	// reads localPath when it runs, as it's updated once the SIP is extracted.
+	defer func() { w.recordFailedRun(ctx, result, rep, params, localPath) }()
+
 	if w.cfg.CheckDuplicates {
 		// Calculate SIP checksum.
 		task := result.NewTask(temporalsdk_workflow.Now(ctx), "Calculate SIP checksum")
@@ -128,10 +156,176 @@ func (w *Preprocessing) Execute(
 		task.Succeed(temporalsdk_workflow.Now(ctx), "SIP is not a duplicate")
 	}
 
+	tools, e := recordTools(ctx, w.tools)
+	if e != nil {
+		return nil, e
+	}
+
+	localPath, sip, e := validateSIP(ctx, result, rep, tools, w.cfg.SharedPath, localPath, params.SIPName)
+	if e != nil {
+		return nil, e
+	}
+
+	// Stop here if the SIP content isn't valid.
+	if result.Outcome != childwf.OutcomeSuccess {
+		return result, nil
+	}
+
+	// Create APIS import task.
+	if w.apisEnabled {
+		metadata, ok := w.createAPISImportTask(ctx, result, sip)
+		if metadata.ImportTaskID != "" {
+			defer w.cancelAPISImportTask(ctx, result, metadata)
+		}
+		if !ok {
+			if result.Outcome == childwf.OutcomeCanceled {
+				w.removeCanceledSIP(ctx, result, localPath)
+			}
+			return result, nil
+		}
+	}
+
+	// Write PREMIS XML.
+	task := result.NewTask(temporalsdk_workflow.Now(ctx), "Create premis.xml")
+	if e = writePREMISFile(ctx, sip, tools, rep); e != nil {
+		logger.Error("System error", "message", e.Error())
+		result.SystemError(
+			temporalsdk_workflow.Now(ctx),
+			task,
+			"premis.xml creation has failed",
+			"An error has occurred while attempting to create the premis.xml file and store it in the metadata directory. Please try again, or ask a system administrator to investigate.",
+		)
+		return result, nil
+	}
+	task.Succeed(temporalsdk_workflow.Now(ctx), "Created a premis.xml file and stored it in the metadata directory")
+
+	// Re-structure SIP.
+	task = result.NewTask(temporalsdk_workflow.Now(ctx), "Restructure SIP")
+	var transformSIP activities.TransformSIPResult
+	e = temporalsdk_workflow.ExecuteActivity(
+		withRestructureActivityOpts(ctx),
+		activities.TransformSIPName,
+		&activities.TransformSIPParams{SIP: sip},
+	).Get(ctx, &transformSIP)
+	if e != nil {
+		logger.Error("System error", "message", e.Error())
+		result.SystemError(
+			temporalsdk_workflow.Now(ctx),
+			task,
+			"restructuring has failed",
+			"An error has occurred while attempting to restructure the SIP for preservation processing. Please try again, or ask a system administrator to investigate.",
+		)
+		return result, nil
+	}
+	task.Succeed(temporalsdk_workflow.Now(ctx), "SIP has been restructured for preservation processing")
+
+	// Write the identifiers.json file.
+	task = result.NewTask(temporalsdk_workflow.Now(ctx), "Create identifier.json")
+	var writeIDFile activities.WriteIdentifierFileResult
+	e = temporalsdk_workflow.ExecuteActivity(
+		withFilesystemActivityOpts(ctx),
+		activities.WriteIdentifierFileName,
+		&activities.WriteIdentifierFileParams{PIP: transformSIP.PIP},
+	).Get(ctx, &writeIDFile)
+	if e != nil {
+		logger.Error("System error", "message", e.Error())
+		result.SystemError(
+			temporalsdk_workflow.Now(ctx),
+			task,
+			"identifier.json creation has failed.",
+			"An error has occurred while attempting to create the identifier.json file and store it in the metadata directory. Please try again, or ask a system administrator to investigate.",
+		)
+		return result, nil
+	}
+	task.Succeed(
+		temporalsdk_workflow.Now(ctx),
+		"Created an identifier.json file and stored it in the metadata directory",
+	)
+
+	// Write the validation-report.json file, the report only lists the tasks
+	// run before this one.
+	pipReport := rep.WithResult(result)
+	task = result.NewTask(temporalsdk_workflow.Now(ctx), "Create validation-report.json")
+	var writeReport activities.WriteValidationReportResult
+	e = temporalsdk_workflow.ExecuteActivity(
+		withFilesystemActivityOpts(ctx),
+		activities.WriteValidationReportName,
+		&activities.WriteValidationReportParams{PIP: transformSIP.PIP, Report: pipReport},
+	).Get(ctx, &writeReport)
+	if e != nil {
+		logger.Error("System error", "message", e.Error())
+		result.SystemError(
+			temporalsdk_workflow.Now(ctx),
+			task,
+			"validation-report.json creation has failed.",
+			"An error has occurred while attempting to create the validation-report.json file and store it in the metadata directory. Please try again, or ask a system administrator to investigate.",
+		)
+		return result, nil
+	}
+	task.Succeed(
+		temporalsdk_workflow.Now(ctx),
+		"Created a validation-report.json file and stored it in the metadata directory",
+	)
+
+	// Bag the SIP for Enduro processing.
+	task = result.NewTask(temporalsdk_workflow.Now(ctx), "Bag SIP")
+	var createBag bagcreate.Result
+	e = temporalsdk_workflow.ExecuteActivity(
+		withFilesystemActivityOpts(ctx),
+		bagcreate.Name,
+		&bagcreate.Params{SourcePath: localPath},
+	).Get(ctx, &createBag)
+	if e != nil {
+		logger.Error("System error", "message", e.Error())
+		result.SystemError(
+			temporalsdk_workflow.Now(ctx),
+			task,
+			"SIP bagging has failed.",
+			"An error has occurred while attempting to bag the SIP. Please try again, or ask a system administrator to investigate.",
+		)
+		return result, nil
+	}
+	task.Succeed(temporalsdk_workflow.Now(ctx), "SIP has been bagged")
+
+	return result, nil
+}
+
+// recordTools returns the validation tools of the worker, recorded in the
+// workflow history so a replay names the same tool versions as the original
+// run.
+func recordTools(ctx temporalsdk_workflow.Context, tools toolinfo.Tools) (toolinfo.Tools, error) {
+	var recorded toolinfo.Tools
+	err := temporalsdk_workflow.SideEffect(ctx, func(temporalsdk_workflow.Context) any {
+		return tools
+	}).Get(&recorded)
+	if err != nil {
+		return toolinfo.Tools{}, fmt.Errorf("record tools: %v", err)
+	}
+
+	return recorded, nil
+}
+
+// validateSIP extracts the SIP at path and runs the validation tasks shared by
+// the preprocessing and validation workflows, adding the failures found to
+// rep. The tasks name the tools that validated the SIP. It returns the local
+// path and the identified SIP. Processing should stop if result.Outcome isn't
+// successful, and a returned error means the workflow itself has failed.
+func validateSIP(
+	ctx temporalsdk_workflow.Context,
+	result *childwf.PreprocessingResult,
+	rep *report.Report,
+	tools toolinfo.Tools,
+	sharedPath string,
+	path string,
+	sipName string,
+) (string, sip.SIP, error) {
+	var e error
+	logger := temporalsdk_workflow.GetLogger(ctx)
+
 	// Extract SIP.
-	localPath = w.extractSIP(ctx, result, localPath, params.SIPName)
+	localPath := extractSIP(ctx, result, rep, sharedPath, path, sipName)
 	if result.Outcome == childwf.OutcomeSystemError {
-		return result, nil
+		return localPath, sip.SIP{}, nil
 	}
 
 	// Check if the SIP is a BagIt bag.
@@ -142,7 +336,7 @@ func (w *Preprocessing) Execute(
 		&localact.IsBagParams{Path: localPath},
 	).Get(ctx, &isBag)
 	if e != nil {
-		return nil, fmt.Errorf("bag check: %v", e)
+		return "", sip.SIP{}, fmt.Errorf("bag check: %v", e)
 	}
 
 	// Unbag the SIP if it is a bag.
@@ -162,22 +356,23 @@ func (w *Preprocessing) Execute(
 				"Bag validation has failed.",
 				"An error occurred during the bag validation process. Please try again, or ask a system administrator to investigate.",
 			)
-			return result, nil
+			return localPath, sip.SIP{}, nil
 		}
 		if bagValidateResult.Error != "" {
+			rep.AddFor(
+				task.Name,
+				toolEntry(tools.BagIt, bagvalidate.Name, report.CodeBagInvalid, "", bagValidateResult.Error),
+			)
 			result.ValidationError(
 				temporalsdk_workflow.Now(ctx),
 				task,
 				"Bag validation has failed.",
-				// TODO: Add BagIt tool and version info.
-				// "An attempt to validate the bag using [tool] - version [version] has failed:",
+				fmt.Sprintf("An attempt to validate the bag using %s has failed:", tools.BagIt),
 				bagValidateResult.Error,
 				"Please ensure the bag is well-formed before reattempting ingest.",
-				"Your SIP has been moved to the failed-sips directory.",
 			)
 		} else {
-			// TODO: Add BagIt tool and version info.
-			task.Succeed(temporalsdk_workflow.Now(ctx), "Bag successfully validated")
+			task.Succeed(temporalsdk_workflow.Now(ctx), "Bag successfully validated using %s", tools.BagIt)
 		}
 
 		task = result.NewTask(temporalsdk_workflow.Now(ctx), "Unbag SIP")
@@ -195,11 +390,11 @@ func (w *Preprocessing) Execute(
 				"SIP unbagging has failed.",
 				"An error occurred during the SIP unbagging process. Please try again, or ask a system administrator to investigate.",
 			)
-			return result, nil
+			return localPath, sip.SIP{}, nil
 		}
 
 		localPath = unbagResult.Path
-		result.RelativePath, e = filepath.Rel(w.cfg.SharedPath, localPath)
+		result.RelativePath, e = filepath.Rel(sharedPath, localPath)
 		if e != nil {
 			logger.Error("System error", "message", e.Error())
 			result.SystemError(
@@ -208,7 +403,7 @@ func (w *Preprocessing) Execute(
 				"SIP unbagging has failed.",
 				"An error occurred during the SIP unbagging process. Please try again, or ask a system administrator to investigate.",
 			)
-			return result, nil
+			return localPath, sip.SIP{}, nil
 		}
 		task.Succeed(temporalsdk_workflow.Now(ctx), "SIP unbagged")
 	}
@@ -222,26 +417,105 @@ func (w *Preprocessing) Execute(
 		&activities.IdentifySIPParams{Path: localPath},
 	).Get(ctx, &identifySIP)
 	if e != nil {
+		rep.AddFor(task.Name, validationEntry(
+			activities.IdentifySIPName,
+			report.CodeSIPUnidentified,
+			"",
+			"The package type could not be identified",
+		))
 		result.ValidationError(
 			temporalsdk_workflow.Now(ctx),
 			task,
 			"SIP identification has failed.",
 			"Enduro could not identify the package type. Please ensure that your SIP matches one of the supported package structures.",
 		)
-		return result, nil
+		return localPath, sip.SIP{}, nil
 	}
 
 	sip := identifySIP.SIP
 	task.Succeed(temporalsdk_workflow.Now(ctx), "SIP structure identified: %s", sip.Type)
-
-	// Validate structure.
-	task = result.NewTask(temporalsdk_workflow.Now(ctx), "Validate SIP structure")
-	var validateStructure activities.ValidateStructureResult
+	startedAt := temporalsdk_workflow.Now(ctx)
+
+	// Inventory the SIP files, walking the SIP and identifying the content
+	// file formats once. The validation activities below read the inventory
+	// instead. It's part of the structure validation task, the first task to
+	// use it. The file format identification it does is reported by its own
+	// task below.
+	var createInventory activities.CreateInventoryResult
 	e = temporalsdk_workflow.ExecuteActivity(
-		withFilesystemActivityOpts(ctx),
+		withChecksumActivityOpts(ctx),
+		activities.CreateInventoryName,
+		&activities.CreateInventoryParams{SIP: sip},
+	).Get(ctx, &createInventory)
+	if e != nil {
+		logger.Error("System error", "message", e.Error())
+		result.SystemError(
+			temporalsdk_workflow.Now(ctx),
+			result.NewTask(startedAt, "Validate SIP structure"),
+			"SIP file inventory has failed.",
+			"An error occurred while listing the SIP files. Please try again, or ask a system administrator to investigate.",
+		)
+		return localPath, sip, nil
+	}
+
+	// The remaining validation activities don't depend on each other, so they
+	// are started together and their results are processed in order below to
+	// keep the task list and report deterministic. Pending activities are
+	// canceled if processing stops early on a system error. The order change
+	// isn't versioned, running workflows must be drained before upgrading.
+	valCtx, cancel := temporalsdk_workflow.WithCancel(ctx)
+	defer cancel()
+	valCtx = withFilesystemActivityOpts(valCtx)
+
+	structureFuture := temporalsdk_workflow.ExecuteActivity(
+		valCtx,
 		activities.ValidateStructureName,
 		&activities.ValidateStructureParams{SIP: sip},
-	).Get(ctx, &validateStructure)
+	)
+	nameFuture := temporalsdk_workflow.ExecuteActivity(
+		valCtx,
+		activities.ValidateSIPNameName,
+		&activities.ValidateSIPNameParams{SIP: sip},
+	)
+	manifestFuture := temporalsdk_workflow.ExecuteActivity(
+		withChecksumActivityOpts(valCtx),
+		activities.VerifyManifestName,
+		&activities.VerifyManifestParams{SIP: sip},
+	)
+	var ffvalidateFuture temporalsdk_workflow.Future
+	if sip.IsSIP() {
+		ffvalidateFuture = temporalsdk_workflow.ExecuteActivity(
+			valCtx,
+			ffvalidate.Name,
+			&ffvalidate.Params{Path: sip.ContentPath},
+		)
+	}
+	filesFuture := temporalsdk_workflow.ExecuteActivity(
+		withFileValidationActivityOpts(valCtx),
+		activities.ValidateFilesName,
+		&activities.ValidateFilesParams{SIP: sip},
+	)
+	metadataFuture := temporalsdk_workflow.ExecuteActivity(
+		valCtx,
+		xmlvalidate.Name,
+		&xmlvalidate.Params{
+			XMLPath: sip.ManifestPath,
+			XSDPath: sip.XSDPath,
+		},
+	)
+	var lmdFuture temporalsdk_workflow.Future
+	if sip.IsAIP() {
+		lmdFuture = temporalsdk_workflow.ExecuteActivity(
+			valCtx,
+			activities.ValidatePREMISName,
+			activities.ValidatePREMISParams{Path: sip.LogicalMDPath, SIPPath: sip.Path},
+		)
+	}
+
+	// Validate structure.
+	task = result.NewTask(startedAt, "Validate SIP structure")
+	var validateStructure activities.ValidateStructureResult
+	e = structureFuture.Get(ctx, &validateStructure)
 	if e != nil {
 		logger.Error("System error", "message", e.Error())
 		result.SystemError(
@@ -250,8 +524,9 @@ func (w *Preprocessing) Execute(
 			"SIP structure validation has failed.",
 			"An error occurred during the structure validation process. Please try again, or ask a system administrator to investigate.",
 		)
-		return result, nil
+		return localPath, sip, nil
 	}
+	rep.AddFor(task.Name, validateStructure.Entries...)
 	if validateStructure.Failures != nil {
 		result.ValidationError(
 			temporalsdk_workflow.Now(ctx),
@@ -265,13 +540,9 @@ func (w *Preprocessing) Execute(
 	}
 
 	// Validate SIP name.
-	task = result.NewTask(temporalsdk_workflow.Now(ctx), "Validate SIP name")
+	task = result.NewTask(startedAt, "Validate SIP name")
 	var ValidateSIPName activities.ValidateSIPNameResult
-	e = temporalsdk_workflow.ExecuteActivity(
-		withFilesystemActivityOpts(ctx),
-		activities.ValidateSIPNameName,
-		&activities.ValidateSIPNameParams{SIP: sip},
-	).Get(ctx, &ValidateSIPName)
+	e = nameFuture.Get(ctx, &ValidateSIPName)
 	if e != nil {
 		logger.Error("System error", "message", e.Error())
 		result.SystemError(
@@ -280,8 +551,9 @@ func (w *Preprocessing) Execute(
 			"SIP name validation has failed.",
 			"An error occurred during the SIP name validation process. Please try again, or ask a system administrator to investigate.",
 		)
-		return result, nil
+		return localPath, sip, nil
 	}
+	rep.AddFor(task.Name, ValidateSIPName.Entries...)
 	if ValidateSIPName.Failures != nil {
 		result.ValidationError(
 			temporalsdk_workflow.Now(ctx),
@@ -302,14 +574,10 @@ func (w *Preprocessing) Execute(
 	}
 
 	// Verify that package contents match the manifest.
-	manifestTask := result.NewTask(temporalsdk_workflow.Now(ctx), "Verify SIP manifest")
-	checksumTask := result.NewTask(temporalsdk_workflow.Now(ctx), "Verify SIP checksums")
+	manifestTask := result.NewTask(startedAt, "Verify SIP manifest")
+	checksumTask := result.NewTask(startedAt, "Verify SIP checksums")
 	var verifyManifest activities.VerifyManifestResult
-	e = temporalsdk_workflow.ExecuteActivity(
-		withFilesystemActivityOpts(ctx),
-		activities.VerifyManifestName,
-		&activities.VerifyManifestParams{SIP: sip},
-	).Get(ctx, &verifyManifest)
+	e = manifestFuture.Get(ctx, &verifyManifest)
 	if e != nil {
 		logger.Error("System error", "message", e.Error())
 		result.SystemError(
@@ -324,9 +592,28 @@ func (w *Preprocessing) Execute(
 			"SIP checksum verification has failed.",
 			"An error occurred during the checksum verification process. Please try again, or ask a system administrator to investigate.",
 		)
-		return result, nil
+		return localPath, sip, nil
 	}
 
+	// The digitization PREMIS files are checked once the manifest has been
+	// verified, to use the checksums calculated then instead of hashing the
+	// files again.
+	var digitizationFuture temporalsdk_workflow.Future
+	if sip.IsDigitized() {
+		digitizationFuture = temporalsdk_workflow.ExecuteActivity(
+			withChecksumActivityOpts(valCtx),
+			activities.ValidateDigitizationPREMISName,
+			&activities.ValidateDigitizationPREMISParams{SIP: sip},
+		)
+	}
+
+	for _, entry := range verifyManifest.Entries {
+		if entry.Code == report.CodeManifestChecksumMismatch {
+			rep.AddFor(checksumTask.Name, entry)
+		} else {
+			rep.AddFor(manifestTask.Name, entry)
+		}
+	}
 	if len(verifyManifest.ManifestFailures) > 0 || len(verifyManifest.MissingFiles) > 0 ||
 		len(verifyManifest.UnexpectedFiles) > 0 {
 		result.ValidationError(
@@ -361,15 +648,70 @@ func (w *Preprocessing) Execute(
 		checksumTask.Succeed(temporalsdk_workflow.Now(ctx), "SIP checksums match file contents")
 	}
 
+	// Report the files that couldn't be conclusively identified.
+	task = result.NewTask(startedAt, "Identify SIP file formats")
+	rep.AddFor(task.Name, createInventory.Entries...)
+	switch {
+	case len(createInventory.Failures) > 0:
+		result.ValidationError(
+			temporalsdk_workflow.Now(ctx),
+			task,
+			"file format identification has failed.",
+			fmt.Sprintf("The format of the following file(s) couldn't be identified by %s:", tools.Siegfried),
+			ul(createInventory.Failures),
+			"Please review the SIP and remove or replace the files with an unknown or ambiguous format.",
+		)
+	case len(createInventory.Warnings) > 0:
+		task.Succeed(
+			temporalsdk_workflow.Now(ctx),
+			"File formats identified using %s, with the following warning(s):\n\n%s",
+			tools.Siegfried,
+			ul(createInventory.Warnings),
+		)
+	default:
+		task.Succeed(temporalsdk_workflow.Now(ctx), "File formats identified using %s", tools.Siegfried)
+	}
+
+	// Cross-check the digitization PREMIS files (digitized types only).
+	if digitizationFuture != nil {
+		task = result.NewTask(startedAt, "Verify digitization PREMIS")
+		var digitization activities.ValidateDigitizationPREMISResult
+		e = digitizationFuture.Get(ctx, &digitization)
+		if e != nil {
+			logger.Error("System error", "message", e.Error())
+			result.SystemError(
+				temporalsdk_workflow.Now(ctx),
+				task,
+				"digitization PREMIS verification has failed.",
+				"An error occurred while comparing the files with their digitization PREMIS. Please try again, or ask a system administrator to investigate.",
+			)
+			return localPath, sip, nil
+		}
+
+		rep.AddFor(task.Name, digitization.Entries...)
+		if digitization.Failures != nil {
+			result.ValidationError(
+				temporalsdk_workflow.Now(ctx),
+				task,
+				"digitization PREMIS verification has failed.",
+				"The following file(s) don't match their digitization PREMIS:",
+				ul(digitization.Failures),
+				"Please review the SIP and ensure that the digitization PREMIS files describe the delivered files.",
+			)
+		} else {
+			task.Succeed(
+				temporalsdk_workflow.Now(ctx),
+				"%d file(s) match the format, size and checksum declared in their digitization PREMIS",
+				digitization.Files,
+			)
+		}
+	}
+
 	// Check for disallowed file formats (SIP types only).
-	if sip.IsSIP() {
-		task = result.NewTask(temporalsdk_workflow.Now(ctx), "Check for disallowed file formats")
+	if ffvalidateFuture != nil {
+		task = result.NewTask(startedAt, "Check for disallowed file formats")
 		var ffvalidateResult ffvalidate.Result
-		e = temporalsdk_workflow.ExecuteActivity(
-			withFilesystemActivityOpts(ctx),
-			ffvalidate.Name,
-			&ffvalidate.Params{Path: sip.ContentPath},
-		).Get(ctx, &ffvalidateResult)
+		e = ffvalidateFuture.Get(ctx, &ffvalidateResult)
 		if e != nil {
 			logger.Error("System error", "message", e.Error())
 			result.SystemError(
@@ -378,31 +720,37 @@ func (w *Preprocessing) Execute(
 				"file format check has failed.",
 				"An error occurred when checking for disallowed file formats. Please try again, or ask a system administrator to investigate.",
 			)
-			return result, nil
+			return localPath, sip, nil
 		}
 
 		if ffvalidateResult.Failures != nil {
+			for _, f := range ffvalidateResult.Failures {
+				rep.AddFor(
+					task.Name,
+					validationEntry(ffvalidate.Name, report.CodeFileFormatDisallowed, "", f),
+				)
+			}
 			result.ValidationError(
 				temporalsdk_workflow.Now(ctx),
 				task,
 				"file format check has failed.",
-				"One or more file formats are not allowed:",
+				fmt.Sprintf("One or more file formats identified by %s are not allowed:", tools.Siegfried),
 				ul(ffvalidateResult.Failures),
 				"Please review the SIP and remove or replace all disallowed file formats.",
 			)
 		} else {
-			task.Succeed(temporalsdk_workflow.Now(ctx), "No disallowed file formats found")
+			task.Succeed(
+				temporalsdk_workflow.Now(ctx),
+				"No disallowed file formats found (formats identified by %s)",
+				tools.Siegfried,
+			)
 		}
 	}
 
 	// Validate SIP file formats against the format specifications.
-	task = result.NewTask(temporalsdk_workflow.Now(ctx), "Validate SIP file formats")
+	task = result.NewTask(startedAt, "Validate SIP file formats")
 	var validateFilesResult activities.ValidateFilesResult
-	e = temporalsdk_workflow.ExecuteActivity(
-		withFilesystemActivityOpts(ctx),
-		activities.ValidateFilesName,
-		&activities.ValidateFilesParams{SIP: sip},
-	).Get(ctx, &validateFilesResult)
+	e = filesFuture.Get(ctx, &validateFilesResult)
 	if e != nil {
 		logger.Error("System error", "message", e.Error())
 		result.SystemError(
@@ -411,33 +759,32 @@ func (w *Preprocessing) Execute(
 			"file format validation has failed.",
 			"An error occurred during the file format validation process. Please try again, or ask a system administrator to investigate.",
 		)
-		return result, nil
+		return localPath, sip, nil
 	}
 
+	rep.AddFor(task.Name, validateFilesResult.Entries...)
 	if validateFilesResult.Failures != nil {
 		result.ValidationError(
 			temporalsdk_workflow.Now(ctx),
 			task,
 			"file format validation has failed.",
-			// TODO: Add tool name and version info.
 			ul(validateFilesResult.Failures),
 			"Please ensure all files are well-formed.",
 		)
+	} else if len(validateFilesResult.Tools) > 0 {
+		task.Succeed(
+			temporalsdk_workflow.Now(ctx),
+			"No invalid files found by the following validator(s):\n\n%s",
+			ul(toolNames(validateFilesResult.Tools)),
+		)
 	} else {
 		task.Succeed(temporalsdk_workflow.Now(ctx), "No invalid files found")
 	}
 
 	// Validate metadata.
-	task = result.NewTask(temporalsdk_workflow.Now(ctx), "Validate SIP metadata")
+	task = result.NewTask(startedAt, "Validate SIP metadata")
 	var validateMetadata xmlvalidate.Result
-	e = temporalsdk_workflow.ExecuteActivity(
-		withFilesystemActivityOpts(ctx),
-		xmlvalidate.Name,
-		&xmlvalidate.Params{
-			XMLPath: sip.ManifestPath,
-			XSDPath: sip.XSDPath,
-		},
-	).Get(ctx, &validateMetadata)
+	e = metadataFuture.Get(ctx, &validateMetadata)
 	if e != nil {
 		logger.Error("System error", "message", e.Error())
 		result.SystemError(
@@ -449,37 +796,42 @@ func (w *Preprocessing) Execute(
 				filepath.Base(sip.ManifestPath),
 			),
 		)
-		return result, nil
+		return localPath, sip, nil
 	}
 
 	if validateMetadata.Failures != nil {
 		for idx, f := range validateMetadata.Failures {
 			validateMetadata.Failures[idx] = strings.ReplaceAll(f, sip.Path+"/", "")
+			rep.AddFor(task.Name, toolEntry(
+				tools.XMLLint,
+				xmlvalidate.Name,
+				report.CodeMetadataInvalid,
+				relPath(sip.Path, sip.ManifestPath),
+				validateMetadata.Failures[idx],
+			))
 		}
 		result.ValidationError(
 			temporalsdk_workflow.Now(ctx),
 			task,
 			"metadata validation has failed.",
+			fmt.Sprintf("The following failures were found by %s:", tools.XMLLint),
 			ul(validateMetadata.Failures),
 			"Please ensure all metadata files are present and well-formed.",
 		)
 	} else {
 		task.Succeed(
 			temporalsdk_workflow.Now(ctx),
-			"Metadata validation successful on the following file(s):\n\n%s",
+			"Metadata validation successful using %s on the following file(s):\n\n%s",
+			tools.XMLLint,
 			ul([]string{filepath.Base(sip.ManifestPath)}),
 		)
 	}
 
 	// Validate logical metadata (AIP types only).
-	if sip.IsAIP() {
-		task = result.NewTask(temporalsdk_workflow.Now(ctx), "Validate logical metadata")
+	if lmdFuture != nil {
+		task = result.NewTask(startedAt, "Validate logical metadata")
 		var validateLMD activities.ValidatePREMISResult
-		e = temporalsdk_workflow.ExecuteActivity(
-			withFilesystemActivityOpts(ctx),
-			activities.ValidatePREMISName,
-			activities.ValidatePREMISParams{Path: sip.LogicalMDPath},
-		).Get(ctx, &validateLMD)
+		e = lmdFuture.Get(ctx, &validateLMD)
 		if e != nil {
 			logger.Error("System error", "message", e.Error())
 			result.SystemError(
@@ -491,8 +843,16 @@ func (w *Preprocessing) Execute(
 					filepath.Base(sip.LogicalMDPath),
 				),
 			)
-			return result, nil
+			return localPath, sip, nil
+		}
+		for idx, entry := range validateLMD.Entries {
+			// A missing file is found before xmllint runs.
+			if entry.Code == report.CodeLogicalMetadataInvalid {
+				validateLMD.Entries[idx].Tool = tools.XMLLint.Name
+				validateLMD.Entries[idx].ToolVersion = tools.XMLLint.Version
+			}
 		}
+		rep.AddFor(task.Name, validateLMD.Entries...)
 		if validateLMD.Failures != nil {
 			result.ValidationError(
 				temporalsdk_workflow.Now(ctx),
@@ -502,110 +862,23 @@ func (w *Preprocessing) Execute(
 				"Please ensure all metadata files are present and well-formed.",
 			)
 		} else {
-			task.Succeed(temporalsdk_workflow.Now(ctx), "Logical metadata validation successful")
+			task.Succeed(temporalsdk_workflow.Now(ctx), "Logical metadata validation successful using %s", tools.XMLLint)
 		}
 	}
 
-	// Stop here if the SIP content isn't valid.
-	if result.Outcome == childwf.OutcomeContentError {
-		return result, nil
-	}
-
-	// Create APIS import task.
-	if w.apisEnabled {
-		if ok := w.createAPISImportTask(ctx, result, sip); !ok {
-			return result, nil
-		}
-	}
-
-	// Write PREMIS XML.
-	task = result.NewTask(temporalsdk_workflow.Now(ctx), "Create premis.xml")
-	if e = writePREMISFile(ctx, sip); e != nil {
-		logger.Error("System error", "message", e.Error())
-		result.SystemError(
-			temporalsdk_workflow.Now(ctx),
-			task,
-			"premis.xml creation has failed",
-			"An error has occurred while attempting to create the premis.xml file and store it in the metadata directory. Please try again, or ask a system administrator to investigate.",
-		)
-		return result, nil
-	}
-	task.Succeed(temporalsdk_workflow.Now(ctx), "Created a premis.xml file and stored it in the metadata directory")
-
-	// Re-structure SIP.
-	task = result.NewTask(temporalsdk_workflow.Now(ctx), "Restructure SIP")
-	var transformSIP activities.TransformSIPResult
-	e = temporalsdk_workflow.ExecuteActivity(
-		withFilesystemActivityOpts(ctx),
-		activities.TransformSIPName,
-		&activities.TransformSIPParams{SIP: sip},
-	).Get(ctx, &transformSIP)
-	if e != nil {
-		logger.Error("System error", "message", e.Error())
-		result.SystemError(
-			temporalsdk_workflow.Now(ctx),
-			task,
-			"restructuring has failed",
-			"An error has occurred while attempting to restructure the SIP for preservation processing. Please try again, or ask a system administrator to investigate.",
-		)
-		return result, nil
-	}
-	task.Succeed(temporalsdk_workflow.Now(ctx), "SIP has been restructured for preservation processing")
-
-	// Write the identifiers.json file.
-	task = result.NewTask(temporalsdk_workflow.Now(ctx), "Create identifier.json")
-	var writeIDFile activities.WriteIdentifierFileResult
-	e = temporalsdk_workflow.ExecuteActivity(
-		withFilesystemActivityOpts(ctx),
-		activities.WriteIdentifierFileName,
-		&activities.WriteIdentifierFileParams{PIP: transformSIP.PIP},
-	).Get(ctx, &writeIDFile)
-	if e != nil {
-		logger.Error("System error", "message", e.Error())
-		result.SystemError(
-			temporalsdk_workflow.Now(ctx),
-			task,
-			"identifier.json creation has failed.",
-			"An error has occurred while attempting to create the identifier.json file and store it in the metadata directory. Please try again, or ask a system administrator to investigate.",
-		)
-		return result, nil
-	}
-	task.Succeed(
-		temporalsdk_workflow.Now(ctx),
-		"Created an identifier.json file and stored it in the metadata directory",
-	)
-
-	// Bag the SIP for Enduro processing.
-	task = result.NewTask(temporalsdk_workflow.Now(ctx), "Bag SIP")
-	var createBag bagcreate.Result
-	e = temporalsdk_workflow.ExecuteActivity(
-		withFilesystemActivityOpts(ctx),
-		bagcreate.Name,
-		&bagcreate.Params{SourcePath: localPath},
-	).Get(ctx, &createBag)
-	if e != nil {
-		logger.Error("System error", "message", e.Error())
-		result.SystemError(
-			temporalsdk_workflow.Now(ctx),
-			task,
-			"SIP bagging has failed.",
-			"An error has occurred while attempting to bag the SIP. Please try again, or ask a system administrator to investigate.",
-		)
-		return result, nil
-	}
-	task.Succeed(temporalsdk_workflow.Now(ctx), "SIP has been bagged")
-
-	return result, nil
+	return localPath, sip, nil
 }
 
 // createAPISImportTask submits metadata to APIS, waits for analysis, and records
-// the resulting custom metadata for the poststorage workflow. It returns false
-// when processing should stop after recording the failure in the workflow result.
+// the resulting custom metadata for the poststorage workflow. It returns the
+// APIS metadata collected so far, with an empty import task ID if the task
+// wasn't created, and false when processing should stop after recording the
+// failure in the workflow result.
 func (w *Preprocessing) createAPISImportTask(
 	ctx temporalsdk_workflow.Context,
 	result *childwf.PreprocessingResult,
 	sip sip.SIP,
-) bool {
+) (apis.CustomMetadata, bool) {
 	logger := temporalsdk_workflow.GetLogger(ctx)
 
 	task := result.NewTask(temporalsdk_workflow.Now(ctx), "Submit metadata to APIS")
@@ -633,7 +906,7 @@ func (w *Preprocessing) createAPISImportTask(
 			"failed to submit metadata to APIS.",
 			"An error occurred while creating the APIS import task. Please try again, or ask a system administrator to investigate.",
 		)
-		return false
+		return apis.CustomMetadata{}, false
 	}
 	metadata := apis.CustomMetadata{ImportTaskID: createAPISImportTask.TaskID}
 	task.Succeed(
@@ -665,7 +938,7 @@ func (w *Preprocessing) createAPISImportTask(
 			"failed to get an APIS analysis result.",
 			"An error occurred while checking the APIS import task status. Please try again, or ask a system administrator to investigate.",
 		)
-		return false
+		return metadata, false
 	}
 
 	switch pollAPISImportTaskStatus.AnalysisResult {
@@ -678,9 +951,10 @@ func (w *Preprocessing) createAPISImportTask(
 		)
 	case apisgen.AnalysisResultKonflikte:
 		decision, ok := w.waitForAPISDecision(ctx, result, task, metadata.ImportTaskID)
-		metadata.Decision = decision
+		metadata.Decision = decision.Option
+		metadata.DecidedBy = decision.User
 		if !ok {
-			return false
+			return metadata, false
 		}
 	case apisgen.AnalysisResultFehler:
 		logger.Error(
@@ -694,7 +968,7 @@ func (w *Preprocessing) createAPISImportTask(
 			"submission to APIS has failed.",
 			"APIS reported an analysis error while processing the submitted metadata. Please try again, or ask a system administrator to investigate.",
 		)
-		return false
+		return metadata, false
 	default:
 		logger.Error("System error", "message", fmt.Errorf(
 			"unexpected APIS analysis result %q for task %q",
@@ -707,7 +981,7 @@ func (w *Preprocessing) createAPISImportTask(
 			"submission to APIS has failed.",
 			"APIS returned an unexpected analysis result. Please ask a system administrator to investigate.",
 		)
-		return false
+		return metadata, false
 	}
 
 	data, err := metadata.Marshal()
@@ -720,22 +994,26 @@ func (w *Preprocessing) createAPISImportTask(
 			"APIS metadata recording has failed.",
 			"An error occurred while recording APIS metadata for later workflow steps. Please try again, or ask a system administrator to investigate.",
 		)
-		return false
+		return metadata, false
 	}
-	result.CustomMetadata = childwf.CustomMetadata{apis.CustomMetadataKey: data}
+	if result.CustomMetadata == nil {
+		result.CustomMetadata = childwf.CustomMetadata{}
+	}
+	result.CustomMetadata[apis.CustomMetadataKey] = data
 
-	return true
+	return metadata, true
 }
 
 // waitForAPISDecision asks the parent workflow for a decision. It returns the
-// selected decision and false when processing should stop after recording the
-// failure in the workflow result.
+// decision response, with the selected option and the user who selected it,
+// and false when processing should stop after recording the failure in the
+// workflow result.
 func (w *Preprocessing) waitForAPISDecision(
 	ctx temporalsdk_workflow.Context,
 	result *childwf.PreprocessingResult,
 	task *childwf.Task,
 	taskID string,
-) (string, bool) {
+) (childwf.DecisionResponse, bool) {
 	logger := temporalsdk_workflow.GetLogger(ctx)
 	info := temporalsdk_workflow.GetInfo(ctx)
 	if info.ParentWorkflowExecution == nil {
@@ -749,7 +1027,7 @@ func (w *Preprocessing) waitForAPISDecision(
 			"submission to APIS has failed.",
 			"An error occurred requesting a human decision on how to continue processing. Please try again, or ask a system administrator to investigate.",
 		)
-		return "", false
+		return childwf.DecisionResponse{}, false
 	}
 
 	err := temporalsdk_workflow.SignalExternalWorkflow(
@@ -779,7 +1057,7 @@ func (w *Preprocessing) waitForAPISDecision(
 			"submission to APIS has failed.",
 			"Could not notify the parent workflow that human review is required. Please try again, or ask a system administrator to investigate.",
 		)
-		return "", false
+		return childwf.DecisionResponse{}, false
 	}
 
 	var decision childwf.DecisionResponse
@@ -793,19 +1071,18 @@ func (w *Preprocessing) waitForAPISDecision(
 			taskID,
 			decision.Option,
 		)
-		return decision.Option, true
+		return decision, true
 	case apis.DecisionOptionCancelIngest:
-		// TODO: Add and use canceled as workflow outcome.
-		result.ValidationError(
+		// The decision isn't a failure, the workflow is canceled unless a
+		// system error is recorded while cleaning up.
+		result.Outcome = childwf.OutcomeCanceled
+		task.Succeed(
 			temporalsdk_workflow.Now(ctx),
-			task,
-			"ingest was canceled after APIS metadata conflict review.",
-			fmt.Sprintf(
-				"APIS detected metadata conflicts for import task ID %q and ingest was canceled by user decision.",
-				taskID,
-			),
+			"APIS detected metadata conflicts for import task ID %q and ingest was canceled with user decision %q.",
+			taskID,
+			decision.Option,
 		)
-		return decision.Option, false
+		return decision, false
 	default:
 		logger.Error("System error", "message", fmt.Errorf(
 			"unsupported decision %q for APIS import task %q", decision.Option, taskID,
@@ -819,14 +1096,287 @@ func (w *Preprocessing) waitForAPISDecision(
 				decision.Option,
 			),
 		)
-		return decision.Option, false
+		return decision, false
 	}
 }
 
-func (w *Preprocessing) extractSIP(
+// cancelAPISImportTask cancels the APIS import task when ingest was canceled
+// by user decision or has stopped with a system error, so the task isn't left
+// open in APIS. A failed cancellation is recorded as a system error.
+func (w *Preprocessing) cancelAPISImportTask(
+	ctx temporalsdk_workflow.Context,
+	result *childwf.PreprocessingResult,
+	metadata apis.CustomMetadata,
+) {
+	var reason, cancelledBy string
+	switch {
+	case metadata.Decision == apis.DecisionOptionCancelIngest:
+		reason = "Ingest was canceled by user decision after APIS metadata conflict review."
+		cancelledBy = metadata.User()
+	case result.Outcome == childwf.OutcomeSystemError:
+		reason = "Ingest was stopped by a system error."
+		cancelledBy = apis.DefaultUser
+	default:
+		return
+	}
+
+	logger := temporalsdk_workflow.GetLogger(ctx)
+	task := result.NewTask(temporalsdk_workflow.Now(ctx), "Cancel APIS import task")
+	err := temporalsdk_workflow.ExecuteActivity(
+		withAPISActivityOpts(ctx),
+		apis.CancelImportTaskActivityName,
+		&apis.CancelImportTaskParams{
+			TaskID:      metadata.ImportTaskID,
+			Reason:      reason,
+			CancelledBy: cancelledBy,
+		},
+	).Get(ctx, nil)
+	if err != nil {
+		logger.Error("System error", "message", err.Error())
+		result.SystemError(
+			temporalsdk_workflow.Now(ctx),
+			task,
+			"APIS import task cancellation has failed.",
+			fmt.Sprintf(
+				"An error occurred while canceling APIS import task ID %q. Please cancel the import task in APIS, or ask a system administrator to investigate.",
+				metadata.ImportTaskID,
+			),
+		)
+		return
+	}
+	task.Succeed(
+		temporalsdk_workflow.Now(ctx),
+		"Canceled APIS import task ID %q",
+		metadata.ImportTaskID,
+	)
+}
+
+// removeCanceledSIP deletes the extracted SIP and its inventory files from the
+// shared directory after ingest was canceled by user decision, as it won't be
+// processed any further.
+func (w *Preprocessing) removeCanceledSIP(
 	ctx temporalsdk_workflow.Context,
 	result *childwf.PreprocessingResult,
 	path string,
+) {
+	logger := temporalsdk_workflow.GetLogger(ctx)
+	task := result.NewTask(temporalsdk_workflow.Now(ctx), "Remove canceled SIP")
+	var removePaths removepaths.Result
+	err := temporalsdk_workflow.ExecuteActivity(
+		withFilesystemActivityOpts(ctx),
+		removepaths.Name,
+		&removepaths.Params{Paths: append([]string{path}, inventory.Paths(path)...)},
+	).Get(ctx, &removePaths)
+	if err != nil {
+		logger.Error("System error", "message", err.Error())
+		result.SystemError(
+			temporalsdk_workflow.Now(ctx),
+			task,
+			"canceled SIP removal has failed.",
+			"An error occurred while removing the extracted SIP after ingest was canceled. Please ask a system administrator to remove it.",
+		)
+		return
+	}
+	task.Succeed(temporalsdk_workflow.Now(ctx), "Removed the extracted SIP after ingest was canceled")
+}
+
+// removeInventory deletes the inventory files of the SIP at path from the
+// shared directory when the workflow doesn't succeed. They are removed with the
+// SIP when it's restructured, moved to the failed SIPs directory or removed
+// after ingest is canceled, but would be left behind by any other failure. A
+// removal error is only logged, as the files don't affect the SIP.
+func (w *Preprocessing) removeInventory(
+	ctx temporalsdk_workflow.Context,
+	result *childwf.PreprocessingResult,
+	path string,
+) {
+	// There is no inventory if the SIP wasn't extracted.
+	if path == "" || result.Outcome == childwf.OutcomeSuccess || result.Outcome == childwf.OutcomeCanceled {
+		return
+	}
+
+	var removePaths removepaths.Result
+	err := temporalsdk_workflow.ExecuteActivity(
+		withFilesystemActivityOpts(ctx),
+		removepaths.Name,
+		&removepaths.Params{Paths: inventory.Paths(path)},
+	).Get(ctx, &removePaths)
+	if err != nil {
+		temporalsdk_workflow.GetLogger(ctx).Error("SIP inventory removal has failed", "message", err.Error())
+	}
+}
+
+// recordFailedRun writes a premis.xml file recording the tasks of a run that
+// failed with a content or system error to a directory named after the SIP in
+// the failed SIPs directory. Nothing is recorded if there is no failed SIPs
+// directory. A SIP that failed with a content error is then moved next to it,
+// a SIP that failed with a system error is left in the shared directory, so
+// it can be processed again once the error is fixed.
+func (w *Preprocessing) recordFailedRun(
+	ctx temporalsdk_workflow.Context,
+	result *childwf.PreprocessingResult,
+	rep *report.Report,
+	params *childwf.PreprocessingParams,
+	path string,
+) {
+	if w.cfg.FailedSIPsPath == "" {
+		return
+	}
+	if result.Outcome != childwf.OutcomeContentError && result.Outcome != childwf.OutcomeSystemError {
+		return
+	}
+
+	// Build the report before adding the failed run tasks, so it only lists
+	// the tasks that lead to the failure.
+	failedReport := rep.WithResult(result)
+
+	destPath := filepath.Join(w.cfg.FailedSIPsPath, fmt.Sprintf("%s-%s", fsutil.BaseNoExt(params.SIPName), params.SIPID))
+
+	// The premis.xml file is written before the SIP is moved, as the move
+	// removes the SIP inventory used to list the SIP files.
+	logger := temporalsdk_workflow.GetLogger(ctx)
+	task := result.NewTask(temporalsdk_workflow.Now(ctx), "Create failed run premis.xml")
+	var writeFailedPREMIS activities.WriteFailedPREMISResult
+	err := temporalsdk_workflow.ExecuteActivity(
+		withFilesystemActivityOpts(ctx),
+		activities.WriteFailedPREMISName,
+		&activities.WriteFailedPREMISParams{
+			Path:     path,
+			DestPath: destPath,
+			Report:   failedReport,
+		},
+	).Get(ctx, &writeFailedPREMIS)
+	if err != nil {
+		logger.Error("System error", "message", err.Error())
+		result.SystemError(
+			temporalsdk_workflow.Now(ctx),
+			task,
+			"failed run premis.xml creation has failed.",
+			"An error occurred while recording the failed run in a premis.xml file. Please ask a system administrator to investigate.",
+		)
+		return
+	}
+	task.Succeed(
+		temporalsdk_workflow.Now(ctx),
+		"Created a premis.xml file recording the failed run in the %s directory",
+		filepath.Base(destPath),
+	)
+
+	if path != "" && result.Outcome == childwf.OutcomeContentError {
+		w.moveFailedSIP(ctx, result, failedReport, path, destPath)
+	}
+}
+
+// moveFailedSIP moves a SIP that failed with a content error to the destPath
+// directory in the failed SIPs directory, with a validation report listing the
+// workflow tasks and the validation failures, so the depositor can review and
+// fix it.
+func (w *Preprocessing) moveFailedSIP(
+	ctx temporalsdk_workflow.Context,
+	result *childwf.PreprocessingResult,
+	failedReport report.Report,
+	path, destPath string,
+) {
+	logger := temporalsdk_workflow.GetLogger(ctx)
+	task := result.NewTask(temporalsdk_workflow.Now(ctx), "Move failed SIP")
+	var moveFailedSIP activities.MoveFailedSIPResult
+	err := temporalsdk_workflow.ExecuteActivity(
+		withFilesystemActivityOpts(ctx),
+		activities.MoveFailedSIPName,
+		&activities.MoveFailedSIPParams{
+			Path:     path,
+			DestPath: destPath,
+			Report:   failedReport,
+		},
+	).Get(ctx, &moveFailedSIP)
+	if err != nil {
+		logger.Error("System error", "message", err.Error())
+		result.SystemError(
+			temporalsdk_workflow.Now(ctx),
+			task,
+			"failed SIP move has failed.",
+			"An error occurred while moving the SIP to the failed SIPs directory. Please ask a system administrator to investigate.",
+		)
+		return
+	}
+	task.Succeed(
+		temporalsdk_workflow.Now(ctx),
+		"Moved the SIP and its validation report to the failed SIPs directory",
+	)
+
+	// Tell the depositor where to find the SIP now that it has been moved.
+	for _, t := range result.Tasks {
+		if t.Outcome == childwf.TaskOutcomeValidationFailure {
+			t.Message += "\n\nYour SIP has been moved to the failed-sips directory."
+		}
+	}
+}
+
+// attachReport adds the validation report entries to the workflow result
+// custom metadata.
+func attachReport(
+	ctx temporalsdk_workflow.Context,
+	result *childwf.PreprocessingResult,
+	rep *report.Report,
+) {
+	data, err := rep.CustomMetadata()
+	if err != nil {
+		// Don't fail the workflow if the report can't be encoded.
+		temporalsdk_workflow.GetLogger(ctx).Error("Validation report", "message", err.Error())
+		return
+	}
+
+	if result.CustomMetadata == nil {
+		result.CustomMetadata = childwf.CustomMetadata{}
+	}
+	result.CustomMetadata[report.CustomMetadataKey] = data
+}
+
+// validationEntry returns a validation report error entry.
+func validationEntry(check string, code report.Code, path, msg string) report.Entry {
+	return report.Entry{
+		Check:    check,
+		Severity: report.SeverityError,
+		Path:     path,
+		Code:     code,
+		Message:  msg,
+	}
+}
+
+// toolEntry returns a validation report entry for a failure found by tool.
+func toolEntry(tool toolinfo.Tool, check string, code report.Code, path, msg string) report.Entry {
+	e := validationEntry(check, code, path, msg)
+	e.Tool = tool.Name
+	e.ToolVersion = tool.Version
+
+	return e
+}
+
+// toolNames returns the name and version of each tool.
+func toolNames(tools []toolinfo.Tool) []string {
+	names := make([]string, len(tools))
+	for i, t := range tools {
+		names[i] = t.String()
+	}
+
+	return names
+}
+
+// relPath returns path relative to root, or path if it can't be made
+// relative.
+func relPath(root, path string) string {
+	if r, err := filepath.Rel(root, path); err == nil {
+		return r
+	}
+	return path
+}
+
+func extractSIP(
+	ctx temporalsdk_workflow.Context,
+	result *childwf.PreprocessingResult,
+	rep *report.Report,
+	sharedPath string,
+	path string,
 	sipName string,
 ) string {
 	logger := temporalsdk_workflow.GetLogger(ctx)
@@ -854,21 +1404,22 @@ func (w *Preprocessing) extractSIP(
 
 	// Verify that the extraction directory has the same name as the uploaded
 	// archive minus the file extension (e.g. "example.zip" -> "example").
-	if filepath.Base(archiveExtract.ExtractPath) != fsutil.BaseNoExt(sipName) {
+	if msg := sip.TopLevelDirFailure(archiveExtract.ExtractPath, sipName); msg != "" {
+		rep.AddFor(
+			task.Name,
+			validationEntry(archiveextract.Name, report.CodeSIPMissingTopLevelDirectory, "", msg),
+		)
 		result.ValidationError(
 			temporalsdk_workflow.Now(ctx),
 			task,
 			"SIP extraction has failed.",
-			fmt.Sprintf(
-				"The extracted SIP is missing the top-level %q folder.",
-				fsutil.BaseNoExt(sipName),
-			),
+			msg,
 			"Please ensure that the SIP is well-formed and try again.",
 		)
 		return archiveExtract.ExtractPath
 	}
 
-	result.RelativePath, e = filepath.Rel(w.cfg.SharedPath, archiveExtract.ExtractPath)
+	result.RelativePath, e = filepath.Rel(sharedPath, archiveExtract.ExtractPath)
 	if e != nil {
 		logger.Error("System error", "message", fmt.Errorf("extract SIP: set relative path: %w", e))
 		result.SystemError(
@@ -888,7 +1439,7 @@ func (w *Preprocessing) extractSIP(
 	return archiveExtract.ExtractPath
 }
 
-func writePREMISFile(ctx temporalsdk_workflow.Context, sip sip.SIP) error {
+func writePREMISFile(ctx temporalsdk_workflow.Context, sip sip.SIP, tools toolinfo.Tools, rep *report.Report) error {
 	var e error
 	path := filepath.Join(sip.Path, "metadata", "premis.xml")
 
@@ -906,13 +1457,31 @@ func writePREMISFile(ctx temporalsdk_workflow.Context, sip sip.SIP) error {
 		return e
 	}
 
+	// Add PREMIS event noting the SIP checksums were verified against the
+	// manifest.
+	var addPREMISEvent activities.AddPREMISEventResult
+	e = temporalsdk_workflow.ExecuteActivity(
+		withFilesystemActivityOpts(ctx),
+		activities.AddPREMISEventName,
+		&activities.AddPREMISEventParams{
+			PREMISFilePath: path,
+			Agent:          premis.AgentDefault(),
+			Type:           "fixity check",
+			Detail:         "name=\"Verify SIP checksums\"",
+			OutcomeDetail:  "File checksum matches the SIP manifest checksum",
+			Failures:       nil,
+		},
+	).Get(ctx, &addPREMISEvent)
+	if e != nil {
+		return e
+	}
+
 	// Add PREMIS event noting validate structure result.
 	validateStructureOutcomeDetail := fmt.Sprintf(
 		"SIP structure identified: %s. SIP structure matches validation criteria.",
 		sip.Type.String(),
 	)
 
-	var addPREMISEvent activities.AddPREMISEventResult
 	e = temporalsdk_workflow.ExecuteActivity(
 		withFilesystemActivityOpts(ctx),
 		activities.AddPREMISEventName,
@@ -984,6 +1553,7 @@ func writePREMISFile(ctx temporalsdk_workflow.Context, sip sip.SIP) error {
 				Outcome:       "valid",
 				OutcomeDetail: "File format complies with specification",
 			},
+			Failures: fileFormatFailures(rep),
 		},
 	).Get(ctx, &addPREMISEvent)
 	if e != nil {
@@ -996,7 +1566,7 @@ func writePREMISFile(ctx temporalsdk_workflow.Context, sip sip.SIP) error {
 		activities.AddPREMISEventName,
 		&activities.AddPREMISEventParams{
 			PREMISFilePath: path,
-			Agent:          premis.AgentDefault(),
+			Agent:          tools.XMLLint.PREMISAgent(),
 			Type:           "validation",
 			Detail:         "name=\"Validate SIP metadata\"",
 			OutcomeDetail:  "Metadata validation successful",
@@ -1007,23 +1577,46 @@ func writePREMISFile(ctx temporalsdk_workflow.Context, sip sip.SIP) error {
 		return e
 	}
 
-	// Add Enduro PREMIS agent.
-	var addPREMISEnduroAgent activities.AddPREMISAgentResult
-	e = temporalsdk_workflow.ExecuteActivity(
-		withFilesystemActivityOpts(ctx),
-		activities.AddPREMISAgentName,
-		&activities.AddPREMISAgentParams{
-			PREMISFilePath: path,
-			Agent:          premis.AgentDefault(),
-		},
-	).Get(ctx, &addPREMISEnduroAgent)
-	if e != nil {
-		return e
+	// Add the xmllint and Enduro PREMIS agents.
+	for _, agent := range []premis.Agent{tools.XMLLint.PREMISAgent(), premis.AgentDefault()} {
+		var addPREMISAgent activities.AddPREMISAgentResult
+		e = temporalsdk_workflow.ExecuteActivity(
+			withFilesystemActivityOpts(ctx),
+			activities.AddPREMISAgentName,
+			&activities.AddPREMISAgentParams{
+				PREMISFilePath: path,
+				Agent:          agent,
+			},
+		).Get(ctx, &addPREMISAgent)
+		if e != nil {
+			return e
+		}
 	}
 
 	return nil
 }
 
+// fileFormatFailures returns the failures found by the file format validation
+// in each SIP file, by validator name and file path. It returns nil if no
+// failures were found.
+func fileFormatFailures(rep *report.Report) map[string]map[string][]string {
+	var failures map[string]map[string][]string
+	for _, e := range rep.Entries {
+		if e.Check != activities.ValidateFilesName {
+			continue
+		}
+		if failures == nil {
+			failures = make(map[string]map[string][]string)
+		}
+		if failures[e.Tool] == nil {
+			failures[e.Tool] = make(map[string][]string)
+		}
+		failures[e.Tool][e.Path] = append(failures[e.Tool][e.Path], e.Message)
+	}
+
+	return failures
+}
+
 // ul formats a list of strings as an unordered, Markdown-style list.
 func ul(items []string) string {
 	if len(items) == 0 {
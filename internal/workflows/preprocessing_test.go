@@ -1,6 +1,7 @@
 package workflows_test
 
 import (
+	"crypto/rand"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -14,11 +15,13 @@ import (
 	"github.com/artefactual-sdps/temporal-activities/bagcreate"
 	"github.com/artefactual-sdps/temporal-activities/bagvalidate"
 	"github.com/artefactual-sdps/temporal-activities/ffvalidate"
+	"github.com/artefactual-sdps/temporal-activities/removepaths"
 	"github.com/artefactual-sdps/temporal-activities/xmlvalidate"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/suite"
 	"go.artefactual.dev/tools/fsutil"
+	"go.artefactual.dev/tools/temporal"
 	temporalsdk_activity "go.temporal.io/sdk/activity"
 	temporalsdk_testsuite "go.temporal.io/sdk/testsuite"
 	temporalsdk_worker "go.temporal.io/sdk/worker"
@@ -29,10 +32,14 @@ import (
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/apis"
 	apisgen "github.com/artefactual-sdps/preprocessing-sfa/internal/apis/gen"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/config"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/inventory"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/localact"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/pips"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/premis"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/progress"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/report"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/sip"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/toolinfo"
 	"github.com/artefactual-sdps/preprocessing-sfa/internal/workflows"
 )
 
@@ -40,6 +47,11 @@ const (
 	sipName    = "SIP_20240606_dept.zip"
 	apisTaskID = "task-000001"
 
+	apisDecisionUser = "archivist@example.com"
+
+	// testRunID is the workflow run ID set by the Temporal test environment.
+	testRunID = "default-test-run-id"
+
 	// The relPath reflects an actual SFA ZIP path passed from Enduro to
 	// preprocessing-sfa — it seems that ingest prepends "SIP_" to the original
 	// file name and appends a UUID.
@@ -127,6 +139,24 @@ var (
 	testTime = time.Date(2024, 6, 6, 15, 8, 39, 0, time.UTC)
 	sipUUID  = uuid.MustParse("8fdfaea1-06ed-4cf6-8bdf-d15d80420f35")
 
+	testTools = toolinfo.Tools{
+		BagIt: toolinfo.Tool{
+			Name:    "bagvalidate",
+			Version: "v0.4.0",
+			URL:     "https://github.com/artefactual-sdps/temporal-activities",
+		},
+		XMLLint: toolinfo.Tool{
+			Name:    "xmllint",
+			Version: "2.13.8",
+			URL:     "https://gitlab.gnome.org/GNOME/libxml2",
+		},
+		Siegfried: toolinfo.Tool{
+			Name:    "Siegfried",
+			Version: "1.11.2",
+			URL:     "https://github.com/richardlehane/siegfried",
+		},
+	}
+
 	preAPISEvents = []*childwf.Task{
 		{
 			Name:        "Calculate SIP checksum",
@@ -151,7 +181,7 @@ var (
 		},
 		{
 			Name:        "Validate Bag",
-			Message:     "Bag successfully validated",
+			Message:     "Bag successfully validated using bagvalidate v0.4.0",
 			Outcome:     childwf.TaskOutcomeSuccess,
 			StartedAt:   testTime,
 			CompletedAt: testTime,
@@ -198,6 +228,20 @@ var (
 			StartedAt:   testTime,
 			CompletedAt: testTime,
 		},
+		{
+			Name:        "Identify SIP file formats",
+			Message:     "File formats identified using Siegfried 1.11.2",
+			Outcome:     childwf.TaskOutcomeSuccess,
+			StartedAt:   testTime,
+			CompletedAt: testTime,
+		},
+		{
+			Name:        "Verify digitization PREMIS",
+			Message:     "2 file(s) match the format, size and checksum declared in their digitization PREMIS",
+			Outcome:     childwf.TaskOutcomeSuccess,
+			StartedAt:   testTime,
+			CompletedAt: testTime,
+		},
 		{
 			Name:        "Validate SIP file formats",
 			Message:     "No invalid files found",
@@ -207,7 +251,7 @@ var (
 		},
 		{
 			Name: "Validate SIP metadata",
-			Message: `Metadata validation successful on the following file(s):
+			Message: `Metadata validation successful using xmllint 2.13.8 on the following file(s):
 
 - UpdatedAreldaMetadata.xml`,
 			Outcome:     childwf.TaskOutcomeSuccess,
@@ -216,7 +260,7 @@ var (
 		},
 		{
 			Name:        "Validate logical metadata",
-			Message:     "Logical metadata validation successful",
+			Message:     "Logical metadata validation successful using xmllint 2.13.8",
 			Outcome:     childwf.TaskOutcomeSuccess,
 			StartedAt:   testTime,
 			CompletedAt: testTime,
@@ -245,6 +289,13 @@ var (
 			StartedAt:   testTime,
 			CompletedAt: testTime,
 		},
+		{
+			Name:        "Create validation-report.json",
+			Message:     "Created a validation-report.json file and stored it in the metadata directory",
+			Outcome:     childwf.TaskOutcomeSuccess,
+			StartedAt:   testTime,
+			CompletedAt: testTime,
+		},
 		{
 			Name:        "Bag SIP",
 			Message:     "SIP has been bagged",
@@ -261,6 +312,7 @@ type PreprocessingTestSuite struct {
 
 	env      *temporalsdk_testsuite.TestWorkflowEnvironment
 	workflow *workflows.Preprocessing
+	progress *progress.Store
 	testDir  string
 	sipPath  string
 }
@@ -270,6 +322,7 @@ func (s *PreprocessingTestSuite) SetupTest(cfg *config.Config) {
 	s.env.SetStartTime(testTime)
 	s.env.SetWorkerOptions(temporalsdk_worker.Options{EnableSessionWorker: true})
 	s.testDir = s.T().TempDir()
+	s.progress = progress.NewStore()
 	cfg.Preprocessing.SharedPath = s.testDir
 
 	sp := filepath.Join(s.testDir, relPath)
@@ -299,16 +352,20 @@ func (s *PreprocessingTestSuite) SetupTest(cfg *config.Config) {
 		activities.NewIdentifySIP().Execute,
 		temporalsdk_activity.RegisterOptions{Name: activities.IdentifySIPName},
 	)
+	s.env.RegisterActivityWithOptions(
+		activities.NewCreateInventory(nil, "").Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.CreateInventoryName},
+	)
 	s.env.RegisterActivityWithOptions(
 		activities.NewValidateStructure().Execute,
 		temporalsdk_activity.RegisterOptions{Name: activities.ValidateStructureName},
 	)
 	s.env.RegisterActivityWithOptions(
-		activities.NewValidateSIPName().Execute,
+		activities.NewValidateSIPName(cfg.Preprocessing.Naming.Rules()).Execute,
 		temporalsdk_activity.RegisterOptions{Name: activities.ValidateSIPNameName},
 	)
 	s.env.RegisterActivityWithOptions(
-		activities.NewVerifyManifest().Execute,
+		activities.NewVerifyManifest(0).Execute,
 		temporalsdk_activity.RegisterOptions{Name: activities.VerifyManifestName},
 	)
 	s.env.RegisterActivityWithOptions(
@@ -316,11 +373,11 @@ func (s *PreprocessingTestSuite) SetupTest(cfg *config.Config) {
 		temporalsdk_activity.RegisterOptions{Name: ffvalidate.Name},
 	)
 	s.env.RegisterActivityWithOptions(
-		activities.NewValidateFiles(nil).Execute,
+		activities.NewValidateFiles(nil, 0).Execute,
 		temporalsdk_activity.RegisterOptions{Name: activities.ValidateFilesName},
 	)
 	s.env.RegisterActivityWithOptions(
-		activities.NewAddPREMISObjects(nil).Execute,
+		activities.NewAddPREMISObjects(nil, nil, nil).Execute,
 		temporalsdk_activity.RegisterOptions{Name: activities.AddPREMISObjectsName},
 	)
 	s.env.RegisterActivityWithOptions(
@@ -343,6 +400,10 @@ func (s *PreprocessingTestSuite) SetupTest(cfg *config.Config) {
 		activities.NewValidatePREMIS(nil).Execute,
 		temporalsdk_activity.RegisterOptions{Name: activities.ValidatePREMISName},
 	)
+	s.env.RegisterActivityWithOptions(
+		activities.NewValidateDigitizationPREMIS(nil).Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.ValidateDigitizationPREMISName},
+	)
 	s.env.RegisterActivityWithOptions(
 		activities.NewTransformSIP().Execute,
 		temporalsdk_activity.RegisterOptions{Name: activities.TransformSIPName},
@@ -351,6 +412,10 @@ func (s *PreprocessingTestSuite) SetupTest(cfg *config.Config) {
 		activities.NewWriteIdentifierFile().Execute,
 		temporalsdk_activity.RegisterOptions{Name: activities.WriteIdentifierFileName},
 	)
+	s.env.RegisterActivityWithOptions(
+		activities.NewWriteValidationReport().Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.WriteValidationReportName},
+	)
 	s.env.RegisterActivityWithOptions(
 		apis.NewCreateImportTaskActivity(nil).Execute,
 		temporalsdk_activity.RegisterOptions{Name: apis.CreateImportTaskActivityName},
@@ -359,12 +424,32 @@ func (s *PreprocessingTestSuite) SetupTest(cfg *config.Config) {
 		apis.NewPollImportTaskStatusActivity(nil, 0).Execute,
 		temporalsdk_activity.RegisterOptions{Name: apis.PollImportTaskStatusActivityName},
 	)
+	s.env.RegisterActivityWithOptions(
+		apis.NewCancelImportTaskActivity(nil).Execute,
+		temporalsdk_activity.RegisterOptions{Name: apis.CancelImportTaskActivityName},
+	)
+	s.env.RegisterActivityWithOptions(
+		removepaths.New().Execute,
+		temporalsdk_activity.RegisterOptions{Name: removepaths.Name},
+	)
+	s.env.RegisterActivityWithOptions(
+		activities.NewWriteFailedPREMIS(rand.Reader).Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.WriteFailedPREMISName},
+	)
+	s.env.RegisterActivityWithOptions(
+		activities.NewMoveFailedSIP().Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.MoveFailedSIPName},
+	)
+	s.env.RegisterActivityWithOptions(
+		activities.NewCopySIP().Execute,
+		temporalsdk_activity.RegisterOptions{Name: activities.CopySIPName},
+	)
 	s.env.RegisterActivityWithOptions(
 		bagcreate.New(cfg.Preprocessing.BagCreate).Execute,
 		temporalsdk_activity.RegisterOptions{Name: bagcreate.Name},
 	)
 
-	s.workflow = workflows.NewPreprocessing(nil, cfg.Preprocessing, cfg.APIS.Enabled)
+	s.workflow = workflows.NewPreprocessing(nil, cfg.Preprocessing, cfg.APIS.Enabled, testTools, s.progress)
 	s.env.RegisterWorkflow(s.workflow.Execute)
 }
 
@@ -514,6 +599,13 @@ func (s *PreprocessingTestSuite) preAPISActivities(ar apisgen.AnalysisResult) (s
 	).Return(
 		&activities.IdentifySIPResult{SIP: expectedSIP}, nil,
 	)
+	s.env.OnActivity(
+		activities.CreateInventoryName,
+		sessionCtx,
+		&activities.CreateInventoryParams{SIP: expectedSIP},
+	).Return(
+		&activities.CreateInventoryResult{}, nil,
+	)
 	s.env.OnActivity(
 		activities.ValidateStructureName,
 		sessionCtx,
@@ -535,6 +627,13 @@ func (s *PreprocessingTestSuite) preAPISActivities(ar apisgen.AnalysisResult) (s
 	).Return(
 		&activities.VerifyManifestResult{}, nil,
 	)
+	s.env.OnActivity(
+		activities.ValidateDigitizationPREMISName,
+		sessionCtx,
+		&activities.ValidateDigitizationPREMISParams{SIP: expectedSIP},
+	).Return(
+		&activities.ValidateDigitizationPREMISResult{Files: 2}, nil,
+	)
 	s.env.OnActivity(
 		activities.ValidateFilesName,
 		sessionCtx,
@@ -555,7 +654,7 @@ func (s *PreprocessingTestSuite) preAPISActivities(ar apisgen.AnalysisResult) (s
 	s.env.OnActivity(
 		activities.ValidatePREMISName,
 		sessionCtx,
-		&activities.ValidatePREMISParams{Path: expectedSIP.LogicalMDPath},
+		&activities.ValidatePREMISParams{Path: expectedSIP.LogicalMDPath, SIPPath: expectedSIP.Path},
 	).Return(
 		&activities.ValidatePREMISResult{}, nil,
 	)
@@ -595,6 +694,20 @@ func (s *PreprocessingTestSuite) postAPISActivities(expectedSIP sip.SIP) {
 	).Return(
 		&activities.AddPREMISObjectsResult{}, nil,
 	)
+	s.env.OnActivity(
+		activities.AddPREMISEventName,
+		sessionCtx,
+		&activities.AddPREMISEventParams{
+			PREMISFilePath: premisFilePath,
+			Agent:          premis.AgentDefault(),
+			Type:           "fixity check",
+			Detail:         "name=\"Verify SIP checksums\"",
+			OutcomeDetail:  "File checksum matches the SIP manifest checksum",
+			Failures:       nil,
+		},
+	).Return(
+		&activities.AddPREMISEventResult{}, nil,
+	)
 	s.env.OnActivity(
 		activities.AddPREMISEventName,
 		sessionCtx,
@@ -646,7 +759,7 @@ func (s *PreprocessingTestSuite) postAPISActivities(expectedSIP sip.SIP) {
 		sessionCtx,
 		&activities.AddPREMISEventParams{
 			PREMISFilePath: premisFilePath,
-			Agent:          premis.AgentDefault(),
+			Agent:          testTools.XMLLint.PREMISAgent(),
 			Type:           "validation",
 			Detail:         "name=\"Validate SIP metadata\"",
 			OutcomeDetail:  "Metadata validation successful",
@@ -655,6 +768,16 @@ func (s *PreprocessingTestSuite) postAPISActivities(expectedSIP sip.SIP) {
 	).Return(
 		&activities.AddPREMISEventResult{}, nil,
 	)
+	s.env.OnActivity(
+		activities.AddPREMISAgentName,
+		sessionCtx,
+		&activities.AddPREMISAgentParams{
+			PREMISFilePath: premisFilePath,
+			Agent:          testTools.XMLLint.PREMISAgent(),
+		},
+	).Return(
+		&activities.AddPREMISAgentResult{}, nil,
+	)
 	s.env.OnActivity(
 		activities.AddPREMISAgentName,
 		sessionCtx,
@@ -681,6 +804,22 @@ func (s *PreprocessingTestSuite) postAPISActivities(expectedSIP sip.SIP) {
 			Path: filepath.Join(s.sipPath, "metadata", "identifiers.json"),
 		}, nil,
 	)
+	s.env.OnActivity(
+		activities.WriteValidationReportName,
+		sessionCtx,
+		mock.MatchedBy(func(params *activities.WriteValidationReportParams) bool {
+			// The report lists the tasks run before writing it, which are
+			// already checked in the workflow result.
+			return params.PIP == expectedPIP &&
+				params.Report.SIPName == sipName &&
+				params.Report.Outcome == "success" &&
+				len(params.Report.Entries) == 0
+		}),
+	).Return(
+		&activities.WriteValidationReportResult{
+			Path: filepath.Join(s.sipPath, "metadata", report.Filename),
+		}, nil,
+	)
 	s.env.OnActivity(
 		bagcreate.Name,
 		sessionCtx,
@@ -720,14 +859,105 @@ func apisTasks(
 	return events
 }
 
+func (s *PreprocessingTestSuite) cancelAPISActivity(taskID, reason, cancelledBy string, err error) {
+	var result *apis.CancelImportTaskResult
+	if err == nil {
+		result = &apis.CancelImportTaskResult{}
+	}
+
+	s.env.OnActivity(
+		apis.CancelImportTaskActivityName,
+		mock.AnythingOfType("*context.timerCtx"),
+		&apis.CancelImportTaskParams{
+			TaskID:      taskID,
+			Reason:      reason,
+			CancelledBy: cancelledBy,
+		},
+	).Return(result, err)
+}
+
+func (s *PreprocessingTestSuite) removeCanceledSIPActivity(path string, err error) {
+	var result *removepaths.Result
+	if err == nil {
+		result = &removepaths.Result{}
+	}
+
+	s.env.OnActivity(
+		removepaths.Name,
+		mock.AnythingOfType("*context.timerCtx"),
+		&removepaths.Params{Paths: append([]string{path}, inventory.Paths(path)...)},
+	).Return(result, err)
+}
+
+// removeInventoryActivity expects the removal of the inventory files of the
+// SIP at path after a failed run.
+func (s *PreprocessingTestSuite) removeInventoryActivity(path string) {
+	s.env.OnActivity(
+		removepaths.Name,
+		mock.AnythingOfType("*context.timerCtx"),
+		&removepaths.Params{Paths: inventory.Paths(path)},
+	).Return(&removepaths.Result{}, nil).Once()
+}
+
+func removeCanceledSIPTask() *childwf.Task {
+	return &childwf.Task{
+		Name:        "Remove canceled SIP",
+		Message:     "Removed the extracted SIP after ingest was canceled",
+		Outcome:     childwf.TaskOutcomeSuccess,
+		StartedAt:   testTime,
+		CompletedAt: testTime,
+	}
+}
+
+func cancelAPISTask(taskID string) *childwf.Task {
+	return &childwf.Task{
+		Name:        "Cancel APIS import task",
+		Message:     fmt.Sprintf("Canceled APIS import task ID %q", taskID),
+		Outcome:     childwf.TaskOutcomeSuccess,
+		StartedAt:   testTime,
+		CompletedAt: testTime,
+	}
+}
+
+func failedRunPREMISTask() *childwf.Task {
+	return &childwf.Task{
+		Name: "Create failed run premis.xml",
+		Message: fmt.Sprintf(
+			"Created a premis.xml file recording the failed run in the SIP_20240606_dept-%s directory",
+			sipUUID,
+		),
+		Outcome:     childwf.TaskOutcomeSuccess,
+		StartedAt:   testTime,
+		CompletedAt: testTime,
+	}
+}
+
+var unidentifiedSIPEntry = report.Entry{
+	Check:    activities.IdentifySIPName,
+	Task:     "Identify SIP structure",
+	Severity: report.SeverityError,
+	Code:     report.CodeSIPUnidentified,
+	Message:  "The package type could not be identified",
+}
+
 func apisCustomMetadata(taskID, decision string) childwf.CustomMetadata {
-	return childwf.CustomMetadata{
-		apis.CustomMetadataKey: json.RawMessage(fmt.Sprintf(
-			`{"importTaskId":%q,"decision":%q}`,
-			taskID,
-			decision,
-		)),
+	cm := reportCustomMetadata()
+	cm[apis.CustomMetadataKey] = json.RawMessage(fmt.Sprintf(
+		`{"importTaskId":%q,"decision":%q}`,
+		taskID,
+		decision,
+	))
+
+	return cm
+}
+
+func reportCustomMetadata(entries ...report.Entry) childwf.CustomMetadata {
+	data, err := report.Report{Entries: entries}.CustomMetadata()
+	if err != nil {
+		panic(err)
 	}
+
+	return childwf.CustomMetadata{report.CustomMetadataKey: data}
 }
 
 func (s *PreprocessingTestSuite) executeAsChildWithHumanReview(
@@ -870,8 +1100,9 @@ func (s *PreprocessingTestSuite) TestIdentifySIPFailure() {
 	s.NoError(err)
 	s.Equal(
 		&childwf.PreprocessingResult{
-			Outcome:      childwf.OutcomeContentError,
-			RelativePath: relPath,
+			Outcome:        childwf.OutcomeContentError,
+			RelativePath:   relPath,
+			CustomMetadata: reportCustomMetadata(unidentifiedSIPEntry),
 			Tasks: []*childwf.Task{
 				{
 					Name:        "Extract SIP",
@@ -893,6 +1124,236 @@ Enduro could not identify the package type. Please ensure that your SIP matches
 		},
 		&result,
 	)
+
+	// The failed run isn't recorded, as there is no failed SIPs directory.
+	s.NoDirExists(filepath.Join(s.testDir, "SIP_20240606_dept-"+sipUUID.String()))
+}
+
+func (s *PreprocessingTestSuite) identifySIPFailureActivities(premisErr, moveErr error) string {
+	extractPath := filepath.Join(filepath.Dir(s.sipPath), fsutil.BaseNoExt(filepath.Base(sipName)))
+	destPath := "/failed-sips/SIP_20240606_dept-" + sipUUID.String()
+	sessionCtx := mock.AnythingOfType("*context.timerCtx")
+
+	s.env.OnActivity(
+		archiveextract.Name,
+		sessionCtx,
+		&archiveextract.Params{SourcePath: s.sipPath},
+	).Return(
+		&archiveextract.Result{ExtractPath: extractPath}, nil,
+	)
+	s.env.OnActivity(
+		activities.IdentifySIPName,
+		sessionCtx,
+		&activities.IdentifySIPParams{Path: extractPath},
+	).Return(
+		nil, fmt.Errorf("IdentifySIP: NewSIP: stat : no such file or directory"),
+	)
+
+	failedReport := report.Report{
+		SIPID:   sipUUID.String(),
+		SIPName: sipName,
+		Outcome: "content error",
+		Tasks: []report.Task{
+			{
+				Name:        "Extract SIP",
+				Outcome:     "success",
+				Message:     "SIP extracted",
+				StartedAt:   testTime,
+				CompletedAt: testTime,
+			},
+			{
+				Name:    "Identify SIP structure",
+				Outcome: "validation failure",
+				Message: `Content error: SIP identification has failed.
+
+Enduro could not identify the package type. Please ensure that your SIP matches one of the supported package structures.`,
+				StartedAt:   testTime,
+				CompletedAt: testTime,
+			},
+		},
+		Entries: []report.Entry{unidentifiedSIPEntry},
+	}
+
+	var premisResult *activities.WriteFailedPREMISResult
+	if premisErr == nil {
+		premisResult = &activities.WriteFailedPREMISResult{Path: destPath + "/premis.xml"}
+	}
+	s.env.OnActivity(
+		activities.WriteFailedPREMISName,
+		sessionCtx,
+		&activities.WriteFailedPREMISParams{
+			Path:     extractPath,
+			DestPath: destPath,
+			Report:   failedReport,
+		},
+	).Return(premisResult, premisErr)
+	s.removeInventoryActivity(extractPath)
+	if premisErr != nil {
+		return extractPath
+	}
+
+	var moveResult *activities.MoveFailedSIPResult
+	if moveErr == nil {
+		moveResult = &activities.MoveFailedSIPResult{
+			Path:       destPath + "/SIP_20240606_dept",
+			ReportPath: destPath + "/validation-report.json",
+		}
+	}
+	s.env.OnActivity(
+		activities.MoveFailedSIPName,
+		sessionCtx,
+		&activities.MoveFailedSIPParams{
+			Path:     extractPath,
+			DestPath: destPath,
+			Report:   failedReport,
+		},
+	).Return(moveResult, moveErr)
+
+	return extractPath
+}
+
+func (s *PreprocessingTestSuite) TestFailedSIPMoved() {
+	s.SetupTest(&config.Config{
+		Preprocessing: config.PreprocessingConfig{FailedSIPsPath: "/failed-sips"},
+	})
+	extractPath := s.identifySIPFailureActivities(nil, nil)
+
+	s.env.ExecuteWorkflow(
+		s.workflow.Execute,
+		&childwf.PreprocessingParams{
+			RelativePath: relPath,
+			SIPID:        sipUUID,
+			SIPName:      sipName,
+		},
+	)
+	s.True(s.env.IsWorkflowCompleted())
+
+	relPath, err := filepath.Rel(s.testDir, extractPath)
+	s.NoError(err)
+
+	var result childwf.PreprocessingResult
+	err = s.env.GetWorkflowResult(&result)
+	s.NoError(err)
+	s.Equal(
+		&childwf.PreprocessingResult{
+			Outcome:        childwf.OutcomeContentError,
+			RelativePath:   relPath,
+			CustomMetadata: reportCustomMetadata(unidentifiedSIPEntry),
+			Tasks: []*childwf.Task{
+				{
+					Name:        "Extract SIP",
+					Message:     "SIP extracted",
+					Outcome:     childwf.TaskOutcomeSuccess,
+					StartedAt:   testTime,
+					CompletedAt: testTime,
+				},
+				{
+					Name: "Identify SIP structure",
+					Message: `Content error: SIP identification has failed.
+
+Enduro could not identify the package type. Please ensure that your SIP matches one of the supported package structures.
+
+Your SIP has been moved to the failed-sips directory.`,
+					Outcome:     childwf.TaskOutcomeValidationFailure,
+					StartedAt:   testTime,
+					CompletedAt: testTime,
+				},
+				failedRunPREMISTask(),
+				{
+					Name:        "Move failed SIP",
+					Message:     "Moved the SIP and its validation report to the failed SIPs directory",
+					Outcome:     childwf.TaskOutcomeSuccess,
+					StartedAt:   testTime,
+					CompletedAt: testTime,
+				},
+			},
+		},
+		&result,
+	)
+}
+
+func (s *PreprocessingTestSuite) TestFailedSIPMoveFailure() {
+	s.SetupTest(&config.Config{
+		Preprocessing: config.PreprocessingConfig{FailedSIPsPath: "/failed-sips"},
+	})
+	extractPath := s.identifySIPFailureActivities(
+		nil,
+		temporal.NewNonRetryableError(errors.New("MoveFailedSIP: permission denied")),
+	)
+
+	s.env.ExecuteWorkflow(
+		s.workflow.Execute,
+		&childwf.PreprocessingParams{
+			RelativePath: relPath,
+			SIPID:        sipUUID,
+			SIPName:      sipName,
+		},
+	)
+	s.True(s.env.IsWorkflowCompleted())
+
+	relPath, err := filepath.Rel(s.testDir, extractPath)
+	s.NoError(err)
+
+	var result childwf.PreprocessingResult
+	err = s.env.GetWorkflowResult(&result)
+	s.NoError(err)
+	s.Equal(childwf.OutcomeSystemError, result.Outcome)
+	s.Equal(relPath, result.RelativePath)
+	s.Len(result.Tasks, 4)
+	s.Equal(
+		&childwf.Task{
+			Name: "Move failed SIP",
+			Message: `System error: failed SIP move has failed.
+
+An error occurred while moving the SIP to the failed SIPs directory. Please ask a system administrator to investigate.`,
+			Outcome:     childwf.TaskOutcomeSystemFailure,
+			StartedAt:   testTime,
+			CompletedAt: testTime,
+		},
+		result.Tasks[3],
+	)
+}
+
+func (s *PreprocessingTestSuite) TestFailedSIPPREMISFailure() {
+	s.SetupTest(&config.Config{
+		Preprocessing: config.PreprocessingConfig{FailedSIPsPath: "/failed-sips"},
+	})
+	extractPath := s.identifySIPFailureActivities(
+		temporal.NewNonRetryableError(errors.New("WriteFailedPREMIS: permission denied")),
+		nil,
+	)
+
+	s.env.ExecuteWorkflow(
+		s.workflow.Execute,
+		&childwf.PreprocessingParams{
+			RelativePath: relPath,
+			SIPID:        sipUUID,
+			SIPName:      sipName,
+		},
+	)
+	s.True(s.env.IsWorkflowCompleted())
+
+	relPath, err := filepath.Rel(s.testDir, extractPath)
+	s.NoError(err)
+
+	var result childwf.PreprocessingResult
+	err = s.env.GetWorkflowResult(&result)
+	s.NoError(err)
+	s.Equal(childwf.OutcomeSystemError, result.Outcome)
+	s.Equal(relPath, result.RelativePath)
+	s.Len(result.Tasks, 3)
+	s.Equal(
+		&childwf.Task{
+			Name: "Create failed run premis.xml",
+			Message: `System error: failed run premis.xml creation has failed.
+
+An error occurred while recording the failed run in a premis.xml file. Please ask a system administrator to investigate.`,
+			Outcome:     childwf.TaskOutcomeSystemFailure,
+			StartedAt:   testTime,
+			CompletedAt: testTime,
+		},
+		result.Tasks[2],
+	)
 }
 
 func (s *PreprocessingTestSuite) TestValidationError() {
@@ -901,6 +1362,27 @@ func (s *PreprocessingTestSuite) TestValidationError() {
 	extractPath := filepath.Join(filepath.Dir(s.sipPath), fsutil.BaseNoExt(filepath.Base(sipName)))
 	expectedSIP := s.bornDigitalSIP(extractPath)
 
+	pdfEntry := report.Entry{
+		Check:       activities.ValidateFilesName,
+		Task:        "Validate SIP file formats",
+		Severity:    report.SeverityError,
+		Path:        "content/content/d_0000001/test.pdf",
+		Code:        report.CodeFileFormatInvalid,
+		Message:     "ISO 19005-1:2005 6.1.3-1: The file trailer dictionary shall contain the ID keyword",
+		Tool:        "veraPDF",
+		ToolVersion: "1.24.1",
+	}
+	unidentifiedEntry := report.Entry{
+		Check:       activities.CreateInventoryName,
+		Task:        "Identify SIP file formats",
+		Severity:    report.SeverityError,
+		Path:        "content/content/d_0000001/00000012.bin",
+		Code:        report.CodeFileFormatUnidentified,
+		Message:     `"content/content/d_0000001/00000012.bin" file format could not be identified: no match`,
+		Tool:        "Siegfried",
+		ToolVersion: "1.11.2",
+	}
+
 	// Mock activities.
 	sessionCtx := mock.AnythingOfType("*context.timerCtx")
 	s.env.OnActivity(
@@ -917,6 +1399,17 @@ func (s *PreprocessingTestSuite) TestValidationError() {
 	).Return(
 		&activities.IdentifySIPResult{SIP: expectedSIP}, nil,
 	)
+	s.env.OnActivity(
+		activities.CreateInventoryName,
+		sessionCtx,
+		&activities.CreateInventoryParams{SIP: expectedSIP},
+	).Return(
+		&activities.CreateInventoryResult{
+			Failures: []string{unidentifiedEntry.Message},
+			Entries:  []report.Entry{unidentifiedEntry},
+		},
+		nil,
+	)
 	s.env.OnActivity(
 		activities.ValidateStructureName,
 		sessionCtx,
@@ -972,7 +1465,11 @@ func (s *PreprocessingTestSuite) TestValidationError() {
 		&activities.ValidateFilesParams{SIP: expectedSIP},
 	).Return(
 		&activities.ValidateFilesResult{
-			Failures: []string{`One or more PDF/A files are invalid`},
+			Failures: []string{
+				`"content/content/d_0000001/test.pdf" failed veraPDF validation: ` +
+					"ISO 19005-1:2005 6.1.3-1: The file trailer dictionary shall contain the ID keyword",
+			},
+			Entries: []report.Entry{pdfEntry},
 		},
 		nil,
 	)
@@ -1013,6 +1510,34 @@ func (s *PreprocessingTestSuite) TestValidationError() {
 		&childwf.PreprocessingResult{
 			Outcome:      childwf.OutcomeContentError,
 			RelativePath: relPath,
+			CustomMetadata: reportCustomMetadata(
+				unidentifiedEntry,
+				report.Entry{
+					Check:    ffvalidate.Name,
+					Task:     "Check for disallowed file formats",
+					Severity: report.SeverityError,
+					Code:     report.CodeFileFormatDisallowed,
+					Message:  `file format fmt/11 not allowed: "content/content/d_0000001/00000010.png"`,
+				},
+				report.Entry{
+					Check:    ffvalidate.Name,
+					Task:     "Check for disallowed file formats",
+					Severity: report.SeverityError,
+					Code:     report.CodeFileFormatDisallowed,
+					Message:  `file format fmt/11 not allowed: "content/content/d_0000001/00000011.png"`,
+				},
+				pdfEntry,
+				report.Entry{
+					Check:       xmlvalidate.Name,
+					Task:        "Validate SIP metadata",
+					Severity:    report.SeverityError,
+					Path:        "header/metadata.xml",
+					Code:        report.CodeMetadataInvalid,
+					Message:     "metadata.xml does not match expected metadata requirements",
+					Tool:        "xmllint",
+					ToolVersion: "2.13.8",
+				},
+			),
 			Tasks: []*childwf.Task{
 				{
 					Name:        "Extract SIP",
@@ -1077,11 +1602,24 @@ Please review the SIP and ensure that the metadata checksums match those of the
 					StartedAt:   testTime,
 					CompletedAt: testTime,
 				},
+				{
+					Name: "Identify SIP file formats",
+					Message: `Content error: file format identification has failed.
+
+The format of the following file(s) couldn't be identified by Siegfried 1.11.2:
+
+- "content/content/d_0000001/00000012.bin" file format could not be identified: no match
+
+Please review the SIP and remove or replace the files with an unknown or ambiguous format.`,
+					Outcome:     childwf.TaskOutcomeValidationFailure,
+					StartedAt:   testTime,
+					CompletedAt: testTime,
+				},
 				{
 					Name: "Check for disallowed file formats",
 					Message: `Content error: file format check has failed.
 
-One or more file formats are not allowed:
+One or more file formats identified by Siegfried 1.11.2 are not allowed:
 
 - file format fmt/11 not allowed: "content/content/d_0000001/00000010.png"
 - file format fmt/11 not allowed: "content/content/d_0000001/00000011.png"
@@ -1095,7 +1633,7 @@ Please review the SIP and remove or replace all disallowed file formats.`,
 					Name: "Validate SIP file formats",
 					Message: `Content error: file format validation has failed.
 
-- One or more PDF/A files are invalid
+- "content/content/d_0000001/test.pdf" failed veraPDF validation: ISO 19005-1:2005 6.1.3-1: The file trailer dictionary shall contain the ID keyword
 
 Please ensure all files are well-formed.`,
 					Outcome:     childwf.TaskOutcomeValidationFailure,
@@ -1106,6 +1644,8 @@ Please ensure all files are well-formed.`,
 					Name: "Validate SIP metadata",
 					Message: `Content error: metadata validation has failed.
 
+The following failures were found by xmllint 2.13.8:
+
 - metadata.xml does not match expected metadata requirements
 
 Please ensure all metadata files are present and well-formed.`,
@@ -1120,7 +1660,10 @@ Please ensure all metadata files are present and well-formed.`,
 }
 
 func (s *PreprocessingTestSuite) TestSystemError() {
-	s.SetupTest(&config.Config{})
+	failedSIPsPath := s.T().TempDir()
+	s.SetupTest(&config.Config{
+		Preprocessing: config.PreprocessingConfig{FailedSIPsPath: failedSIPsPath},
+	})
 
 	// Mock activities.
 	s.env.OnActivity(
@@ -1148,8 +1691,9 @@ func (s *PreprocessingTestSuite) TestSystemError() {
 	s.NoError(err)
 	s.Equal(
 		&childwf.PreprocessingResult{
-			Outcome:      childwf.OutcomeSystemError,
-			RelativePath: relPath,
+			Outcome:        childwf.OutcomeSystemError,
+			RelativePath:   relPath,
+			CustomMetadata: reportCustomMetadata(),
 			Tasks: []*childwf.Task{
 				{
 					Name: "Extract SIP",
@@ -1163,10 +1707,16 @@ func (s *PreprocessingTestSuite) TestSystemError() {
 					StartedAt:   testTime,
 					CompletedAt: testTime,
 				},
+				failedRunPREMISTask(),
 			},
 		},
 		&result,
 	)
+
+	// The failed run is recorded in the failed SIPs directory, but the SIP is
+	// left in the shared directory.
+	s.FileExists(filepath.Join(failedSIPsPath, "SIP_20240606_dept-"+sipUUID.String(), "premis.xml"))
+	s.DirExists(s.sipPath)
 }
 
 func (s *PreprocessingTestSuite) TestExtractionError() {
@@ -1196,6 +1746,7 @@ func (s *PreprocessingTestSuite) TestExtractionError() {
 		nil,
 		fmt.Errorf("IdentifySIP: NewSIP: stat : no such file or directory"),
 	)
+	s.removeInventoryActivity(extractPath)
 
 	// Execute workflow.
 	s.env.ExecuteWorkflow(
@@ -1216,6 +1767,19 @@ func (s *PreprocessingTestSuite) TestExtractionError() {
 		&childwf.PreprocessingResult{
 			Outcome:      childwf.OutcomeContentError,
 			RelativePath: relPath,
+			CustomMetadata: reportCustomMetadata(
+				report.Entry{
+					Check:    archiveextract.Name,
+					Task:     "Extract SIP",
+					Severity: report.SeverityError,
+					Code:     report.CodeSIPMissingTopLevelDirectory,
+					Message: fmt.Sprintf(
+						"The extracted SIP is missing the top-level %q folder.",
+						fsutil.BaseNoExt(sipName),
+					),
+				},
+				unidentifiedSIPEntry,
+			),
 			Tasks: []*childwf.Task{
 				{
 					Name: "Extract SIP",
@@ -1348,6 +1912,13 @@ func (s *PreprocessingTestSuite) TestHumanReviewCancelIngest() {
 	s.writeBagitTxt(s.sipPath)
 
 	_, extractPath, apisTaskID := s.preAPISActivities(apisgen.AnalysisResultKonflikte)
+	s.removeCanceledSIPActivity(extractPath, nil)
+	s.cancelAPISActivity(
+		apisTaskID,
+		"Ingest was canceled by user decision after APIS metadata conflict review.",
+		apisDecisionUser,
+		nil,
+	)
 
 	result := s.executeAsChildWithHumanReview(
 		&childwf.PreprocessingParams{
@@ -1357,6 +1928,7 @@ func (s *PreprocessingTestSuite) TestHumanReviewCancelIngest() {
 		},
 		childwf.DecisionResponse{
 			Option: apis.DecisionOptionCancelIngest,
+			User:   apisDecisionUser,
 		},
 	)
 
@@ -1365,18 +1937,158 @@ func (s *PreprocessingTestSuite) TestHumanReviewCancelIngest() {
 
 	s.Equal(
 		&childwf.PreprocessingResult{
-			Outcome:      childwf.OutcomeContentError,
-			RelativePath: updatedRelPath,
-			Tasks: apisTasks(
-				apisTaskID,
-				fmt.Sprintf(
-					`Content error: ingest was canceled after APIS metadata conflict review.
+			Outcome:        childwf.OutcomeCanceled,
+			RelativePath:   updatedRelPath,
+			CustomMetadata: reportCustomMetadata(),
+			Tasks: append(
+				apisTasks(
+					apisTaskID,
+					fmt.Sprintf(
+						"APIS detected metadata conflicts for import task ID %q and ingest was canceled with user decision %q.",
+						apisTaskID,
+						apis.DecisionOptionCancelIngest,
+					),
+					childwf.TaskOutcomeSuccess,
+					false,
+				),
+				removeCanceledSIPTask(),
+				cancelAPISTask(apisTaskID),
+			),
+		},
+		result,
+	)
+}
+
+func (s *PreprocessingTestSuite) TestHumanReviewCancelIngestCancelFailure() {
+	s.SetupTest(&config.Config{
+		APIS: apis.Config{Enabled: true},
+		Preprocessing: config.PreprocessingConfig{
+			CheckDuplicates: true,
+		},
+	})
+	s.writeBagitTxt(s.sipPath)
+
+	_, extractPath, apisTaskID := s.preAPISActivities(apisgen.AnalysisResultKonflikte)
+	s.removeCanceledSIPActivity(extractPath, nil)
+	s.cancelAPISActivity(
+		apisTaskID,
+		"Ingest was canceled by user decision after APIS metadata conflict review.",
+		apisDecisionUser,
+		temporal.NewNonRetryableError(
+			errors.New("cancel APIS import task: conflict: import task has already been imported"),
+		),
+	)
+
+	result := s.executeAsChildWithHumanReview(
+		&childwf.PreprocessingParams{
+			RelativePath: relPath,
+			SIPID:        sipUUID,
+			SIPName:      sipName,
+		},
+		childwf.DecisionResponse{
+			Option: apis.DecisionOptionCancelIngest,
+			User:   apisDecisionUser,
+		},
+	)
+
+	updatedRelPath, err := filepath.Rel(s.testDir, extractPath)
+	s.NoError(err)
 
-APIS detected metadata conflicts for import task ID %q and ingest was canceled by user decision.`,
+	s.Equal(
+		&childwf.PreprocessingResult{
+			Outcome:        childwf.OutcomeSystemError,
+			RelativePath:   updatedRelPath,
+			CustomMetadata: reportCustomMetadata(),
+			Tasks: append(
+				apisTasks(
+					apisTaskID,
+					fmt.Sprintf(
+						"APIS detected metadata conflicts for import task ID %q and ingest was canceled with user decision %q.",
+						apisTaskID,
+						apis.DecisionOptionCancelIngest,
+					),
+					childwf.TaskOutcomeSuccess,
+					false,
+				),
+				removeCanceledSIPTask(),
+				&childwf.Task{
+					Name: "Cancel APIS import task",
+					Message: fmt.Sprintf(
+						`System error: APIS import task cancellation has failed.
+
+An error occurred while canceling APIS import task ID %q. Please cancel the import task in APIS, or ask a system administrator to investigate.`,
+						apisTaskID,
+					),
+					Outcome:     childwf.TaskOutcomeSystemFailure,
+					StartedAt:   testTime,
+					CompletedAt: testTime,
+				},
+			),
+		},
+		result,
+	)
+}
+
+func (s *PreprocessingTestSuite) TestHumanReviewCancelIngestRemovalFailure() {
+	s.SetupTest(&config.Config{
+		APIS: apis.Config{Enabled: true},
+		Preprocessing: config.PreprocessingConfig{
+			CheckDuplicates: true,
+		},
+	})
+	s.writeBagitTxt(s.sipPath)
+
+	_, extractPath, apisTaskID := s.preAPISActivities(apisgen.AnalysisResultKonflikte)
+	s.removeCanceledSIPActivity(extractPath, errors.New("permission denied"))
+	s.removeInventoryActivity(extractPath)
+	s.cancelAPISActivity(
+		apisTaskID,
+		"Ingest was canceled by user decision after APIS metadata conflict review.",
+		apisDecisionUser,
+		nil,
+	)
+
+	result := s.executeAsChildWithHumanReview(
+		&childwf.PreprocessingParams{
+			RelativePath: relPath,
+			SIPID:        sipUUID,
+			SIPName:      sipName,
+		},
+		childwf.DecisionResponse{
+			Option: apis.DecisionOptionCancelIngest,
+			User:   apisDecisionUser,
+		},
+	)
+
+	updatedRelPath, err := filepath.Rel(s.testDir, extractPath)
+	s.NoError(err)
+
+	s.Equal(
+		&childwf.PreprocessingResult{
+			Outcome:        childwf.OutcomeSystemError,
+			RelativePath:   updatedRelPath,
+			CustomMetadata: reportCustomMetadata(),
+			Tasks: append(
+				apisTasks(
 					apisTaskID,
+					fmt.Sprintf(
+						"APIS detected metadata conflicts for import task ID %q and ingest was canceled with user decision %q.",
+						apisTaskID,
+						apis.DecisionOptionCancelIngest,
+					),
+					childwf.TaskOutcomeSuccess,
+					false,
 				),
-				childwf.TaskOutcomeValidationFailure,
-				false,
+				&childwf.Task{
+					Name: "Remove canceled SIP",
+					Message: `System error: canceled SIP removal has failed.
+
+An error occurred while removing the extracted SIP after ingest was canceled. Please ask a system administrator to remove it.`,
+					Outcome:     childwf.TaskOutcomeSystemFailure,
+					StartedAt:   testTime,
+					CompletedAt: testTime,
+				},
+				cancelAPISTask(apisTaskID),
 			),
 		},
 		result,
@@ -1393,6 +2105,7 @@ func (s *PreprocessingTestSuite) TestHumanReviewInvalidOption() {
 	s.writeBagitTxt(s.sipPath)
 
 	_, extractPath, apisTaskID := s.preAPISActivities(apisgen.AnalysisResultKonflikte)
+	s.cancelAPISActivity(apisTaskID, "Ingest was stopped by a system error.", apis.DefaultUser, nil)
 
 	result := s.executeAsChildWithHumanReview(
 		&childwf.PreprocessingParams{
@@ -1410,17 +2123,91 @@ func (s *PreprocessingTestSuite) TestHumanReviewInvalidOption() {
 
 	s.Equal(
 		&childwf.PreprocessingResult{
-			Outcome:      childwf.OutcomeSystemError,
-			RelativePath: updatedRelPath,
-			Tasks: apisTasks(
-				apisTaskID,
-				`System error: submission to APIS has failed.
+			Outcome:        childwf.OutcomeSystemError,
+			RelativePath:   updatedRelPath,
+			CustomMetadata: reportCustomMetadata(),
+			Tasks: append(
+				apisTasks(
+					apisTaskID,
+					`System error: submission to APIS has failed.
 
 Received unsupported user decision "Unexpected option" while resolving APIS metadata conflicts. Please ask a system administrator to investigate.`,
-				childwf.TaskOutcomeSystemFailure,
-				false,
+					childwf.TaskOutcomeSystemFailure,
+					false,
+				),
+				cancelAPISTask(apisTaskID),
 			),
 		},
 		result,
 	)
 }
+
+func (s *PreprocessingTestSuite) TestAPISImportTaskCanceledOnSystemError() {
+	s.SetupTest(&config.Config{
+		APIS: apis.Config{Enabled: true},
+		Preprocessing: config.PreprocessingConfig{
+			CheckDuplicates: true,
+		},
+	})
+	s.writeBagitTxt(s.sipPath)
+
+	expectedSIP, extractPath, apisTaskID := s.preAPISActivities(apisgen.AnalysisResultAlleNeu)
+	s.env.OnActivity(
+		activities.AddPREMISObjectsName,
+		mock.AnythingOfType("*context.timerCtx"),
+		&activities.AddPREMISObjectsParams{
+			SIP:            expectedSIP,
+			PREMISFilePath: filepath.Join(expectedSIP.Path, "metadata", "premis.xml"),
+		},
+	).Return(
+		nil, errors.New("permission denied"),
+	)
+	s.cancelAPISActivity(apisTaskID, "Ingest was stopped by a system error.", apis.DefaultUser, nil)
+
+	s.env.ExecuteWorkflow(
+		s.workflow.Execute,
+		&childwf.PreprocessingParams{
+			RelativePath: relPath,
+			SIPID:        sipUUID,
+			SIPName:      sipName,
+		},
+	)
+	s.True(s.env.IsWorkflowCompleted())
+
+	updatedRelPath, err := filepath.Rel(s.testDir, extractPath)
+	s.NoError(err)
+
+	var result childwf.PreprocessingResult
+	err = s.env.GetWorkflowResult(&result)
+	s.NoError(err)
+	s.Equal(
+		&childwf.PreprocessingResult{
+			Outcome:        childwf.OutcomeSystemError,
+			CustomMetadata: apisCustomMetadata(apisTaskID, ""),
+			RelativePath:   updatedRelPath,
+			Tasks: append(
+				apisTasks(
+					apisTaskID,
+					fmt.Sprintf(
+						`APIS analysis completed for import task ID %q with result %q`,
+						apisTaskID,
+						apisgen.AnalysisResultAlleNeu,
+					),
+					childwf.TaskOutcomeSuccess,
+					false,
+				),
+				&childwf.Task{
+					Name: "Create premis.xml",
+					Message: `System error: premis.xml creation has failed
+
+An error has occurred while attempting to create the premis.xml file and store it in the metadata directory. Please try again, or ask a system administrator to investigate.`,
+					Outcome:     childwf.TaskOutcomeSystemFailure,
+					StartedAt:   testTime,
+					CompletedAt: testTime,
+				},
+				cancelAPISTask(apisTaskID),
+			),
+		},
+		&result,
+	)
+}
@@ -0,0 +1,60 @@
+package workflows
+
+import (
+	"github.com/artefactual-sdps/enduro/pkg/childwf"
+	temporalsdk_workflow "go.temporal.io/sdk/workflow"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/progress"
+)
+
+// progressTracker holds the workflow progress returned by the progress query.
+type progressTracker struct {
+	tasks func() []*childwf.Task
+	store *progress.Store
+	runID string
+}
+
+// trackProgress registers the progress query handler. tasks returns the
+// current workflow task list, and the counters are read from the store where
+// the activities running in this worker record them, so they are never added
+// to the workflow history. The counters aren't part of the workflow state, the
+// query only returns the counters of the activities run by the worker process
+// answering it, since it started. The returned function removes the workflow
+// counters from the store, it must be called when the workflow completes.
+func trackProgress(
+	ctx temporalsdk_workflow.Context,
+	store *progress.Store,
+	tasks func() []*childwf.Task,
+) (func(), error) {
+	t := &progressTracker{
+		tasks: tasks,
+		store: store,
+		runID: temporalsdk_workflow.GetInfo(ctx).WorkflowExecution.RunID,
+	}
+
+	err := temporalsdk_workflow.SetQueryHandler(ctx, progress.QueryType, func() (progress.Status, error) {
+		return t.status(), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return func() { t.store.Delete(t.runID) }, nil
+}
+
+// status returns the workflow progress. The step in progress is the last task
+// not completed yet.
+func (t *progressTracker) status() progress.Status {
+	s := progress.Status{
+		Tasks:    t.tasks(),
+		Counters: t.store.Counters(t.runID),
+	}
+	for i := len(s.Tasks) - 1; i >= 0; i-- {
+		if s.Tasks[i].CompletedAt.IsZero() {
+			s.Step = s.Tasks[i].Name
+			break
+		}
+	}
+
+	return s
+}
@@ -0,0 +1,148 @@
+package workflows
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/artefactual-sdps/enduro/pkg/childwf"
+	"github.com/artefactual-sdps/temporal-activities/removepaths"
+	"go.artefactual.dev/tools/temporal"
+	temporalsdk_workflow "go.temporal.io/sdk/workflow"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/activities"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/config"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/progress"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/report"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/toolinfo"
+)
+
+// Validation is a validation-only ("dry run") variant of the preprocessing
+// workflow. It runs the same SIP validation tasks but never contacts APIS,
+// writes a premis.xml file, restructures or bags the SIP.
+type Validation struct {
+	cfg      config.PreprocessingConfig
+	tools    toolinfo.Tools
+	progress *progress.Store
+}
+
+func NewValidation(cfg config.PreprocessingConfig, tools toolinfo.Tools, store *progress.Store) *Validation {
+	return &Validation{cfg: cfg, tools: tools, progress: store}
+}
+
+// Execute validates the SIP archive found at params.RelativePath and returns
+// the full list of validation tasks. The SIP is copied to a working directory
+// in the shared directory and validated there, the working directory is
+// removed once validation is done, so the original SIP is never modified.
+func (w *Validation) Execute(
+	ctx temporalsdk_workflow.Context,
+	params *childwf.PreprocessingParams,
+) (*childwf.PreprocessingResult, error) {
+	var e error
+	result := &childwf.PreprocessingResult{}
+	logger := temporalsdk_workflow.GetLogger(ctx)
+	logger.Debug("Validation workflow running!", "params", params)
+
+	defer func() {
+		logger.Debug("Validation workflow finished!", "result", result, "error", e)
+	}()
+
+	if params == nil || params.RelativePath == "" {
+		e = temporal.NewNonRetryableError(
+			fmt.Errorf("error calling workflow with unexpected inputs"),
+		)
+		return nil, e
+	}
+	result.RelativePath = params.RelativePath
+
+	untrack, e := trackProgress(ctx, w.progress, func() []*childwf.Task { return result.Tasks })
+	if e != nil {
+		return nil, e
+	}
+	defer untrack()
+
+	rep := report.New(params.SIPID.String(), params.SIPName)
+	defer attachReport(ctx, result, rep)
+
+	sipPath := filepath.Join(w.cfg.SharedPath, filepath.Clean(params.RelativePath))
+	tools, e := recordTools(ctx, w.tools)
+	if e != nil {
+		return nil, e
+	}
+
+	workDir := filepath.Join(
+		w.cfg.SharedPath,
+		fmt.Sprintf("validation-%s", temporalsdk_workflow.GetInfo(ctx).WorkflowExecution.RunID),
+	)
+	copyPath, ok := w.copySIP(ctx, result, sipPath, workDir)
+	if ok {
+		_, _, e = validateSIP(ctx, result, rep, tools, w.cfg.SharedPath, copyPath, params.SIPName)
+	}
+
+	// Remove the working directory and report the original SIP path back, as
+	// the working copy isn't meant to be processed any further.
+	w.removeWorkingCopy(ctx, result, workDir)
+	result.RelativePath = params.RelativePath
+
+	if e != nil {
+		return nil, e
+	}
+
+	return result, nil
+}
+
+// copySIP copies the SIP at path to the workDir working directory and returns
+// the path of the copy, or false if the SIP couldn't be copied.
+func (w *Validation) copySIP(
+	ctx temporalsdk_workflow.Context,
+	result *childwf.PreprocessingResult,
+	path string,
+	workDir string,
+) (string, bool) {
+	logger := temporalsdk_workflow.GetLogger(ctx)
+	task := result.NewTask(temporalsdk_workflow.Now(ctx), "Copy SIP")
+	var copySIP activities.CopySIPResult
+	err := temporalsdk_workflow.ExecuteActivity(
+		withFilesystemActivityOpts(ctx),
+		activities.CopySIPName,
+		&activities.CopySIPParams{Path: path, DestPath: workDir},
+	).Get(ctx, &copySIP)
+	if err != nil {
+		logger.Error("System error", "message", err.Error())
+		result.SystemError(
+			temporalsdk_workflow.Now(ctx),
+			task,
+			"SIP copy has failed.",
+			"An error occurred while copying the SIP to a working directory for validation. Please try again, or ask a system administrator to investigate.",
+		)
+		return "", false
+	}
+	task.Succeed(temporalsdk_workflow.Now(ctx), "Copied the SIP to a working directory")
+
+	return copySIP.Path, true
+}
+
+func (w *Validation) removeWorkingCopy(
+	ctx temporalsdk_workflow.Context,
+	result *childwf.PreprocessingResult,
+	path string,
+) {
+	logger := temporalsdk_workflow.GetLogger(ctx)
+	task := result.NewTask(temporalsdk_workflow.Now(ctx), "Remove working copy")
+	var removePaths removepaths.Result
+	err := temporalsdk_workflow.ExecuteActivity(
+		withFilesystemActivityOpts(ctx),
+		removepaths.Name,
+		&removepaths.Params{Paths: []string{path}},
+	).Get(ctx, &removePaths)
+	if err != nil {
+		logger.Error("System error", "message", err.Error())
+		result.SystemError(
+			temporalsdk_workflow.Now(ctx),
+			task,
+			"Working copy removal has failed.",
+			"An error occurred while removing the working copy of the SIP after validation. Please ask a system administrator to remove it.",
+		)
+		return
+	}
+	task.Succeed(temporalsdk_workflow.Now(ctx), "Removed the working copy of the SIP")
+}
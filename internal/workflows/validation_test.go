@@ -0,0 +1,381 @@
+package workflows_test
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/artefactual-sdps/enduro/pkg/childwf"
+	"github.com/artefactual-sdps/temporal-activities/archiveextract"
+	"github.com/artefactual-sdps/temporal-activities/bagvalidate"
+	"github.com/artefactual-sdps/temporal-activities/removepaths"
+	"github.com/artefactual-sdps/temporal-activities/xmlvalidate"
+	"github.com/stretchr/testify/mock"
+	"go.artefactual.dev/tools/fsutil"
+	"go.artefactual.dev/tools/temporal"
+	temporalsdk_workflow "go.temporal.io/sdk/workflow"
+
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/activities"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/config"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/localact"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/progress"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/report"
+	"github.com/artefactual-sdps/preprocessing-sfa/internal/workflows"
+)
+
+const validationWorkflowName = "preprocessing-validation"
+
+func (s *PreprocessingTestSuite) setupValidation() {
+	cfg := config.PreprocessingConfig{}
+	s.SetupTest(&config.Config{Preprocessing: cfg})
+	cfg.SharedPath = s.testDir
+
+	s.env.RegisterWorkflowWithOptions(
+		workflows.NewValidation(cfg, testTools, s.progress).Execute,
+		temporalsdk_workflow.RegisterOptions{Name: validationWorkflowName},
+	)
+}
+
+// validationActivities mocks every activity expected to run in the validation
+// workflow. Activities that aren't mocked (APIS, PREMIS, restructure and bag
+// creation) would fail, as they are registered without their dependencies.
+// The concurrent validation activities take delay to complete.
+func (s *PreprocessingTestSuite) validationActivities(
+	nameFailures []string,
+	removeErr error,
+	delay time.Duration,
+) {
+	workDir := filepath.Join(s.testDir, "validation-"+testRunID)
+	copyPath := filepath.Join(workDir, filepath.Base(s.sipPath))
+	extractPath := filepath.Join(workDir, fsutil.BaseNoExt(filepath.Base(sipName)))
+	expectedSIP := s.digitizedAIP(extractPath)
+	ctx := mock.AnythingOfType("*context.valueCtx")
+	sessionCtx := mock.AnythingOfType("*context.timerCtx")
+
+	s.env.OnActivity(
+		activities.CopySIPName,
+		sessionCtx,
+		&activities.CopySIPParams{Path: s.sipPath, DestPath: workDir},
+	).Return(
+		&activities.CopySIPResult{Path: copyPath}, nil,
+	)
+	s.env.OnActivity(
+		archiveextract.Name,
+		sessionCtx,
+		&archiveextract.Params{SourcePath: copyPath},
+	).Return(
+		&archiveextract.Result{ExtractPath: extractPath}, nil,
+	)
+	s.env.OnActivity(
+		localact.IsBag,
+		ctx,
+		&localact.IsBagParams{Path: extractPath},
+	).Return(
+		&localact.IsBagResult{IsBag: true}, nil,
+	)
+	s.env.OnActivity(
+		bagvalidate.Name,
+		sessionCtx,
+		&bagvalidate.Params{Path: extractPath},
+	).Return(
+		&bagvalidate.Result{Valid: true}, nil,
+	)
+	s.env.OnActivity(
+		activities.UnbagName,
+		sessionCtx,
+		&activities.UnbagParams{Path: extractPath},
+	).Return(
+		&activities.UnbagResult{Path: extractPath}, nil,
+	)
+	s.env.OnActivity(
+		activities.IdentifySIPName,
+		sessionCtx,
+		&activities.IdentifySIPParams{Path: extractPath},
+	).Return(
+		&activities.IdentifySIPResult{SIP: expectedSIP}, nil,
+	)
+	s.env.OnActivity(
+		activities.CreateInventoryName,
+		sessionCtx,
+		&activities.CreateInventoryParams{SIP: expectedSIP},
+	).Return(
+		&activities.CreateInventoryResult{}, nil,
+	)
+	s.env.OnActivity(
+		activities.ValidateStructureName,
+		sessionCtx,
+		&activities.ValidateStructureParams{SIP: expectedSIP},
+	).Return(
+		&activities.ValidateStructureResult{}, nil,
+	).After(delay)
+	s.env.OnActivity(
+		activities.ValidateSIPNameName,
+		sessionCtx,
+		&activities.ValidateSIPNameParams{SIP: expectedSIP},
+	).Return(
+		&activities.ValidateSIPNameResult{Failures: nameFailures, Entries: sipNameEntries(nameFailures)}, nil,
+	).After(delay)
+	s.env.OnActivity(
+		activities.VerifyManifestName,
+		sessionCtx,
+		&activities.VerifyManifestParams{SIP: expectedSIP},
+	).Return(
+		&activities.VerifyManifestResult{}, nil,
+	).After(delay)
+	s.env.OnActivity(
+		activities.ValidateDigitizationPREMISName,
+		sessionCtx,
+		&activities.ValidateDigitizationPREMISParams{SIP: expectedSIP},
+	).Return(
+		&activities.ValidateDigitizationPREMISResult{Files: 2}, nil,
+	).After(delay)
+	s.env.OnActivity(
+		activities.ValidateFilesName,
+		sessionCtx,
+		&activities.ValidateFilesParams{SIP: expectedSIP},
+	).Return(
+		&activities.ValidateFilesResult{}, nil,
+	).After(delay)
+	s.env.OnActivity(
+		xmlvalidate.Name,
+		sessionCtx,
+		&xmlvalidate.Params{
+			XMLPath: expectedSIP.ManifestPath,
+			XSDPath: expectedSIP.XSDPath,
+		},
+	).Return(
+		&xmlvalidate.Result{}, nil,
+	).After(delay)
+	s.env.OnActivity(
+		activities.ValidatePREMISName,
+		sessionCtx,
+		&activities.ValidatePREMISParams{Path: expectedSIP.LogicalMDPath, SIPPath: expectedSIP.Path},
+	).Return(
+		&activities.ValidatePREMISResult{}, nil,
+	).After(delay)
+
+	var removeResult *removepaths.Result
+	if removeErr == nil {
+		removeResult = &removepaths.Result{}
+	}
+	s.env.OnActivity(
+		removepaths.Name,
+		sessionCtx,
+		&removepaths.Params{Paths: []string{workDir}},
+	).Return(removeResult, removeErr)
+}
+
+var copySIPTask = &childwf.Task{
+	Name:        "Copy SIP",
+	Message:     "Copied the SIP to a working directory",
+	Outcome:     childwf.TaskOutcomeSuccess,
+	StartedAt:   testTime,
+	CompletedAt: testTime,
+}
+
+func sipNameEntries(failures []string) []report.Entry {
+	var entries []report.Entry
+	for _, f := range failures {
+		entries = append(entries, report.Entry{
+			Check:    activities.ValidateSIPNameName,
+			Task:     "Validate SIP name",
+			Severity: report.SeverityError,
+			Code:     report.CodeSIPInvalidName,
+			Message:  f,
+		})
+	}
+
+	return entries
+}
+
+func (s *PreprocessingTestSuite) executeValidation() *childwf.PreprocessingResult {
+	s.env.ExecuteWorkflow(
+		validationWorkflowName,
+		&childwf.PreprocessingParams{
+			RelativePath: relPath,
+			SIPID:        sipUUID,
+			SIPName:      sipName,
+		},
+	)
+	s.True(s.env.IsWorkflowCompleted())
+
+	var result childwf.PreprocessingResult
+	err := s.env.GetWorkflowResult(&result)
+	s.NoError(err)
+
+	return &result
+}
+
+func (s *PreprocessingTestSuite) TestValidationWorkflowSuccess() {
+	s.setupValidation()
+	s.writeBagitTxt(s.sipPath)
+	s.validationActivities(nil, nil, 0)
+
+	result := s.executeValidation()
+
+	// The checksum and duplicate check tasks aren't part of the validation
+	// workflow.
+	tasks := append([]*childwf.Task{copySIPTask}, preAPISEvents[2:]...)
+	tasks = append(tasks, &childwf.Task{
+		Name:        "Remove working copy",
+		Message:     "Removed the working copy of the SIP",
+		Outcome:     childwf.TaskOutcomeSuccess,
+		StartedAt:   testTime,
+		CompletedAt: testTime,
+	})
+
+	s.Equal(
+		&childwf.PreprocessingResult{
+			Outcome:        childwf.OutcomeSuccess,
+			RelativePath:   relPath,
+			CustomMetadata: reportCustomMetadata(),
+			Tasks:          tasks,
+		},
+		result,
+	)
+}
+
+func (s *PreprocessingTestSuite) TestValidationWorkflowContentError() {
+	s.setupValidation()
+	s.writeBagitTxt(s.sipPath)
+	s.validationActivities(
+		[]string{`SIP name "SIP_20240606_dept" violates naming standard`},
+		nil,
+		0,
+	)
+
+	result := s.executeValidation()
+
+	// All validation tasks run even when one of them fails.
+	tasks := append([]*childwf.Task{copySIPTask}, preAPISEvents[2:]...)
+	for idx, t := range tasks {
+		if t.Name == "Validate SIP name" {
+			tasks[idx] = &childwf.Task{
+				Name: "Validate SIP name",
+				Message: `Content error: SIP name validation has failed.
+
+The name used for the package does not match the expected convention for the "DigitizedAIP" type.
+
+- SIP name "SIP_20240606_dept" violates naming standard
+
+Please review the naming conventions specified for this type of SIP.`,
+				Outcome:     childwf.TaskOutcomeValidationFailure,
+				StartedAt:   testTime,
+				CompletedAt: testTime,
+			}
+		}
+	}
+	tasks = append(tasks, &childwf.Task{
+		Name:        "Remove working copy",
+		Message:     "Removed the working copy of the SIP",
+		Outcome:     childwf.TaskOutcomeSuccess,
+		StartedAt:   testTime,
+		CompletedAt: testTime,
+	})
+
+	s.Equal(
+		&childwf.PreprocessingResult{
+			Outcome:      childwf.OutcomeContentError,
+			RelativePath: relPath,
+			CustomMetadata: reportCustomMetadata(
+				sipNameEntries([]string{`SIP name "SIP_20240606_dept" violates naming standard`})...,
+			),
+			Tasks: tasks,
+		},
+		result,
+	)
+}
+
+func (s *PreprocessingTestSuite) TestValidationWorkflowRemovalFailure() {
+	s.setupValidation()
+	s.writeBagitTxt(s.sipPath)
+	s.validationActivities(nil, temporal.NewNonRetryableError(errors.New("permission denied")), 0)
+
+	result := s.executeValidation()
+
+	tasks := append([]*childwf.Task{copySIPTask}, preAPISEvents[2:]...)
+	tasks = append(tasks, &childwf.Task{
+		Name: "Remove working copy",
+		Message: fmt.Sprintf(
+			"System error: Working copy removal has failed.\n\n%s",
+			"An error occurred while removing the working copy of the SIP after validation. Please ask a system administrator to remove it.",
+		),
+		Outcome:     childwf.TaskOutcomeSystemFailure,
+		StartedAt:   testTime,
+		CompletedAt: testTime,
+	})
+
+	s.Equal(
+		&childwf.PreprocessingResult{
+			Outcome:        childwf.OutcomeSystemError,
+			RelativePath:   relPath,
+			CustomMetadata: reportCustomMetadata(),
+			Tasks:          tasks,
+		},
+		result,
+	)
+}
+
+func (s *PreprocessingTestSuite) TestValidationWorkflowRunsChecksConcurrently() {
+	s.setupValidation()
+	s.writeBagitTxt(s.sipPath)
+	s.validationActivities(nil, nil, time.Hour)
+
+	result := s.executeValidation()
+	s.Equal(childwf.OutcomeSuccess, result.Outcome)
+
+	// The validation tasks start together and complete after the slowest
+	// activity, instead of taking an hour each. The digitization PREMIS check
+	// starts once the manifest is verified, the tasks processed after it
+	// complete an hour later.
+	for name, completedAt := range map[string]time.Time{
+		"Validate SIP structure":     testTime.Add(time.Hour),
+		"Validate SIP name":          testTime.Add(time.Hour),
+		"Verify SIP manifest":        testTime.Add(time.Hour),
+		"Verify SIP checksums":       testTime.Add(time.Hour),
+		"Verify digitization PREMIS": testTime.Add(2 * time.Hour),
+		"Validate SIP file formats":  testTime.Add(2 * time.Hour),
+		"Validate SIP metadata":      testTime.Add(2 * time.Hour),
+		"Validate logical metadata":  testTime.Add(2 * time.Hour),
+	} {
+		idx := slices.IndexFunc(result.Tasks, func(t *childwf.Task) bool { return t.Name == name })
+		s.GreaterOrEqual(idx, 0, name)
+		s.Equal(testTime, result.Tasks[idx].StartedAt, name)
+		s.Equal(completedAt, result.Tasks[idx].CompletedAt, name)
+	}
+}
+
+func (s *PreprocessingTestSuite) TestValidationWorkflowProgressQuery() {
+	s.setupValidation()
+	s.writeBagitTxt(s.sipPath)
+	s.validationActivities(nil, nil, time.Hour)
+
+	// Simulate the progress recorded by an activity running in the worker.
+	s.env.RegisterDelayedCallback(func() {
+		s.progress.Set(testRunID, progress.FilesHashed, progress.Counter{Done: 2, Total: 4})
+	}, time.Minute*30)
+
+	var status progress.Status
+	s.env.RegisterDelayedCallback(func() {
+		enc, err := s.env.QueryWorkflow(progress.QueryType)
+		s.NoError(err)
+		s.NoError(enc.Get(&status))
+	}, time.Minute*45)
+
+	result := s.executeValidation()
+	s.Equal(childwf.OutcomeSuccess, result.Outcome)
+
+	// The concurrent validation activities are running, and the workflow is
+	// waiting for the first of them.
+	s.Equal("Validate SIP structure", status.Step)
+	s.Equal(map[string]progress.Counter{progress.FilesHashed: {Done: 2, Total: 4}}, status.Counters)
+	s.Len(status.Tasks, 6)
+	s.Equal("Identify SIP structure", status.Tasks[4].Name)
+	s.Equal(childwf.TaskOutcomeSuccess, status.Tasks[4].Outcome)
+	s.Equal(childwf.TaskOutcomeUnspecified, status.Tasks[5].Outcome)
+
+	// The workflow counters are removed from the store once it completes.
+	s.Nil(s.progress.Counters(testRunID))
+}